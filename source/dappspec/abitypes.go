@@ -0,0 +1,239 @@
+package dappspec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## ABI types
+// Selectors, topics and the keys of a userdoc are hashed from the ABI
+// types of the parameters, which contracts, enums, structs and value
+// types are not: solc hashes `swap(IERC20,Side,uint256)` as
+// `swap(address,uint8,uint256)`. The types every source declares are
+// read before the pages are rendered: a contract or interface is an
+// `address`, an enum a `uint8`, a value type the type it wraps and a
+// struct the tuple of its fields. A signature with a type that is not
+// declared in the sources, or declared differently in two of them, is
+// left out rather than hashed wrong.
+
+// a declared type: what it is, and for a struct its fields
+type abiDeclaration struct {
+	canonical string
+	fields    []Param
+}
+
+var (
+	commentText       = regexp.MustCompile(`(?s)//[^\n]*|/\*.*?\*/`)
+	typeDeclaration   = regexp.MustCompile(`\b(?:(?:abstract\s+)?(contract|interface|library)|(enum)|(struct))\s+(\w+)|\btype\s+(\w+)\s+is\s+([\w.]+)\s*;`)
+	arraySuffix       = regexp.MustCompile(`(?:\[\s*\w*\s*\])+$`)
+	fixedPointMatcher = regexp.MustCompile(`^u?fixed(?:\d+x\d+)?$`)
+)
+
+// Filled before any page is rendered and only read after.
+var (
+	// the types declared in the sources, by name, nil when declared
+	// differently in different places
+	abiTypes map[string]*abiDeclaration
+	// a digest of the above, for the cache
+	abiTypesKey string
+)
+
+// read the types `files` declare, when something hashes signatures
+func scanABITypes(files []string) {
+	abiTypes, abiTypesKey = map[string]*abiDeclaration{}, ""
+	if !config.Events && !config.Methods && !wantsFormat("natspec") && !wantsFormat("json") {
+		return
+	}
+	for _, source := range files {
+		if getLanguage(source) == nil || getLanguage(source).name != "solidity" {
+			continue
+		}
+		code, err := provider.Read(source)
+		if err != nil {
+			continue
+		}
+		if code, err = decodeSource(source, code); err != nil {
+			continue
+		}
+		text := commentText.ReplaceAllString(string(code), " ")
+		for _, m := range typeDeclaration.FindAllStringSubmatchIndex(text, -1) {
+			var name string
+			var decl abiDeclaration
+			switch {
+			case m[2] >= 0:
+				if text[m[2]:m[3]] == "library" {
+					continue
+				}
+				name, decl.canonical = text[m[8]:m[9]], "address"
+			case m[4] >= 0:
+				name, decl.canonical = text[m[8]:m[9]], "uint8"
+			case m[6] >= 0:
+				name = text[m[8]:m[9]]
+				body, _ := braced(text, m[9])
+				decl.fields = structFields(body)
+			default:
+				name, decl.canonical = text[m[10]:m[11]], text[m[12]:m[13]]
+			}
+			declareABIType(name, &decl)
+		}
+	}
+	names := make([]string, 0, len(abiTypes))
+	for name := range abiTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	h := sha256.New()
+	for _, name := range names {
+		t, _ := abiType(name)
+		fmt.Fprintf(h, "%s=%s\n", name, t)
+	}
+	abiTypesKey = hex.EncodeToString(h.Sum(nil))
+}
+
+// record `decl` as the declaration of `name`, unless another one differs
+func declareABIType(name string, decl *abiDeclaration) {
+	seen, ok := abiTypes[name]
+	if !ok {
+		abiTypes[name] = decl
+		return
+	}
+	if seen == nil || seen.canonical != decl.canonical || len(seen.fields) != len(decl.fields) {
+		abiTypes[name] = nil
+		return
+	}
+	for i, f := range seen.fields {
+		if f.Type != decl.fields[i].Type {
+			abiTypes[name] = nil
+			return
+		}
+	}
+}
+
+// the contents of the braces starting at or after `from`
+func braced(s string, from int) (string, bool) {
+	open := strings.IndexByte(s[from:], '{')
+	if open < 0 {
+		return "", false
+	}
+	open += from
+	end := strings.IndexByte(s[open:], '}')
+	if end < 0 {
+		return "", false
+	}
+	return s[open+1 : open+end], true
+}
+
+// the fields of a struct body
+func structFields(body string) []Param {
+	var fields []Param
+	for _, field := range strings.Split(body, ";") {
+		words := strings.Fields(field)
+		if len(words) < 2 {
+			continue
+		}
+		fields = append(fields, Param{Type: strings.Join(words[:len(words)-1], " "), Name: words[len(words)-1]})
+	}
+	return fields
+}
+
+// the canonical ABI type of the parameter type `t`, and whether it is
+// known
+func abiType(t string) (string, bool) {
+	return resolveABIType(t, map[string]bool{})
+}
+
+// `abiType`, with the structs `t` is within, which a struct cannot
+// contain
+func resolveABIType(t string, within map[string]bool) (string, bool) {
+	t = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(t), " payable"))
+	suffix := arraySuffix.FindString(t)
+	base := strings.TrimSpace(strings.TrimSuffix(t, suffix))
+	suffix = strings.Join(strings.Fields(suffix), "")
+	if c := canonicalType(base); elementaryType.MatchString(c) {
+		return c + suffix, true
+	}
+	switch {
+	case base == "byte":
+		return "bytes1" + suffix, true
+	case base == "fixed" || base == "ufixed":
+		return base + "128x18" + suffix, true
+	case fixedPointMatcher.MatchString(base):
+		return base + suffix, true
+	}
+	// `IPool.Side` is the `Side` of `IPool`
+	name := base[strings.LastIndexByte(base, '.')+1:]
+	if !isIdentifier(name) || within[name] {
+		return "", false
+	}
+	decl := abiTypes[name]
+	if decl == nil {
+		return "", false
+	}
+	if decl.fields == nil {
+		if decl.canonical == "" {
+			return "", false
+		}
+		c, ok := resolveABIType(decl.canonical, within)
+		return c + suffix, ok
+	}
+	within[name] = true
+	defer delete(within, name)
+	types := make([]string, len(decl.fields))
+	for i, f := range decl.fields {
+		c, ok := resolveABIType(f.Type, within)
+		if !ok {
+			return "", false
+		}
+		types[i] = c
+	}
+	return "(" + strings.Join(types, ",") + ")" + suffix, true
+}
+
+// `sym` as its selector or topic is hashed from, e.g.
+// `swap(address,uint8,uint256)`, and whether all its types are known
+func abiSignature(sym *Symbol) (string, bool) {
+	types, ok := abiTypeList(sym.Params)
+	if !ok {
+		return "", false
+	}
+	return sym.Name + "(" + strings.Join(types, ",") + ")", true
+}
+
+// the ABI types of `params`, and whether all of them are known
+func abiTypeList(params []Param) ([]string, bool) {
+	types := make([]string, len(params))
+	for i, p := range params {
+		t, ok := abiType(p.Type)
+		if !ok {
+			return nil, false
+		}
+		types[i] = t
+	}
+	return types, true
+}
+
+// `p` as an input of an ABI fragment: a struct is a `tuple` with its
+// fields as components
+func abiInput(p Param) (ABIInput, bool) {
+	t, ok := abiType(p.Type)
+	if !ok {
+		return ABIInput{}, false
+	}
+	in := ABIInput{Name: p.Name, Type: t, Indexed: p.Indexed}
+	base := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(p.Type), arraySuffix.FindString(strings.TrimSpace(p.Type))))
+	decl := abiTypes[base[strings.LastIndexByte(base, '.')+1:]]
+	if decl == nil || decl.fields == nil {
+		return in, true
+	}
+	// the tuple is `t` up to its array suffix
+	in.Type = "tuple" + t[strings.LastIndexByte(t, ')')+1:]
+	for _, f := range decl.fields {
+		c, _ := abiInput(f)
+		in.Components = append(in.Components, c)
+	}
+	return in, true
+}
@@ -0,0 +1,201 @@
+package dappspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ## ABI golden files
+// `testdata/abi/Swap.sol` takes contracts, enums, structs and value types
+// as parameters, and what dappspec hashes from them is compared with the
+// files next to it, written the way solc would have them. Accept a change
+// with
+//
+//	go test -run TestABIGolden -update
+
+// the outputs compared, under the output directory
+var abiOutputs = []string{"events.json", "methods.json", "natspec/Swap.json"}
+
+func TestABIGolden(t *testing.T) {
+	golden, err := filepath.Abs("testdata/abi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := os.ReadFile(filepath.Join(golden, "Swap.sol"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.WriteFile("Swap.sol", code, 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(WithConfig(Config{Events: true, Methods: true}), WithFormats("html", "json", "natspec"), WithHighlighter(plainHighlighter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Generate("Swap.sol"); err != nil {
+		t.Fatal(err)
+	}
+	for _, out := range abiOutputs {
+		got, err := os.ReadFile(filepath.Join("docs", filepath.FromSlash(out)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := filepath.Join(golden, filepath.FromSlash(out))
+		if *update {
+			os.MkdirAll(filepath.Dir(want), 0755)
+			if err := os.WriteFile(want, got, 0644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		expected, err := os.ReadFile(want)
+		if err != nil {
+			t.Fatalf("%v (run with -update to create it)", err)
+		}
+		if !bytes.Equal(got, expected) {
+			t.Errorf("%s differs from %s (run with -update to accept it)", out, want)
+		}
+	}
+	// `--format json` has the same userdoc and devdoc
+	var page struct{ Contracts json.RawMessage }
+	b, err := os.ReadFile(filepath.Join("docs", "json", "Swap.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &page); err != nil {
+		t.Fatal(err)
+	}
+	natspec, _ := os.ReadFile(filepath.Join("docs", "natspec", "Swap.json"))
+	var got, want interface{}
+	json.Unmarshal(page.Contracts, &got)
+	json.Unmarshal(natspec, &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("the contracts of json/Swap.json are not those of natspec/Swap.json")
+	}
+}
+
+// A contract-typed parameter is hashed as an `address`, and an event with
+// a type declared nowhere gets no topic at all.
+func TestEventTopics(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "abi", "events.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events struct{ Events []EventEntry }
+	if err := json.Unmarshal(b, &events); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]string{
+		"Transfer": {"Transfer(address,address,uint256)", "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"},
+		"Swapped":  {"Swapped(address,uint8,uint256)", ""},
+		"Placed":   {"Placed((address,uint8,uint128))", ""},
+		"Paid":     {"", ""},
+	}
+	for _, e := range events.Events {
+		w, ok := want[e.Name]
+		if !ok {
+			continue
+		}
+		delete(want, e.Name)
+		if e.Signature != w[0] {
+			t.Errorf("%s: signature %q, want %q", e.Name, e.Signature, w[0])
+		}
+		if w[1] != "" && e.Topic0 != w[1] {
+			t.Errorf("%s: topic0 %s, want %s", e.Name, e.Topic0, w[1])
+		}
+		if w[0] == "" && (e.Topic0 != "" || e.ABI != nil) {
+			t.Errorf("%s: has a topic or ABI for a type declared nowhere", e.Name)
+		}
+	}
+	for name := range want {
+		t.Errorf("no event %s", name)
+	}
+}
+
+// Functions are keyed by the selector of their ABI signature, and left out
+// when it cannot be known.
+func TestMethodSelectors(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "abi", "methods.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var methods struct{ Methods map[string][]MethodEntry }
+	if err := json.Unmarshal(b, &methods); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"0xa9059cbb":                                    "transfer(address,uint256)",
+		selector("swap(address,uint8,uint256)"):         "swap(address,uint8,uint256)",
+		selector("peek(address)"):                       "peek(address)",
+		selector("place((address,uint8,uint128))"):      "place((address,uint8,uint128))",
+		selector("placeAll((address,uint8,uint128)[])"): "placeAll((address,uint8,uint128)[])",
+	}
+	if len(methods.Methods) != len(want) {
+		t.Errorf("%d selectors, want %d", len(methods.Methods), len(want))
+	}
+	for sel, signature := range want {
+		if entries := methods.Methods[sel]; len(entries) != 1 || entries[0].Signature != signature {
+			t.Errorf("%s: %+v, want %s", sel, entries, signature)
+		}
+	}
+}
+
+// The userdoc and devdoc are keyed as `solc --userdoc --devdoc` keys them,
+// with the interface a parameter is typed as written as an `address`.
+func TestUserdocKeys(t *testing.T) {
+	b, err := os.ReadFile(filepath.Join("testdata", "abi", "natspec", "Swap.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var contracts map[string]struct {
+		Userdoc struct {
+			Methods map[string]json.RawMessage
+			Events  map[string]json.RawMessage
+			Errors  map[string]json.RawMessage
+		}
+		Devdoc struct {
+			Methods        map[string]json.RawMessage
+			StateVariables map[string]struct {
+				Return  string
+				Returns map[string]string
+			}
+		}
+	}
+	if err := json.Unmarshal(b, &contracts); err != nil {
+		t.Fatal(err)
+	}
+	swap := contracts["Swap"]
+	for _, key := range []string{"swap(address,uint8,uint256)", "peek(address)", "place((address,uint8,uint128))", "placeAll((address,uint8,uint128)[])"} {
+		if _, ok := swap.Userdoc.Methods[key]; !ok {
+			t.Errorf("no userdoc method %s", key)
+		}
+	}
+	for _, key := range []string{"swap(address,uint8,uint256)", "peek(address)"} {
+		if _, ok := swap.Devdoc.Methods[key]; !ok {
+			t.Errorf("no devdoc method %s", key)
+		}
+	}
+	if _, ok := swap.Userdoc.Events["Swapped(address,uint8,uint256)"]; !ok {
+		t.Errorf("no userdoc event Swapped(address,uint8,uint256)")
+	}
+	if _, ok := swap.Userdoc.Errors["Slipped((address,uint8,uint128),uint128)"]; !ok {
+		t.Errorf("no userdoc error Slipped((address,uint8,uint128),uint128)")
+	}
+	// solc has the return of a state variable both ways
+	if last := swap.Devdoc.StateVariables["last"]; last.Return != "the price last paid" || last.Returns["_0"] != "the price last paid" {
+		t.Errorf("devdoc of last: %+v", last)
+	}
+	if len(swap.Userdoc.Methods) != 4 {
+		t.Errorf("userdoc methods %v, want the 4 without pay(Vault)", swap.Userdoc.Methods)
+	}
+}
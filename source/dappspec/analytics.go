@@ -0,0 +1,75 @@
+package dappspec
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"sync"
+)
+
+// ## Analytics
+// A single `analytics` setting injects a tracking snippet and, where one
+// is legally required, a consent banner into every page, without forking
+// the template. Plausible and Google Analytics have shortcuts; anything
+// else can be given as raw HTML. All of it is left out in `--no-js` mode.
+
+// `Analytics` configures what is injected
+type Analytics struct {
+	// "plausible", "ga" or empty for a raw `snippet`
+	Provider string `json:"provider,omitempty"`
+	// The site domain (Plausible) or measurement ID (GA)
+	ID string `json:"id,omitempty"`
+	// Raw HTML for the end of `<head>`
+	Snippet string `json:"snippet,omitempty"`
+	// Raw HTML for the start of `<body>`, e.g. a consent banner
+	Consent string `json:"consent,omitempty"`
+}
+
+const plausibleOrigin = "https://plausible.io"
+
+var warnInlineOnce sync.Once
+
+// the HTML for `<head>` and `<body>`
+func analyticsSnippets() (head, body string) {
+	a := config.Analytics
+	if config.NoJS {
+		return "", ""
+	}
+	id := html.EscapeString(a.ID)
+	switch a.Provider {
+	case "plausible":
+		head = fmt.Sprintf(`<script defer data-domain="%s" src="%s/js/script.js"></script>`, id, plausibleOrigin)
+	case "ga":
+		head = fmt.Sprintf(`<script async src="https://www.googletagmanager.com/gtag/js?id=%s"></script>
+<script>window.dataLayer=window.dataLayer||[];function gtag(){dataLayer.push(arguments);}gtag('js',new Date());gtag('config','%s');</script>`, id, id)
+		if config.CSP {
+			warnInlineOnce.Do(func() {
+				log.Println("dappspec: the Google Analytics snippet uses an inline script, which --csp pages block")
+			})
+		}
+	case "":
+	default:
+		warnInlineOnce.Do(func() {
+			log.Printf("dappspec: unknown analytics provider %q", a.Provider)
+		})
+	}
+	if a.Snippet != "" {
+		head += a.Snippet
+	}
+	return head, a.Consent
+}
+
+// origins the analytics provider loads scripts from and reports to,
+// for `--csp`
+func analyticsOrigins() []string {
+	if config.NoJS {
+		return nil
+	}
+	switch config.Analytics.Provider {
+	case "plausible":
+		return []string{plausibleOrigin}
+	case "ga":
+		return []string{"https://www.googletagmanager.com", "https://www.google-analytics.com"}
+	}
+	return nil
+}
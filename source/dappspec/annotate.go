@@ -0,0 +1,94 @@
+package dappspec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ## Annotated diffs
+// The manifest records a fingerprint of the docs and code of every
+// section. `--annotate-diff` compares them with the manifest of another
+// build (given as its docs directory or the manifest itself) and marks the
+// sections that are new or changed since then in the gutter, so a reviewer
+// of an upgrade can read just those. Declarations are matched by name and
+// parameter types, other sections by content alone.
+
+var (
+	sectionsMu sync.Mutex
+	// the fingerprints of this run and earlier ones, by page and key
+	sectionPrints = map[string]map[string]string{}
+	// the fingerprints of the baseline build
+	baseline map[string]map[string]string
+)
+
+// what a section is matched on: its declaration, or its content
+func sectionKey(sec SectionView, print string) string {
+	if sec.symbol == nil {
+		return "#" + print
+	}
+	ds := DocSymbol{Symbol: sec.symbol}
+	return ds.Key()
+}
+
+func sectionPrint(sec SectionView) string {
+	sum := sha256.Sum256([]byte(strings.TrimSpace(string(sec.docsText)) + "\x00" + strings.TrimSpace(string(sec.codeText))))
+	return hex.EncodeToString(sum[:8])
+}
+
+// record the fingerprints of a page, and return how each section differs
+// from the baseline: `new`, `changed` or empty
+func annotateSections(page string, views []SectionView) []string {
+	prints := map[string]string{}
+	changes := make([]string, len(views))
+	for i, sec := range views {
+		print := sectionPrint(sec)
+		key := sectionKey(sec, print)
+		prints[key] = print
+		if baseline == nil {
+			continue
+		}
+		old, ok := baseline[page][key]
+		switch {
+		case sec.symbol == nil && !ok:
+			changes[i] = "changed"
+		case !ok:
+			changes[i] = "new"
+		case old != print:
+			changes[i] = "changed"
+		}
+	}
+	sectionsMu.Lock()
+	sectionPrints[page] = prints
+	sectionsMu.Unlock()
+	return changes
+}
+
+// read the fingerprints of the build at `path`, a docs directory or its
+// manifest
+func loadBaseline(path string) error {
+	if path == "" {
+		return nil
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, filepath.Base(manifestFile()))
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("annotate-diff: %v", err)
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("annotate-diff: %s: %v", path, err)
+	}
+	if m.Sections == nil {
+		return fmt.Errorf("annotate-diff: %s has no section fingerprints; regenerate the baseline with this version of dappspec", path)
+	}
+	baseline = m.Sections
+	return nil
+}
@@ -0,0 +1,39 @@
+# A Pygments lexer for Huff, which Pygments does not ship. dappspec passes
+# it to pygmentize with -x.
+from pygments.lexer import RegexLexer, words
+from pygments.token import Comment, Keyword, Name, Number, Operator, Punctuation, String, Text
+
+__all__ = ['HuffLexer']
+
+
+class HuffLexer(RegexLexer):
+    name = 'Huff'
+    aliases = ['huff']
+    filenames = ['*.huff']
+
+    tokens = {
+        'root': [
+            (r'\s+', Text),
+            (r'//.*?$', Comment.Single),
+            (r'/\*', Comment.Multiline, 'comment'),
+            (r'#(define|include)\b', Keyword.Declaration),
+            (words(('macro', 'fn', 'test', 'function', 'event', 'error', 'constant',
+                    'table', 'jumptable', 'takes', 'returns', 'view', 'pure',
+                    'payable', 'nonpayable'), suffix=r'\b'), Keyword),
+            (r'"[^"]*"', String),
+            (r'0x[0-9a-fA-F]+', Number.Hex),
+            (r'\d+', Number.Integer),
+            (r'\[[A-Za-z_]\w*\]', Name.Constant),
+            (r'<[A-Za-z_]\w*>', Name.Variable),
+            (r'[A-Za-z_]\w*(?=\s*\()', Name.Function),
+            (r'[A-Za-z_]\w*:', Name.Label),
+            (r'[A-Za-z_]\w*', Name),
+            (r'[=+\-*/<>]', Operator),
+            (r'[(){}\[\],;:]', Punctuation),
+        ],
+        'comment': [
+            (r'[^*/]+', Comment.Multiline),
+            (r'\*/', Comment.Multiline, '#pop'),
+            (r'[*/]', Comment.Multiline),
+        ],
+    }
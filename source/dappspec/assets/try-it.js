@@ -0,0 +1,175 @@
+// Calls view functions from their "Try it" forms with `eth_call`.
+// Arguments and results of the elementary ABI types are encoded and
+// decoded here; anything else is shown as the raw return data.
+(function () {
+  "use strict";
+
+  function word(hex) {
+    return hex.padStart(64, "0");
+  }
+
+  function bigWord(value) {
+    var n = BigInt(value);
+    if (n < 0n) {
+      n += 1n << 256n;
+    }
+    if (n < 0n || n >> 256n) {
+      throw new Error(value + " does not fit in 32 bytes");
+    }
+    return word(n.toString(16));
+  }
+
+  function utf8Hex(text) {
+    return Array.from(new TextEncoder().encode(text), function (b) {
+      return b.toString(16).padStart(2, "0");
+    }).join("");
+  }
+
+  function stripHex(value) {
+    var hex = value.trim().replace(/^0x/i, "");
+    if (!/^[0-9a-fA-F]*$/.test(hex) || hex.length % 2) {
+      throw new Error(value + " is not hex");
+    }
+    return hex.toLowerCase();
+  }
+
+  function dynamic(type) {
+    return type === "string" || type === "bytes";
+  }
+
+  function encodeStatic(type, value) {
+    var m;
+    if (type === "address") {
+      var hex = stripHex(value);
+      if (hex.length !== 40) {
+        throw new Error(value + " is not an address");
+      }
+      return word(hex);
+    }
+    if (type === "bool") {
+      return word(/^(true|1)$/i.test(value.trim()) ? "1" : "0");
+    }
+    if ((m = /^bytes(\d+)$/.exec(type))) {
+      var bytes = stripHex(value);
+      if (bytes.length > 2 * m[1]) {
+        throw new Error(value + " is longer than " + type);
+      }
+      return bytes.padEnd(64, "0");
+    }
+    if ((m = /^(u?)int(\d*)$/.exec(type))) {
+      return bigWord(value.trim());
+    }
+    throw new Error("cannot encode " + type);
+  }
+
+  function encodeDynamic(type, value) {
+    var hex = type === "string" ? utf8Hex(value) : stripHex(value);
+    var padded = hex.padEnd(Math.ceil(hex.length / 64) * 64, "0");
+    return bigWord(hex.length / 2) + padded;
+  }
+
+  function encode(types, values) {
+    var head = "";
+    var tail = "";
+    types.forEach(function (type, i) {
+      if (dynamic(type)) {
+        head += bigWord(types.length * 32 + tail.length / 2);
+        tail += encodeDynamic(type, values[i]);
+      } else {
+        head += encodeStatic(type, values[i]);
+      }
+    });
+    return head + tail;
+  }
+
+  function decodeOne(type, data, at) {
+    var chunk = data.substr(at * 2, 64);
+    var m;
+    if (dynamic(type)) {
+      var offset = Number(BigInt("0x" + chunk)) * 2;
+      var length = Number(BigInt("0x" + data.substr(offset, 64))) * 2;
+      var hex = data.substr(offset + 64, length);
+      if (type === "bytes") {
+        return "0x" + hex;
+      }
+      var bytes = new Uint8Array(hex.length / 2);
+      for (var i = 0; i < bytes.length; i++) {
+        bytes[i] = parseInt(hex.substr(i * 2, 2), 16);
+      }
+      return JSON.stringify(new TextDecoder().decode(bytes));
+    }
+    if (type === "address") {
+      return "0x" + chunk.slice(24);
+    }
+    if (type === "bool") {
+      return BigInt("0x" + chunk) ? "true" : "false";
+    }
+    if ((m = /^bytes(\d+)$/.exec(type))) {
+      return "0x" + chunk.slice(0, 2 * m[1]);
+    }
+    if ((m = /^(u?)int(\d*)$/.exec(type))) {
+      var n = BigInt("0x" + chunk);
+      if (!m[1] && n >> 255n) {
+        n -= 1n << 256n;
+      }
+      return n.toString();
+    }
+    return null;
+  }
+
+  function decode(types, data) {
+    var values = [];
+    for (var i = 0; i < types.length; i++) {
+      var value = decodeOne(types[i], data, i * 32);
+      if (value === null) {
+        return "0x" + data;
+      }
+      values.push(value);
+    }
+    return values.join(", ");
+  }
+
+  function call(form) {
+    var output = form.querySelector("output");
+    var inputs = Array.from(form.querySelectorAll("input"));
+    var data;
+    try {
+      data = form.dataset.selector + encode(
+        inputs.map(function (input) { return input.dataset.type; }),
+        inputs.map(function (input) { return input.value; })
+      );
+    } catch (err) {
+      output.textContent = err.message;
+      return;
+    }
+    output.textContent = "…";
+    fetch(form.dataset.rpc, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: JSON.stringify({
+        jsonrpc: "2.0",
+        id: 1,
+        method: "eth_call",
+        params: [{ to: form.dataset.address, data: data }, "latest"]
+      })
+    })
+      .then(function (response) { return response.json(); })
+      .then(function (reply) {
+        if (reply.error) {
+          throw new Error(reply.error.message);
+        }
+        var returns = form.dataset.returns ? form.dataset.returns.split(",") : [];
+        output.textContent = decode(returns, reply.result.replace(/^0x/, ""));
+      })
+      .catch(function (err) {
+        output.textContent = "Error: " + err.message;
+      });
+  }
+
+  document.addEventListener("submit", function (event) {
+    if (event.target.classList.contains("try-it")) {
+      event.preventDefault();
+      call(event.target);
+    }
+  });
+})();
@@ -0,0 +1,84 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ## Audiences
+// One set of comments can serve a public site for integrators and an
+// internal one with operational detail. Lines of docs between
+//
+//	<!-- dappspec:if audience=internal -->
+//	<!-- dappspec:else -->
+//	<!-- dappspec:endif -->
+//
+// are kept only when the site is built for that audience, with
+// `--audience internal` (or `audience`); the `else` part, if any,
+// otherwise. `audience=ops,internal` matches either of them, and
+// `audience!=internal` any other audience, none included. Blocks can be
+// nested, and one left open fails the file.
+
+var audienceDirective = regexp.MustCompile(`^\s*<!--\s*dappspec:(if\s+audience\s*(!?=)\s*([\w,-]+)|else|endif)\s*-->\s*$`)
+
+// whether a condition holds for the configured audience
+func audienceMatches(op, audiences string) bool {
+	in := false
+	for _, a := range strings.Split(audiences, ",") {
+		in = in || a == config.Audience
+	}
+	return in == (op == "=")
+}
+
+// `text` without the lines meant for other audiences
+func selectAudience(text []byte) ([]byte, error) {
+	if !bytes.Contains(text, []byte("dappspec:")) {
+		return text, nil
+	}
+	var out bytes.Buffer
+	// whether each open block, and all those around it, is kept
+	var kept []bool
+	keeping := func() bool { return len(kept) == 0 || kept[len(kept)-1] }
+	for _, line := range bytes.SplitAfter(text, []byte("\n")) {
+		m := audienceDirective.FindSubmatch(bytes.TrimRight(line, "\n"))
+		switch {
+		case m == nil:
+			if keeping() {
+				out.Write(line)
+			}
+		case string(m[1]) == "else":
+			if len(kept) == 0 {
+				return nil, fmt.Errorf("dappspec:else without dappspec:if")
+			}
+			outer := len(kept) == 1 || kept[len(kept)-2]
+			kept[len(kept)-1] = outer && !kept[len(kept)-1]
+		case string(m[1]) == "endif":
+			if len(kept) == 0 {
+				return nil, fmt.Errorf("dappspec:endif without dappspec:if")
+			}
+			kept = kept[:len(kept)-1]
+		default:
+			kept = append(kept, keeping() && audienceMatches(string(m[2]), string(m[3])))
+		}
+	}
+	if len(kept) > 0 {
+		return nil, fmt.Errorf("dappspec:if without dappspec:endif")
+	}
+	return out.Bytes(), nil
+}
+
+// leave out the docs of every section meant for other audiences
+func selectSections(sections *list.List) error {
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		docs, err := selectAudience(sec.docsText)
+		if err != nil {
+			return err
+		}
+		sec.docsText = docs
+	}
+	return nil
+}
@@ -0,0 +1,237 @@
+package dappspec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ## Backlinks
+// `@@name` in docs links to the section declaring `name`: on the same page
+// if it declares one, or else on the first page that does. In return every
+// section lists the sections mentioning it under "Referenced by". That
+// takes knowing every page before rendering the first, so when any source
+// uses `@@` all of them are parsed up front, and the pages are rendered
+// from those parses.
+
+// an `Anchor` is a section of a page
+type Anchor struct {
+	// The path of the page under `docs/`, as in `Token.html`
+	Page string
+	// The id of the section
+	ID string
+}
+
+// the link to `a` from the page `from`
+func (a Anchor) href(from string) string {
+	if a.Page == from {
+		return "#" + a.ID
+	}
+	return pageLink(from, a.Page) + "#" + a.ID
+}
+
+// a `Backlink` is a section referring to another one
+type Backlink struct {
+	Href  string `json:"href"`
+	Label string `json:"label"`
+}
+
+type referrer struct {
+	at    Anchor
+	label string
+}
+
+// a source parsed ahead of rendering
+type scannedSource struct {
+	doc   *Document
+	stats FileStats
+}
+
+// Filled before any page is rendered and only read after.
+var (
+	scanned = map[string]scannedSource{}
+	// the sections declaring each name, per page and across the site
+	pageAnchors = map[string]map[string]Anchor{}
+	siteAnchors = map[string]Anchor{}
+	backlinks   = map[Anchor][]referrer{}
+	// a digest of all of the above, for the cache
+	referencesKey string
+)
+
+// the page `source` is documented on
+func pageOf(source string) string {
+	return outputPath(source)
+}
+
+// the pre-parsed document of `source`, if there is one
+func scannedDocument(source string) (*Document, FileStats) {
+	s, ok := scanned[source]
+	if !ok {
+		return nil, FileStats{}
+	}
+	return s.doc, s.stats
+}
+
+// the parsed document of `source` for the steps after rendering, parsing
+// it again if it was not scanned
+func sourceDocument(source string) (*Document, error) {
+	if doc, _ := scannedDocument(source); doc != nil {
+		return doc, nil
+	}
+	code, err := provider.Read(source)
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := parseDocument(source, code)
+	return doc, err
+}
+
+// the parsed document of `source` for the scans before rendering, kept in
+// `scanned` so the page is rendered from the same parse
+func scanDocument(source string) (*Document, error) {
+	if doc, _ := scannedDocument(source); doc != nil {
+		return doc, nil
+	}
+	code, err := provider.Read(source)
+	if err != nil {
+		return nil, err
+	}
+	doc, stats, err := parseDocument(source, code)
+	if err != nil {
+		return nil, err
+	}
+	scanned[source] = scannedSource{doc, stats}
+	return doc, nil
+}
+
+// parse every source if any of them refers to a symbol or attaches a
+// library (or `orphans` asks for it), and index what
+// declares and what mentions each name
+func scanReferences(files []string) {
+	scanned = map[string]scannedSource{}
+	pageAnchors, siteAnchors = map[string]map[string]Anchor{}, map[string]Anchor{}
+	backlinks, referencesKey = map[Anchor][]referrer{}, ""
+	usingSites = nil
+
+	codes := map[string][]byte{}
+	found := false
+	for _, source := range files {
+		code, err := provider.Read(source)
+		if err != nil {
+			continue
+		}
+		codes[source] = code
+		found = found || referenceRx.Match(code) || usingMatcher.Match(code)
+	}
+	if !found && !scanAll {
+		return
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for source, code := range codes {
+		wg.Add(1)
+		go func(source string, code []byte) {
+			defer wg.Done()
+			doc, stats, err := parseDocument(source, code)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			scanned[source] = scannedSource{doc, stats}
+			mu.Unlock()
+		}(source, code)
+	}
+	wg.Wait()
+
+	type mention struct {
+		name string
+		from referrer
+	}
+	var mentions []mention
+	for _, source := range files {
+		s, ok := scanned[source]
+		if !ok {
+			continue
+		}
+		page := pageOf(source)
+		anchors := map[string]Anchor{}
+		pageAnchors[page] = anchors
+		views := sectionViews(s.doc)
+		scanUsing(source, views)
+		for _, sec := range views {
+			at := Anchor{page, "section-" + sec.Tag}
+			if sec.symbol != nil && !sec.collapsed {
+				if _, ok := anchors[sec.symbol.Name]; !ok {
+					anchors[sec.symbol.Name] = at
+				}
+				if _, ok := siteAnchors[sec.symbol.Name]; !ok {
+					siteAnchors[sec.symbol.Name] = at
+				}
+			}
+			from := referrer{at, sectionLabel(source, sec)}
+			for _, m := range referenceRx.FindAllSubmatch(sec.docsText, -1) {
+				mentions = append(mentions, mention{string(m[1]), from})
+			}
+		}
+	}
+	for _, m := range mentions {
+		target, ok := resolveReference(m.from.at.Page, m.name)
+		if !ok || target == m.from.at {
+			continue
+		}
+		list := backlinks[target]
+		if len(list) == 0 || list[len(list)-1].at != m.from.at {
+			backlinks[target] = append(list, m.from)
+		}
+	}
+
+	// a page shows its incoming links and where its own ones point, and
+	// either can change with any other file
+	b, _ := json.Marshal([]interface{}{siteAnchors, pageAnchors, fmt.Sprint(backlinks), fmt.Sprint(usingSites)})
+	sum := sha256.Sum256(b)
+	referencesKey = hex.EncodeToString(sum[:])
+}
+
+// what a section is called in the lists of backlinks
+func sectionLabel(source string, sec SectionView) string {
+	switch {
+	case sec.symbol == nil:
+		return fmt.Sprintf("%s, section %d", pageTitle(source), sec.Index)
+	case sec.symbol.Contract != "":
+		return sec.symbol.Contract + "." + sec.symbol.Name
+	}
+	return sec.symbol.Name
+}
+
+// the section `name` refers to from `page`
+func resolveReference(page, name string) (Anchor, bool) {
+	if at, ok := pageAnchors[page][name]; ok {
+		return at, true
+	}
+	at, ok := siteAnchors[name]
+	return at, ok
+}
+
+// turn the `@@name`s of rendered docs on `page` into links
+func linkReferences(page string, html []byte) []byte {
+	return referenceRx.ReplaceAllFunc(html, func(m []byte) []byte {
+		name := string(m[2:])
+		at, ok := resolveReference(page, name)
+		if !ok {
+			return referenceRx.ReplaceAll(m, referenceTpl)
+		}
+		return []byte(fmt.Sprintf(`<a href="%s" title="Jump to %s">%s</a>`, at.href(page), name, name))
+	})
+}
+
+// the sections referring to the section `id` of `page`
+func referencedBy(page, id string) []Backlink {
+	var links []Backlink
+	for _, r := range backlinks[Anchor{page, id}] {
+		links = append(links, Backlink{r.at.href(page), r.label})
+	}
+	return links
+}
@@ -0,0 +1,112 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ## Badges
+// `--badges` writes README badges into `docs/badges/`: documentation
+// coverage, the solc versions the sources accept, and their license.
+// Each comes as a ready-made SVG and as a shields.io endpoint JSON file,
+// for `https://img.shields.io/endpoint?url=...`.
+
+// a `Badge` follows the shields.io endpoint schema
+type Badge struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Label         string `json:"label"`
+	Message       string `json:"message"`
+	Color         string `json:"color"`
+}
+
+// shields.io named colors, as hex for the SVGs
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+	"blue":        "#007ec6",
+	"lightgrey":   "#9f9f9f",
+}
+
+func coverageColor(percent int) string {
+	switch {
+	case percent >= 90:
+		return "brightgreen"
+	case percent >= 75:
+		return "green"
+	case percent >= 50:
+		return "yellow"
+	case percent >= 25:
+		return "orange"
+	}
+	return "red"
+}
+
+// the badges for this run
+func runBadges() map[string]Badge {
+	runStatsMu.Lock()
+	defer runStatsMu.Unlock()
+	percent := runStats.Coverage.Percent()
+	badges := map[string]Badge{
+		"coverage": {1, "docs", fmt.Sprintf("%d%%", percent), coverageColor(percent)},
+		"solc":     {1, "solc", "unknown", "lightgrey"},
+		"license":  {1, "license", "unknown", "lightgrey"},
+	}
+	if pragmas := sortedKeys(runStats.Pragmas); len(pragmas) > 0 {
+		badges["solc"] = Badge{1, "solc", strings.Join(pragmas, " | "), "blue"}
+	}
+	if licenses := sortedKeys(runStats.Licenses); len(licenses) > 0 {
+		badges["license"] = Badge{1, "license", strings.Join(licenses, " / "), "blue"}
+	}
+	return badges
+}
+
+// roughly the width of `s` in 11px Verdana, which is what shields uses
+func textWidth(s string) int {
+	return len([]rune(s))*7 + 10
+}
+
+// a flat badge in the style of shields.io
+func badgeSVG(b Badge) string {
+	lw, mw := textWidth(b.Label), textWidth(b.Message)
+	color := badgeColors[b.Color]
+	label, msg := html.EscapeString(b.Label), html.EscapeString(b.Message)
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%[1]d" height="20" role="img" aria-label="%[4]s: %[5]s">
+<title>%[4]s: %[5]s</title>
+<linearGradient id="s" x2="0" y2="100%%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>
+<clipPath id="r"><rect width="%[1]d" height="20" rx="3" fill="#fff"/></clipPath>
+<g clip-path="url(#r)"><rect width="%[2]d" height="20" fill="#555"/><rect x="%[2]d" width="%[3]d" height="20" fill="%[6]s"/><rect width="%[1]d" height="20" fill="url(#s)"/></g>
+<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">
+<text x="%[7]d" y="14">%[4]s</text><text x="%[8]d" y="14">%[5]s</text>
+</g>
+</svg>
+`, lw+mw, lw, mw, label, msg, color, lw/2, lw+mw/2)
+}
+
+func writeBadges() error {
+	if !config.Badges {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Join(outputDir(), "badges"), 0755); err != nil {
+		return err
+	}
+	for name, badge := range runBadges() {
+		b, err := json.Marshal(badge)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(filepath.Join(outputDir(), "badges", name+".json"), append(b, '\n'), 0644); err != nil {
+			return err
+		}
+		if err := writeOutput(filepath.Join(outputDir(), "badges", name+".svg"), []byte(badgeSVG(badge)), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
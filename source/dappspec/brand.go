@@ -0,0 +1,54 @@
+package dappspec
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// ## Logo and favicon
+// `logo` and `favicon` name an image file, copied into `docs/` under its
+// own name, or an absolute URL, used as is. The default templates show the
+// logo above the page and link the favicon from `<head>`.
+
+// the URL a page refers to `image` by
+func brandURL(image string) string {
+	if image == "" || isRemote(image) {
+		return image
+	}
+	return filepath.Base(image)
+}
+
+func isRemote(image string) bool {
+	u, err := url.Parse(image)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
+
+// the origins of remote images, for the content security policy
+func brandOrigins() []string {
+	var origins []string
+	for _, image := range []string{config.Logo, config.Favicon} {
+		if isRemote(image) {
+			u, _ := url.Parse(image)
+			origins = append(origins, u.Scheme+"://"+u.Host)
+		}
+	}
+	return origins
+}
+
+// copy the local images into `docs/`
+func writeBrand() error {
+	for _, image := range []string{config.Logo, config.Favicon} {
+		if image == "" || isRemote(image) {
+			continue
+		}
+		b, err := os.ReadFile(image)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(filepath.Join(outputDir(), filepath.Base(image)), b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
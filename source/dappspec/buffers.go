@@ -0,0 +1,60 @@
+package dappspec
+
+import (
+	"bytes"
+	"sync"
+)
+
+// ## Buffers
+// Flattened files run to tens of thousands of lines, and several are
+// parsed and highlighted at once. The buffers a section is gathered in,
+// and the one Pygments' output is read into, are reused across files
+// instead of growing anew for each; a section keeps its docs and code in
+// a single allocation; and once a page is rendered its sections let go of
+// their HTML, which would otherwise live as long as the run.
+
+// buffers grown past this are left to the garbage collector rather than
+// kept around for files that are rarely as big
+const maxPooledBuffer = 16 << 20
+
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// an empty buffer, to give back with `putBuffer` once nothing refers to
+// its bytes
+func getBuffer() *bytes.Buffer {
+	return bufferPool.Get().(*bytes.Buffer)
+}
+
+func putBuffer(b *bytes.Buffer) {
+	if b.Cap() > maxPooledBuffer {
+		return
+	}
+	b.Reset()
+	bufferPool.Put(b)
+}
+
+// `docs` and `code` copied into one allocation, the first with no room to
+// grow into the second
+func copyText(docs, code []byte) ([]byte, []byte) {
+	text := make([]byte, len(docs)+len(code))
+	n := copy(text, docs)
+	copy(text[n:], code)
+	return text[:n:n], text[n:]
+}
+
+// `b` without any `cut`, removed in place
+func removeAll(b, cut []byte) []byte {
+	i := bytes.Index(b, cut)
+	if i < 0 {
+		return b
+	}
+	out := b[:i]
+	for rest := b[i+len(cut):]; ; {
+		j := bytes.Index(rest, cut)
+		if j < 0 {
+			return append(out, rest...)
+		}
+		out = append(out, rest[:j]...)
+		rest = rest[j+len(cut):]
+	}
+}
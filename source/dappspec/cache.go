@@ -0,0 +1,157 @@
+package dappspec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ## Cache
+// With `--cache` (and always with `--staged`), a file whose source, the
+// settings, the template and the set of documented files are all the
+// same as last time is not parsed or highlighted again: its pages are
+// kept, and its numbers are taken from `docs/.dappspec-cache.json`. This
+// is what makes running dappspec from a pre-commit hook bearable.
+
+// where the cache is kept between runs
+func cacheFile() string {
+	return filepath.Join(outputDir(), ".dappspec-cache.json")
+}
+
+// a `CacheEntry` is what is remembered about a source file
+type CacheEntry struct {
+	Key   string    `json:"key"`
+	Stats FileStats `json:"stats"`
+	// The outputs generated from the file
+	Outputs []string `json:"outputs"`
+}
+
+var (
+	cacheMu sync.Mutex
+	cache   = map[string]*CacheEntry{}
+)
+
+func loadCache() error {
+	if !config.Cache {
+		return nil
+	}
+	b, err := os.ReadFile(cacheFile())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &cache); err != nil {
+		return fmt.Errorf("%s: %v", cacheFile(), err)
+	}
+	return nil
+}
+
+func writeCache() error {
+	if !config.Cache {
+		return nil
+	}
+	cacheMu.Lock()
+	b, err := json.MarshalIndent(cache, "", "  ")
+	cacheMu.Unlock()
+	if err != nil {
+		return err
+	}
+	return writeAtomic(cacheFile(), append(b, '\n'), 0644)
+}
+
+// the sources documented by earlier runs, so a partial run keeps them in
+// the table of contents
+func cachedSources() []string {
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	var list []string
+	for source := range cache {
+		if _, err := os.Stat(source); err == nil {
+			list = append(list, source)
+		}
+	}
+	return list
+}
+
+func uniqueSorted(list []string) []string {
+	seen := map[string]bool{}
+	var out []string
+	for _, s := range list {
+		if !seen[s] {
+			seen[s] = true
+			out = append(out, s)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// everything the pages of `source` depend on
+func cacheKey(source string, code []byte) string {
+	if !config.Cache {
+		return ""
+	}
+	settings, _ := json.Marshal(config)
+	templates, _ := json.Marshal(Partials)
+	h := sha256.New()
+	for _, part := range [][]byte{
+		[]byte(versionString()), settings, []byte(Css), []byte(HTML), templates,
+		[]byte(strings.Join(sources, "\n")), []byte(referencesKey), []byte(titlesKey), []byte(valueTypesKey), []byte(abiTypesKey), []byte(modifiersKey), []byte(testsKey), []byte(source), code, includedFiles(source, code),
+	} {
+		h.Write(part)
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// if `source` is cached under `key` and its pages are still on disk,
+// account for them as if they had just been written
+func reuseCached(source, key string) (bool, error) {
+	if key == "" {
+		return false, nil
+	}
+	cacheMu.Lock()
+	entry, ok := cache[source]
+	cacheMu.Unlock()
+	if !ok || entry.Key != key {
+		return false, nil
+	}
+	pages := make(map[string][]byte, len(entry.Outputs))
+	for _, path := range entry.Outputs {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return false, nil
+		}
+		pages[path] = b
+	}
+	for path, b := range pages {
+		if config.Minify {
+			recordClasses(b)
+		}
+		if err := writeOutput(path, b, 0644); err != nil {
+			return false, err
+		}
+	}
+	recordStats(entry.Stats)
+	reportPage(source, entry.Stats, true)
+	return true, nil
+}
+
+func storeCached(source, key string, stats FileStats, outputs []string) {
+	if key == "" {
+		return
+	}
+	cacheMu.Lock()
+	defer cacheMu.Unlock()
+	cache[source] = &CacheEntry{key, stats, outputs}
+}
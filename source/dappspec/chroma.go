@@ -0,0 +1,88 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+)
+
+// ## Chroma
+// `chroma`, the default highlighter, is Chroma's lexers in dappspec's own
+// process: no Python, no `pygmentize` and no process per page, so a
+// single binary documents a tree on a CI runner or on Windows. Its spans
+// carry the classes Pygments gives the same tokens, so the stylesheet,
+// `--token-classes` and themes see no difference. A language Chroma has
+// no lexer for, like Huff or a `file.py` lexer of the `languages`
+// setting, goes to Pygments instead when the highlighter is left unset,
+// and is only escaped when there is no `pygmentize` either.
+
+// `chromaHighlighter` highlights with the Chroma lexer of the language
+type chromaHighlighter struct{}
+
+func (chromaHighlighter) Highlight(lang *Language, code [][]byte) ([][]byte, error) {
+	lexer := chromaLexer(lang)
+	if lexer == nil {
+		if config.Highlighter != "" {
+			return nil, fmt.Errorf("chroma has no lexer %s (try --highlighter pygments)", lang.name)
+		}
+		if missingCommand(pygmentize()) {
+			return plainHighlighter{true}.Highlight(lang, code)
+		}
+		return pygmentsHighlighter{}.Highlight(lang, code)
+	}
+	// the file is lexed as a whole, so strings and comments that span
+	// sections are still seen as such, and cut at the dividers after
+	text := string(bytes.Join(code, []byte(lang.dividerText)))
+	tokens, err := chroma.Coalesce(lexer).Tokenise(nil, text)
+	if err != nil {
+		return nil, fmt.Errorf("chroma %s: %v", lang.name, err)
+	}
+	buf := getBuffer()
+	defer putBuffer(buf)
+	for token := tokens(); token != chroma.EOF; token = tokens() {
+		writeToken(buf, token)
+	}
+	return ownFragments(splitOutput(lang, buf.Bytes(), len(code))), nil
+}
+
+// the Chroma lexer of `lang`, nil if Chroma has none
+func chromaLexer(lang *Language) chroma.Lexer {
+	if customLexer(lang.name) {
+		return nil
+	}
+	return lexers.Get(lang.name)
+}
+
+// `token` the way Pygments writes it: escaped, in a span with the class
+// of its type for each of its lines, and the line breaks between the
+// spans, which is where the dividers are looked for
+func writeToken(buf *bytes.Buffer, token chroma.Token) {
+	class := pygmentsClass(token.Type)
+	for i, line := range strings.Split(token.Value, "\n") {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		switch {
+		case line == "":
+		case class == "":
+			buf.WriteString(html.EscapeString(line))
+		default:
+			fmt.Fprintf(buf, `<span class="%s">%s</span>`, class, html.EscapeString(line))
+		}
+	}
+}
+
+// the Pygments class of a token type, or that of the nearest type above
+// it that has one
+func pygmentsClass(t chroma.TokenType) string {
+	for _, t := range []chroma.TokenType{t, t.SubCategory(), t.Category()} {
+		if class, ok := chroma.StandardTypes[t]; ok {
+			return class
+		}
+	}
+	return ""
+}
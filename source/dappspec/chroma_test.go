@@ -0,0 +1,87 @@
+package dappspec
+
+import (
+	"html"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// Chroma lexes a file in one go and its output is cut back into the
+// sections at the dividers, each with its own code and nothing else, even
+// after a comment or a string that Chroma ends with the line break.
+func TestChromaSections(t *testing.T) {
+	setupLanguages()
+	for _, lang := range languages {
+		compileLanguage(lang)
+	}
+	code := map[string][]string{
+		".sol": {"contract A {", "    // a comment", "    string s = \"<b>\";\n    uint x = 1;", "}"},
+		".yul": {"object \"A\" {", "    code { let x := 0x20 } // done"},
+		".rs":  {"pub fn f(&self) -> u8 {", "    // x", "    1\n}"},
+	}
+	for ext, sections := range code {
+		lang := languages[ext]
+		if chromaLexer(lang) == nil {
+			t.Errorf("%s: chroma has no lexer %s", ext, lang.name)
+			continue
+		}
+		in := make([][]byte, len(sections))
+		for i, s := range sections {
+			in[i] = []byte(s)
+		}
+		out, err := chromaHighlighter{}.Highlight(lang, in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(out) != len(sections) {
+			t.Fatalf("%s: %d fragments, want %d", ext, len(out), len(sections))
+		}
+		for i, fragment := range out {
+			if text := html.UnescapeString(htmlTag.ReplaceAllString(string(fragment), "")); text != sections[i] {
+				t.Errorf("%s: fragment %d is %q, want %q", ext, i, text, sections[i])
+			}
+			if !strings.Contains(string(fragment), `<span class="`) {
+				t.Errorf("%s: fragment %d is not highlighted: %s", ext, i, fragment)
+			}
+		}
+	}
+}
+
+// A language Chroma has no lexer for is an error only when Chroma was
+// asked for by name.
+func TestChromaUnknownLexer(t *testing.T) {
+	lang := &Language{name: "lexers/huff.py:HuffLexer", symbol: "//"}
+	compileLanguage(lang)
+	defer func(c Config) { config = c }(config)
+	config.Highlighter = "chroma"
+	if _, err := (chromaHighlighter{}).Highlight(lang, [][]byte{[]byte("#define macro MAIN() = {}")}); err == nil || !strings.Contains(err.Error(), "no lexer") {
+		t.Errorf("highlighting with a custom lexer = %v, want no lexer", err)
+	}
+}
+
+// A Chroma style is written as `pygmentize -S` writes the Pygments style
+// of the same name.
+func TestChromaStyle(t *testing.T) {
+	css, err := chromaStyle("friendly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rule := range []string{
+		".highlight { background-color: #f0f0f0 }\n",
+		".highlight .k { color: #007020; font-weight: bold } /* Keyword */\n",
+		".highlight .c1 { color: #60a0b0; font-style: italic } /* CommentSingle */\n",
+	} {
+		if !strings.Contains(css, rule) {
+			t.Errorf("no %q in\n%s", rule, css)
+		}
+	}
+	if !cssRule.MatchString(css) {
+		t.Errorf("the rules cannot be pruned by --minify")
+	}
+	if _, err := chromaStyle("nosuch"); err == nil {
+		t.Errorf("no error for an unknown style")
+	}
+}
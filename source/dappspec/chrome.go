@@ -0,0 +1,66 @@
+package dappspec
+
+import "strings"
+
+// ## Page chrome
+// Everything around the content that is the same for every page of a
+// run, whichever template renders it.
+
+// a `PageChrome` is embedded in the data of every page template
+type PageChrome struct {
+	// The dappspec build that produced the page
+	Version string
+	// Scripts to load, empty in `--no-js` mode
+	Scripts []string
+	// The policy and stylesheet hash in `--csp` mode
+	CSP            string
+	StyleIntegrity string
+	// Raw HTML from the config, for the end of `<head>` and the start
+	// of `<body>`
+	HeadHTML string
+	BodyHTML string
+	// Where the logo and favicon are, if configured
+	Logo    string
+	Favicon string
+	// The `extra` values from the config
+	Extra map[string]interface{}
+	// The way back to `docs/` from pages in subdirectories, as in `../`
+	Root string
+}
+
+func pageChrome() PageChrome {
+	head, body := analyticsSnippets()
+	return PageChrome{
+		Version:        versionString(),
+		Scripts:        pageScripts(),
+		CSP:            contentSecurityPolicy(),
+		StyleIntegrity: styleIntegrity(),
+		HeadHTML:       head,
+		BodyHTML:       body,
+		Logo:           brandURL(config.Logo),
+		Favicon:        brandURL(config.Favicon),
+		Extra:          config.Extra,
+	}
+}
+
+// the chrome of `page`, with local files linked relative to it
+func pageChromeAt(page string) PageChrome {
+	chrome := pageChrome()
+	chrome.Root = pageRoot(page)
+	if chrome.Root == "" {
+		return chrome
+	}
+	local := func(link string) string {
+		if link == "" || isRemote(link) || strings.HasPrefix(link, "/") {
+			return link
+		}
+		return chrome.Root + link
+	}
+	scripts := make([]string, len(chrome.Scripts))
+	for i, s := range chrome.Scripts {
+		scripts[i] = local(s)
+	}
+	chrome.Scripts = scripts
+	chrome.Logo, chrome.Favicon = local(chrome.Logo), local(chrome.Favicon)
+	return chrome
+}
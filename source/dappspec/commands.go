@@ -0,0 +1,78 @@
+package dappspec
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+)
+
+// ## Subcommands
+// Besides documenting the files named on the command line, dappspec has a
+// few helper commands, invoked as `dappspec <command> [args]`.
+
+// a `Command` is one of those helpers
+type Command struct {
+	// what the user types after `dappspec`
+	Name string
+	// one line shown in the usage text
+	Usage string
+	// receives everything after the command name
+	Run func(args []string) error
+}
+
+// a map of all the commands we know
+var commands map[string]*Command
+
+func setupCommands() {
+	commands = make(map[string]*Command)
+	for _, cmd := range []*Command{
+		{"completion", "completion bash|zsh|fish   print a shell completion script", runCompletion},
+		{"publish", "publish --s3|--gcs URL     upload changed files of the output directory to a bucket", runPublish},
+		{"remote", "remote owner/repo[@ref]    document a GitHub repository without a checkout", runRemote},
+		{"serve", "serve [--addr A] files...  serve docs/, rebuilding and reloading on changes", runServe},
+		{"snapshot", "snapshot [flags] files...  generate and record output hashes in " + snapshotFile, runSnapshot},
+		{"verify", "verify [flags] files...    regenerate and fail if the output differs from the snapshot", runVerify},
+		{"fetch-verified", "fetch-verified --address A document a deployed contract's verified source", runFetchVerified},
+		{"install-hook", "install-hook [flags...]    install a git pre-commit hook running dappspec --staged", runInstallHook},
+		{"orphans", "orphans [flags] files...   report unreferenced declarations and unreachable pages", runOrphans},
+		{"release-notes", "release-notes FROM TO      print Markdown notes on public API changes between two refs", runReleaseNotes},
+	} {
+		commands[cmd.Name] = cmd
+	}
+}
+
+// names of all the commands, sorted
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// if the first argument names a command, run it and report whether we did
+func dispatchCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+	if err := cmd.Run(args[1:]); err != nil {
+		log.Println("dappspec: ", err)
+		os.Exit(1)
+	}
+	return true
+}
+
+// print the commands after the flag defaults
+func commandUsage() {
+	out := flagOutput()
+	fmt.Fprintln(out, "\nCommands:")
+	for _, name := range commandNames() {
+		fmt.Fprintln(out, "  dappspec", commands[name].Usage)
+	}
+}
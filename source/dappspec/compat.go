@@ -0,0 +1,107 @@
+package dappspec
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+)
+
+// ## Compatibility
+// On top of the symbol diff, changes to the public API are classified as
+// breaking or not. Removing a function, event or error is breaking, and
+// so is changing its parameter types, since that removes the old
+// signature. Keeping the signature but changing what it returns, hiding
+// it, or making it stop accepting ether is breaking too. With
+// `release-notes --check-compat` every breaking change has to be
+// acknowledged with a `@custom:breaking` tag, on the new declaration or,
+// for removals, on the contract (`@custom:breaking burn was removed`).
+
+var breakingTag = regexp.MustCompile(`@custom:breaking\b`)
+
+func returnTypes(sym *DocSymbol) string {
+	types := make([]string, len(sym.Returns))
+	for i, p := range sym.Returns {
+		types[i] = canonicalType(p.Type)
+	}
+	return strings.Join(types, ",")
+}
+
+func indexedParams(sym *DocSymbol) string {
+	var b strings.Builder
+	for _, p := range sym.Params {
+		if p.Indexed {
+			b.WriteByte('1')
+		} else {
+			b.WriteByte('0')
+		}
+	}
+	return b.String()
+}
+
+func isVisible(sym *DocSymbol) bool {
+	return sym.Visibility == "" || sym.Visibility == "external" || sym.Visibility == "public"
+}
+
+// whether a change to the public API breaks existing callers
+func (c *Change) Breaking() bool {
+	if !isPublicAPI(c.Symbol()) {
+		return false
+	}
+	switch c.Kind {
+	case "removed":
+		return true
+	case "changed":
+		o, n := c.Old, c.New
+		return returnTypes(o) != returnTypes(n) ||
+			indexedParams(o) != indexedParams(n) ||
+			(isVisible(o) && !isVisible(n)) ||
+			(o.Mutability == "payable" && n.Mutability != "payable")
+	}
+	return false
+}
+
+// whether a breaking change carries a `@custom:breaking` tag, given the
+// declarations of the new version of its file
+func acknowledged(c *Change, current map[string]*DocSymbol) bool {
+	if c.New != nil {
+		return breakingTag.MatchString(c.New.Docs)
+	}
+	name := regexp.MustCompile(`\b` + regexp.QuoteMeta(c.Old.Name) + `\b`)
+	for _, sym := range current {
+		if !breakingTag.MatchString(sym.Docs) {
+			continue
+		}
+		// a replacement of the same name, or its contract mentioning it
+		if sym.Name == c.Old.Name || (sym.Name == c.Old.Contract && name.MatchString(sym.Docs)) {
+			return true
+		}
+	}
+	return false
+}
+
+// report breaking changes without an acknowledgement, returning an error
+// if there are any
+func checkCompat(to string, changes map[string][]*Change) error {
+	var missing int
+	for source, list := range changes {
+		var current map[string]*DocSymbol
+		for _, c := range list {
+			if !c.Breaking() {
+				continue
+			}
+			if current == nil {
+				current = docSymbols(source, fileAt(to, repoPath(source)))
+			}
+			if acknowledged(c, current) {
+				continue
+			}
+			missing++
+			log.Printf("dappspec: %s: breaking change without @custom:breaking: %s %s", repoPath(source), c.Kind, c.Symbol().Signature)
+		}
+	}
+	if missing > 0 {
+		return fmt.Errorf("%d unacknowledged breaking change(s)", missing)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package dappspec
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// ## Shell completion
+// `dappspec completion <shell>` prints a completion script built from the
+// registered flags and commands, so it never goes stale as the CLI grows.
+
+type completionData struct {
+	Flags    []string
+	Commands []string
+}
+
+var completionScripts = map[string]string{
+	"bash": `# bash completion for dappspec
+_dappspec() {
+    local cur="${COMP_WORDS[COMP_CWORD]}"
+    if [[ "$cur" == -* ]]; then
+        COMPREPLY=($(compgen -W "{{ join .Flags " " }}" -- "$cur"))
+    elif [[ $COMP_CWORD -eq 1 ]]; then
+        COMPREPLY=($(compgen -W "{{ join .Commands " " }}" -- "$cur") $(compgen -f -- "$cur"))
+    else
+        COMPREPLY=($(compgen -f -- "$cur"))
+    fi
+}
+complete -o filenames -F _dappspec dappspec
+`,
+	"zsh": `#compdef dappspec
+_dappspec() {
+    local -a flags cmds
+    flags=({{ range .Flags }}'{{ . }}' {{ end }})
+    cmds=({{ range .Commands }}'{{ . }}' {{ end }})
+    if [[ "$words[CURRENT]" == -* ]]; then
+        compadd -a flags
+    elif (( CURRENT == 2 )); then
+        compadd -a cmds
+        _files
+    else
+        _files
+    fi
+}
+compdef _dappspec dappspec
+`,
+	"fish": `# fish completion for dappspec
+{{ range .Commands }}complete -c dappspec -n '__fish_use_subcommand' -a '{{ . }}'
+{{ end }}{{ range .Flags }}complete -c dappspec -l '{{ trim . }}'
+{{ end }}`,
+}
+
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: dappspec completion bash|zsh|fish")
+	}
+	return writeCompletion(os.Stdout, args[0])
+}
+
+func writeCompletion(w io.Writer, shell string) error {
+	script, ok := completionScripts[shell]
+	if !ok {
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	data := completionData{Commands: commandNames()}
+	commandLine.VisitAll(func(f *flag.Flag) {
+		data.Flags = append(data.Flags, "--"+f.Name)
+	})
+	t, err := template.New(shell).Funcs(template.FuncMap{
+		"join": strings.Join,
+		"trim": func(s string) string { return strings.TrimPrefix(s, "--") },
+	}).Parse(script)
+	if err != nil {
+		return err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, data); err != nil {
+		return err
+	}
+	_, err = w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,418 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// ## Configuration
+// Per-project settings live in `dappspec.json` next to where dappspec is
+// run (or wherever `--config` points). Flags given on the command line win
+// over the file.
+
+// a `Config` holds the project settings
+type Config struct {
+	// How sections are grouped within a page: "" (not at all) or "kind"
+	GroupBy string `json:"groupBy,omitempty"`
+	// How sections are ordered within a group: "source" or "alpha"
+	Order string `json:"order,omitempty"`
+	// Also write the condensed reference view
+	Reference bool `json:"reference,omitempty"`
+	// Script URLs added to every page
+	Scripts []string `json:"scripts,omitempty"`
+	// Markdown walkthroughs to write to docs/guides/, embedding sections
+	// with `{{include Contract.name}}`
+	Guides []string `json:"guides,omitempty"`
+	// Leave every script out, whatever else asks for one
+	NoJS bool `json:"noJS,omitempty"`
+	// Only use what a strict Content Security Policy allows
+	CSP bool `json:"csp,omitempty"`
+	// Directory with a custom template, stylesheet and assets
+	Theme string `json:"theme,omitempty"`
+	// Width of a tab in the code column
+	TabWidth int `json:"tabWidth,omitempty"`
+	// Replace tabs with spaces in the code column
+	ExpandTabs bool `json:"expandTabs,omitempty"`
+	// Long lines in the code column: "scroll" or "wrap"
+	CodeWrap string `json:"codeWrap,omitempty"`
+	// Minify pages and the stylesheet
+	Minify bool `json:"minify,omitempty"`
+	// Write .gz and .br siblings of every text asset
+	Precompress bool `json:"precompress,omitempty"`
+	// What to run once the documentation has been generated
+	Hooks Hooks `json:"hooks,omitempty"`
+	// A tracking snippet and consent banner for every page
+	Analytics Analytics `json:"analytics,omitempty"`
+	// Where the sources are hosted
+	Repo Repo `json:"repo,omitempty"`
+	// List the git contributors of each file at the bottom of its page
+	Contributors bool `json:"contributors,omitempty"`
+	// Write coverage, solc and license badges into docs/badges
+	Badges bool `json:"badges,omitempty"`
+	// Where the docs will be served from, e.g. https://docs.example.com
+	BaseURL string `json:"baseURL,omitempty"`
+	// The Atom feed of documentation changes
+	Feed Feed `json:"feed,omitempty"`
+	// Collapse dependencies and duplicates in flattened sources
+	Dedupe bool `json:"dedupe,omitempty"`
+	// Draw the import graph on its own page
+	Imports bool `json:"imports,omitempty"`
+	// Where relative links in comments go: `pages`, `repo` or `off`
+	Links string `json:"links,omitempty"`
+	// Compile the snippets of `@custom:example` tags with solc
+	CheckExamples bool `json:"checkExamples,omitempty"`
+	// Forms calling the view functions of deployed contracts
+	TryIt TryIt `json:"tryIt,omitempty"`
+	// Write every event to docs/events.json, for indexers
+	Events bool `json:"events,omitempty"`
+	// Write a selector registry of the external functions to docs/methods.json
+	Methods bool `json:"methods,omitempty"`
+	// Mark the sections new or changed since this build (docs directory
+	// or manifest)
+	AnnotateDiff string `json:"annotateDiff,omitempty"`
+	// A template for the path of each page under the output directory, like
+	// `{{.Dir}}/{{.Contract | kebab}}.html`
+	OutputName string `json:"outputName,omitempty"`
+	// Show the notice, kind and coverage of each file in the table of
+	// contents
+	TOCSummary bool `json:"tocSummary,omitempty"`
+	// Report what is risky to leave undocumented, like a `receive`
+	// without docs
+	Lint bool `json:"lint,omitempty"`
+	// Write a dashboard of documentation coverage and complexity to
+	// docs/overview.html
+	Overview bool `json:"overview,omitempty"`
+	// How many pages are rendered at once, all of them if 0
+	Jobs int `json:"jobs,omitempty"`
+	// Fail the run when `--lint` reports anything; implies `--lint`
+	Strict bool `json:"strict,omitempty"`
+	// Render every file even if some fail, and fail at the end
+	KeepGoing bool `json:"keepGoing,omitempty"`
+	// Overwrite files in the output directory that dappspec did not
+	// generate; `--force` only, as it is a decision for one run
+	Force bool `json:"-"`
+	// The Pygments command, `pygmentize` if empty
+	Pygmentize string `json:"pygmentize,omitempty"`
+	// What highlights the code: `chroma` (if empty), `pygments`, `none`
+	// or `command`, which runs `HighlightCommand`
+	Highlighter      string   `json:"highlighter,omitempty"`
+	HighlightCommand []string `json:"highlightCommand,omitempty"`
+	// A highlighter of the program's own, over the above
+	HighlightWith Highlighter `json:"-"`
+	// Chroma styles (Pygments' with `pygments` or `command`) to color the
+	// code with, for light and dark appearances
+	HighlightStyle     string `json:"highlightStyle,omitempty"`
+	HighlightDarkStyle string `json:"highlightDarkStyle,omitempty"`
+	// Mark Solidity keywords with `--highlighter none`
+	PlainKeywords bool `json:"plainKeywords,omitempty"`
+	// Give token spans stable classes like `tok-keyword` as well
+	TokenClasses bool `json:"tokenClasses,omitempty"`
+	// Where to write the report of the run, as JSON
+	Report string `json:"report,omitempty"`
+	// A file to add the numbers of every run to, a line of JSON each
+	MetricsAppend string `json:"metricsAppend,omitempty"`
+	// Also document the Foundry tests under test/ and scripts under
+	// script/, under docs/tests/
+	FoundryTests bool `json:"foundryTests,omitempty"`
+	// The directories and files to find the tests of each function in
+	Tests []string `json:"tests,omitempty"`
+	// A JSON file mapping functions to tests, as `Contract.function` to
+	// `file:name`
+	TestMap string `json:"testMap,omitempty"`
+	// Where the sources come from, instead of the working tree
+	Ref     string `json:"-"`
+	Archive string `json:"-"`
+	// Where the docs go, `docs` if empty; the `docs/` in the comments
+	// above and below stands for it
+	Out string `json:"out,omitempty"`
+	// The directory the pages are laid out from, the working directory
+	// if empty
+	Root string `json:"root,omitempty"`
+	// Output formats, `html` if empty
+	Formats []string `json:"formats,omitempty"`
+	// Draw a preview image per page for link unfurling
+	SocialCards bool `json:"socialCards,omitempty"`
+	// Image files (or URLs) for the page header and the browser tab
+	Logo    string `json:"logo,omitempty"`
+	Favicon string `json:"favicon,omitempty"`
+	// Languages forced on files matching a glob, by extension or name
+	LanguageFor map[string]string `json:"languageFor,omitempty"`
+	// Lexer settings (and new languages) by file extension
+	Languages map[string]*LanguageConfig `json:"languages,omitempty"`
+	// The tags to leave out of every output, as `custom:internal`
+	RedactTags []string `json:"redactTags,omitempty"`
+	// Glob patterns of more sources to document, and of sources to leave
+	// out
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
+	// Number contracts and their sections across the site
+	NumberSections bool `json:"numberSections,omitempty"`
+	// Who the site is for, keeping the docs under `dappspec:if
+	// audience=...` meant for them
+	Audience string `json:"audience,omitempty"`
+	// The title, slug and order of the page of each source, by path
+	Pages map[string]*PageMeta `json:"pages,omitempty"`
+	// Title and name the page of `docs_Token.sol` as `Token`, as older
+	// versions did
+	StripDocsPrefix bool `json:"stripDocsPrefix,omitempty"`
+	// The values of `{{var "name"}}` in docs
+	Vars map[string]string `json:"vars,omitempty"`
+	// Arbitrary values for custom templates, as `.Extra`
+	Extra map[string]interface{} `json:"extra,omitempty"`
+	// Directory of template blocks to override
+	Partials string `json:"partials,omitempty"`
+	// Only document files staged in git
+	Staged bool `json:"-"`
+	// Skip files that are unchanged since the last run
+	Cache bool `json:"cache,omitempty"`
+}
+
+// the command highlighting code
+func pygmentize() string {
+	if config.Pygmentize != "" {
+		return config.Pygmentize
+	}
+	return "pygmentize"
+}
+
+// the base URL without a trailing slash
+func baseURL() string {
+	return strings.TrimSuffix(config.BaseURL, "/")
+}
+
+// `Hooks` run after a successful build
+type Hooks struct {
+	// A shell command
+	Command string `json:"command,omitempty"`
+	// A URL that receives the build report as a JSON POST
+	URL string `json:"url,omitempty"`
+}
+
+// the scripts to put on a page
+func pageScripts() []string {
+	if config.NoJS {
+		return nil
+	}
+	if tryItEnabled() {
+		return append(append([]string{}, config.Scripts...), tryItScript)
+	}
+	return config.Scripts
+}
+
+// the settings for this run
+var config Config
+
+const defaultConfigFile = "dappspec.json"
+
+// read the config file. A missing default file is fine, a missing file
+// that was asked for explicitly is not.
+func loadConfig(path string) error {
+	explicit := path != ""
+	if !explicit {
+		path = defaultConfigFile
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) && !explicit {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, &config)
+}
+
+// a flag of `name=value` pairs, which can be given more than once
+type pairsFlag map[string]string
+
+func (e pairsFlag) String() string { return "" }
+
+func (e pairsFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, "=")
+	if !ok || name == "" {
+		return fmt.Errorf("expected name=value, got %q", s)
+	}
+	e[name] = value
+	return nil
+}
+
+var (
+	extraValues = pairsFlag{}
+	varValues   = pairsFlag{}
+	lexerValues = pairsFlag{}
+)
+
+// copy flags the user actually set over the config file values
+func applyFlags() {
+	commandLine.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "group-by":
+			config.GroupBy = *groupBy
+		case "order":
+			config.Order = *sectionOrder
+		case "reference":
+			config.Reference = *reference
+		case "no-js":
+			config.NoJS = *noJS
+		case "csp":
+			config.CSP = *csp
+		case "theme":
+			config.Theme = *theme
+		case "tab-width":
+			config.TabWidth = *tabWidthFlag
+		case "expand-tabs":
+			config.ExpandTabs = *expandTabsFlag
+		case "code-wrap":
+			config.CodeWrap = *codeWrap
+		case "minify":
+			config.Minify = *minify
+		case "precompress":
+			config.Precompress = *precompress
+		case "post-build-command":
+			config.Hooks.Command = *hookCommand
+		case "post-build-url":
+			config.Hooks.URL = *hookURL
+		case "repo-url":
+			config.Repo.URL = *repoURL
+		case "repo-branch":
+			config.Repo.Branch = *repoBranchFlag
+		case "contributors":
+			config.Contributors = *contributorsFlag
+		case "badges":
+			config.Badges = *badges
+		case "base-url":
+			config.BaseURL = *baseURLFlag
+		case "feed":
+			config.Feed.Enabled = *feed
+		case "staged":
+			config.Staged = *staged
+			config.Cache = config.Cache || *staged
+		case "dedupe":
+			config.Dedupe = *dedupe
+		case "imports":
+			config.Imports = *imports
+		case "links":
+			config.Links = *links
+		case "check-examples":
+			config.CheckExamples = *exampleCheck
+		case "events":
+			config.Events = *eventsFlag
+		case "methods":
+			config.Methods = *methodsFlag
+		case "annotate-diff":
+			config.AnnotateDiff = *annotateDiff
+		case "output-name":
+			config.OutputName = *outputNameFlag
+		case "toc-summary":
+			config.TOCSummary = *tocSummary
+		case "lint":
+			config.Lint = *lintFlag
+		case "overview":
+			config.Overview = *overviewFlag
+		case "jobs":
+			config.Jobs = *jobsFlag
+		case "strict":
+			config.Strict = *strictFlag
+		case "keep-going":
+			config.KeepGoing = *keepGoingFlag
+		case "force":
+			config.Force = *force
+		case "highlighter":
+			config.Highlighter = *highlighterFlag
+		case "highlight-style":
+			config.HighlightStyle = *highlightStyle
+		case "highlight-dark-style":
+			config.HighlightDarkStyle = *darkStyle
+		case "plain-keywords":
+			config.PlainKeywords = *plainKeywords
+		case "token-classes":
+			config.TokenClasses = *tokenClassFlag
+		case "report":
+			config.Report = *reportFlag
+		case "metrics-append":
+			config.MetricsAppend = *metricsAppend
+		case "foundry-tests":
+			config.FoundryTests = *foundryFlag
+		case "tests":
+			config.Tests = strings.Split(*testsFlag, ",")
+		case "test-map":
+			config.TestMap = *testMapFlag
+		case "rpc-url":
+			config.TryIt.RPC = *rpcURL
+		case "address":
+			if config.TryIt.Addresses == nil {
+				config.TryIt.Addresses = map[string]string{}
+			}
+			for contract, address := range addressValues {
+				config.TryIt.Addresses[contract] = address
+			}
+		case "ref":
+			config.Ref = *ref
+		case "archive":
+			config.Archive = *archive
+		case "format":
+			config.Formats = strings.Split(*formatList, ",")
+		case "social-cards":
+			config.SocialCards = *socialCards
+		case "logo":
+			config.Logo = *logo
+		case "favicon":
+			config.Favicon = *favicon
+		case "language":
+			if config.LanguageFor == nil {
+				config.LanguageFor = map[string]string{}
+			}
+			for pattern, name := range languageValues {
+				config.LanguageFor[pattern] = name
+			}
+		case "lexer":
+			if config.Languages == nil {
+				config.Languages = map[string]*LanguageConfig{}
+			}
+			for ext, lexer := range lexerValues {
+				if config.Languages[ext] == nil {
+					config.Languages[ext] = &LanguageConfig{}
+				}
+				config.Languages[ext].Lexer = lexer
+			}
+		case "redact-tags":
+			config.RedactTags = strings.Split(*redactTags, ",")
+		case "out":
+			config.Out = *outFlag
+		case "root":
+			config.Root = *rootFlag
+		case "include":
+			config.Include = strings.Split(*includeFlag, ",")
+		case "exclude":
+			config.Exclude = strings.Split(*excludeFlag, ",")
+		case "number-sections":
+			config.NumberSections = *numberSections
+		case "audience":
+			config.Audience = *audienceFlag
+		case "strip-docs-prefix":
+			config.StripDocsPrefix = *docsPrefixFlag
+		case "var":
+			if config.Vars == nil {
+				config.Vars = map[string]string{}
+			}
+			for name, value := range varValues {
+				config.Vars[name] = value
+			}
+		case "extra":
+			if config.Extra == nil {
+				config.Extra = map[string]interface{}{}
+			}
+			for name, value := range extraValues {
+				config.Extra[name] = value
+			}
+		case "partials":
+			config.Partials = *partials
+		case "cache":
+			config.Cache = *useCache
+		}
+	})
+}
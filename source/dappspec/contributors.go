@@ -0,0 +1,65 @@
+package dappspec
+
+import (
+	"crypto/md5"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ## Contributors
+// With `--contributors`, each page ends with the people who touched its
+// source file according to `git shortlog`. It is off by default because
+// it makes the output depend on the history, not just the sources.
+
+// a `Contributor` is one author of a file
+type Contributor struct {
+	Name    string
+	Commits int
+	// A profile link, if one can be derived from the email
+	URL       string
+	AvatarURL string
+}
+
+var (
+	shortlogLine = regexp.MustCompile(`^\s*(\d+)\s+(.*?)\s+<([^>]*)>\s*$`)
+	// 12345+octocat@users.noreply.github.com or octocat@users.noreply.github.com
+	githubNoreply = regexp.MustCompile(`^(?:\d+\+)?([^@]+)@users\.noreply\.github\.com$`)
+)
+
+// the origins avatars are loaded from, for `--csp`
+var avatarOrigins = []string{"https://github.com", "https://avatars.githubusercontent.com", "https://www.gravatar.com"}
+
+// the contributors of `source`, most commits first
+func contributors(source string) []*Contributor {
+	if !config.Contributors {
+		return nil
+	}
+	cmd := exec.Command("git", "shortlog", "-sne", "HEAD", "--", filepath.Base(source))
+	cmd.Dir = filepath.Dir(source)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil
+	}
+	var list []*Contributor
+	for _, line := range strings.Split(string(out), "\n") {
+		m := shortlogLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(m[1])
+		c := &Contributor{Name: m[2], Commits: n}
+		email := strings.ToLower(strings.TrimSpace(m[3]))
+		if gh := githubNoreply.FindStringSubmatch(email); gh != nil {
+			c.URL = "https://github.com/" + gh[1]
+			c.AvatarURL = c.URL + ".png?size=40"
+		} else if email != "" {
+			c.AvatarURL = fmt.Sprintf("https://www.gravatar.com/avatar/%x?s=40&d=identicon", md5.Sum([]byte(email)))
+		}
+		list = append(list, c)
+	}
+	return list
+}
@@ -0,0 +1,113 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ## Documentation coverage
+// Every declaration that is not `private` is expected to carry a doc
+// comment. Coverage is counted per file as it is parsed and summed over
+// the run, for badges and reports.
+
+// a `Coverage` counts declarations and how many of them are documented
+type Coverage struct {
+	Documented int `json:"documented"`
+	Total      int `json:"total"`
+}
+
+// the documented share, 0-100. Nothing to document counts as complete.
+func (c Coverage) Percent() int {
+	if c.Total == 0 {
+		return 100
+	}
+	return c.Documented * 100 / c.Total
+}
+
+func (c *Coverage) add(other Coverage) {
+	c.Documented += other.Documented
+	c.Total += other.Total
+}
+
+var pragmaMatcher = regexp.MustCompile(`pragma\s+solidity\s+([^;]+);`)
+
+// what the run as a whole found, added to by every file
+var (
+	runStatsMu sync.Mutex
+	runStats   = struct {
+		Coverage Coverage
+		Pragmas  map[string]bool
+		Licenses map[string]bool
+	}{Pragmas: map[string]bool{}, Licenses: map[string]bool{}}
+)
+
+// whether a section's declaration should be documented
+func expectsDocs(sym *Symbol) bool {
+	return sym != nil && sym.Visibility != "private"
+}
+
+// count the declarations of a file. This runs before the contract
+// metadata is taken off its section.
+func measureCoverage(sections *list.List) Coverage {
+	var c Coverage
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if sec.collapsed || !expectsDocs(sec.symbol) {
+			continue
+		}
+		c.Total++
+		if len(bytes.TrimSpace(sec.docsText)) > 0 {
+			c.Documented++
+		}
+	}
+	return c
+}
+
+// a `FileStats` is what a file contributes to the run's numbers
+type FileStats struct {
+	Coverage Coverage `json:"coverage"`
+	Sections int      `json:"sections,omitempty"`
+	License  string   `json:"license,omitempty"`
+	Pragmas  []string `json:"pragmas,omitempty"`
+	// The tags left out with `redactTags`
+	Redactions []Redaction `json:"redactions,omitempty"`
+}
+
+func documentStats(doc *Document) FileStats {
+	stats := FileStats{Coverage: doc.Coverage, Sections: doc.Sections.Len()}
+	if doc.License != nil {
+		stats.License = doc.License.ID
+	}
+	for e := doc.Sections.Front(); e != nil; e = e.Next() {
+		for _, m := range pragmaMatcher.FindAllSubmatch(e.Value.(*Section).codeText, -1) {
+			stats.Pragmas = append(stats.Pragmas, strings.TrimSpace(string(m[1])))
+		}
+	}
+	return stats
+}
+
+// add a file's numbers to the run
+func recordStats(stats FileStats) {
+	runStatsMu.Lock()
+	defer runStatsMu.Unlock()
+	runStats.Coverage.add(stats.Coverage)
+	if stats.License != "" {
+		runStats.Licenses[stats.License] = true
+	}
+	for _, pragma := range stats.Pragmas {
+		runStats.Pragmas[pragma] = true
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,103 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## Content Security Policy
+// The pages never use inline styles or scripts, but a strict policy also
+// rules out the web font the stylesheet imports. `--csp` drops that
+// import, pins the stylesheet with an integrity hash, adds the policy as
+// a meta tag and writes `docs/csp.json` listing the policy and the hash
+// of every generated file, for hosts that set the header themselves.
+
+var fontImport = regexp.MustCompile(`(?m)^@import [^;]*;\n?`)
+
+// the stylesheet as it should be written for this run
+func stylesheet() string {
+	css := Css + tabStyle() + styleCSS
+	if config.CSP {
+		css = fontImport.ReplaceAllString(css, "")
+	}
+	if config.Minify {
+		css = minifyCSS(css)
+	}
+	return css
+}
+
+// the stylesheet as it is written once all pages are done. Unused
+// highlighting rules are only pruned when no page has pinned the hash of
+// the full stylesheet.
+func finalStylesheet() string {
+	if !config.Minify || config.CSP {
+		return stylesheet()
+	}
+	css := pruneCSS(Css + tabStyle() + styleCSS)
+	return minifyCSS(css)
+}
+
+// the integrity attribute value for the stylesheet, empty outside `--csp`
+func styleIntegrity() string {
+	if !config.CSP {
+		return ""
+	}
+	return hashOutput("dappspec.css", []byte(stylesheet())).SHA384
+}
+
+// the policy the pages are compatible with
+func contentSecurityPolicy() string {
+	if !config.CSP {
+		return ""
+	}
+	scripts := map[string]bool{"'self'": true}
+	for _, origin := range analyticsOrigins() {
+		scripts[origin] = true
+	}
+	for _, src := range pageScripts() {
+		if u, err := url.Parse(src); err == nil && u.Host != "" {
+			scripts[u.Scheme+"://"+u.Host] = true
+		}
+	}
+	var scriptSrc []string
+	for src := range scripts {
+		scriptSrc = append(scriptSrc, src)
+	}
+	sort.Strings(scriptSrc)
+	connectSrc := append([]string{"'self'"}, analyticsOrigins()...)
+	connectSrc = append(connectSrc, tryItOrigins()...)
+	imgSrc := append([]string{"'self'", "data:"}, brandOrigins()...)
+	if config.Contributors {
+		imgSrc = append(imgSrc, avatarOrigins...)
+	}
+	return strings.Join([]string{
+		"default-src 'none'",
+		"style-src 'self'",
+		"img-src " + strings.Join(imgSrc, " "),
+		"script-src " + strings.Join(scriptSrc, " "),
+		"connect-src " + strings.Join(connectSrc, " "),
+		"base-uri 'none'",
+		"form-action 'none'",
+	}, "; ")
+}
+
+type cspManifest struct {
+	Policy string    `json:"policy"`
+	Files  []*Output `json:"files"`
+}
+
+// write `docs/csp.json` once everything else has been generated
+func writeCSPManifest() error {
+	if !config.CSP {
+		return nil
+	}
+	b, err := json.MarshalIndent(cspManifest{contentSecurityPolicy(), writtenOutputs()}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(filepath.Join(outputDir(), "csp.json"), append(b, '\n'), 0644)
+}
@@ -0,0 +1,1078 @@
+// **Dappspec** is a Go port of [Docco](http://jashkenas.github.com/docco/): the
+// original quick-and-dirty, hundred-line-long, literate-programming-style
+// documentation generator. It produces HTML that displays your comments
+// alongside your code. Comments are passed through
+// [Markdown](http://daringfireball.net/projects/markdown/syntax), and code is
+// passed through [Pygments](http://pygments.org/) syntax highlighting.  This
+// page is the result of running Dappspec against its own source file.
+//
+// If you install Dappspec, you can run it from the command-line:
+//
+// dappspec *.go
+//
+// ...will generate an HTML documentation page for each of the named source
+// files, with a menu linking to the other pages, saving it into a `docs`
+// folder.
+//
+// The [source for Dappspec](http://github.com/sambacha/dappspec) is available on
+// GitHub, and released under the MIT license.
+//
+// To install Dappspec, first make sure you have [Pygments](http://pygments.org/)
+// Then, with the go tool:
+//
+//	go get github.com/sambacha/dappspec
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// ## Types
+// Due to Go's statically typed nature, what is passed around in object
+// literals in Docco, requires various structures
+
+// A `Section` captures a piece of documentation and code
+// Every time interleaving code is found between two comments
+// a new `Section` is created.
+type Section struct {
+	docsText      []byte
+	codeText      []byte
+	firstCodeLine string
+	DocsHTML      []byte
+	CodeHTML      []byte
+	// what the code declares, if anything
+	symbol *Symbol
+	// the heading it is listed under when grouping by kind
+	group string
+	// folded away as a dependency or duplicate, with `--dedupe`
+	collapsed bool
+	// the contract, interface or library the section declares or is in
+	unit string
+	// notes on lines of the code
+	footnotes []Footnote
+}
+
+// a `Document` is everything known about a single source file
+type Document struct {
+	Source string
+	// The license header folded away from the top of the file, if any
+	License *License
+	// The NatSpec describing the contract as a whole
+	Metadata []MetadataEntry
+	// The `Section`s making up the rest of the file
+	Sections *list.List
+	// How much of the file is documented
+	Coverage Coverage
+	// The userdoc and devdoc of its contracts, once a renderer asked
+	natspec map[string]*ContractDocs
+}
+
+// a `TemplateSection` is a section that can be passed
+// to Go's templating system, which expects strings.
+type TemplateSection struct {
+	DocsHTML   string
+	CodeHTML   string
+	SectionTag string
+	// Set on the first section of each group
+	GroupTitle string
+	// The sections mentioning this one with `@@`
+	ReferencedBy []Backlink
+	// The libraries this section attaches with `using`, or for a library
+	// the sections attaching it
+	Uses   []Backlink
+	UsedBy []Backlink
+	// What to pass a constructor or initializer
+	Deployment *Deployment
+	// The "Try it" form of a view function of a deployed contract
+	Call *CallForm
+	// `new` or `changed` since the `--annotate-diff` baseline
+	Change string
+	// Set on the section declaring a contract, interface or library
+	Kind *UnitKind
+	// The documented fields of a struct or values of an enum
+	Members []Member
+	// `view`, `pure`, `payable` or `nonpayable` for functions
+	Mutability string
+	// The modifiers a function applies
+	Modifiers []ModifierUse
+	// The size of a function
+	Metrics *FunctionMetrics
+	// The tests exercising a function
+	Tests []Backlink
+	// On the page of a test, `invariant`, `fuzz` or `reverts`
+	TestKind string
+	// With `numberSections`, like `1.2`
+	Number string
+	// Notes on lines of the code
+	Footnotes []Footnote
+}
+
+// a `Language` describes a programming language
+type Language struct {
+	// the `Pygments` name of the language
+	name string
+	// The comment delimiter
+	symbol string
+	// The regular expression to match the comment delimiter
+	commentMatcher *regexp.Regexp
+	// Used as a placeholder so we can parse back Pygments output
+	// and put the sections together
+	dividerText string
+	// The HTML equivalent
+	dividerHTML *regexp.Regexp
+	// Extra Pygments options, as `name=value`
+	options []string
+	// Declarations follow Rust's syntax rather than Solidity's
+	rust bool
+	// Other line comment markers that start docs, like Rust's `//!`
+	alsoDocs []string
+	// Openings of block comments that are docs too, if any, and the
+	// delimiter closing them
+	blockStarts []string
+	blockEnd    string
+	// Not highlighted, and without docs: the code is shown as it is
+	plain bool
+}
+
+// a `TemplateData` is per-file
+type TemplateData struct {
+	// Title of the HTML output
+	Title string
+	// The Sections making up this file
+	Sections []*TemplateSection
+	// A full list of source files so that a table-of-contents can
+	// be generated
+	Sources []string
+	// The Foundry tests and scripts, listed apart
+	Tests []string
+	// The guides, with `guides`
+	Guides []GuideLink
+	// Only generate the TOC is there is more than one file
+	// Go's templating system does not allow expressions in the
+	// template, so calculate it outside
+	Multiple bool
+	// What every kind of page has in common
+	PageChrome
+	// The license header folded away from the top of the file, if any
+	License *License
+	// The contract-level NatSpec
+	Metadata []MetadataEntry
+	// Link to the reference view of the file, if one is generated
+	Reference string
+	// Link to the examples of the file, if it has any
+	Examples string
+	// A plain-text summary for search engines
+	Description string
+	// The page's absolute URL and social card, with a base URL
+	Canonical   string
+	SocialImage string
+	// Where to edit the source on GitHub
+	EditURL string
+	// The import graph page, with `--imports`
+	Imports string
+	// The documentation overview, with `--overview`
+	Overview string
+	// Who worked on the source, with `--contributors`
+	Contributors []*Contributor
+	// Whether the page has functions to filter by mutability
+	Filter bool
+}
+
+// a map of all the languages we know
+var languages map[string]*Language
+
+// paths of all the source files, sorted
+var sources []string
+
+// The flags of the command line are kept apart from those of the program
+// dappspec is part of.
+var commandLine = flag.NewFlagSet("dappspec", flag.ExitOnError)
+
+// command-line flags
+var (
+	showVersion      = commandLine.Bool("version", false, "print version information and exit")
+	updateCheck      = commandLine.Bool("check-update", false, "check GitHub for a newer release")
+	cssFile          = commandLine.String("css", "", "use this stylesheet instead of the built-in one")
+	templateFile     = commandLine.String("template", "", "use this page template instead of the built-in one")
+	assetsDir        = commandLine.String("print-assets", "", "write the built-in template and stylesheet to this directory and exit")
+	configFile       = commandLine.String("config", "", "read settings from this file (default \""+defaultConfigFile+"\" if present)")
+	groupBy          = commandLine.String("group-by", "", "group sections within a page: \"kind\" or \"\" for source layout")
+	sectionOrder     = commandLine.String("order", "source", "order sections within a group: \"source\" or \"alpha\"")
+	reference        = commandLine.Bool("reference", false, "also write a condensed reference page per file")
+	noJS             = commandLine.Bool("no-js", false, "leave all scripts out of the generated pages")
+	csp              = commandLine.Bool("csp", false, "generate output compatible with a strict Content Security Policy")
+	force            = commandLine.Bool("force", false, "overwrite files in docs/ that dappspec did not generate")
+	theme            = commandLine.String("theme", "", "directory with a template, stylesheet and assets to use")
+	tabWidthFlag     = commandLine.Int("tab-width", 0, fmt.Sprintf("width of a tab in the code column (default %d)", defaultTabWidth))
+	expandTabsFlag   = commandLine.Bool("expand-tabs", false, "replace tabs with spaces in the code column")
+	codeWrap         = commandLine.String("code-wrap", "scroll", "long lines in the code column: \"scroll\" or \"wrap\"")
+	minify           = commandLine.Bool("minify", false, "minify the generated HTML and CSS")
+	precompress      = commandLine.Bool("precompress", false, "also write .gz and .br versions of every generated text file")
+	hookCommand      = commandLine.String("post-build-command", "", "shell command to run after generating")
+	hookURL          = commandLine.String("post-build-url", "", "URL to POST the build report to after generating")
+	repoURL          = commandLine.String("repo-url", "", "GitHub URL of the repository, for \"Edit this file\" links")
+	repoBranchFlag   = commandLine.String("repo-branch", "", "branch the \"Edit this file\" links point at (default \""+defaultBranch+"\")")
+	contributorsFlag = commandLine.Bool("contributors", false, "list each file's git contributors at the bottom of its page")
+	badges           = commandLine.Bool("badges", false, "write coverage, solc and license badges into docs/badges")
+	baseURLFlag      = commandLine.String("base-url", "", "URL the docs will be served from")
+	feed             = commandLine.Bool("feed", false, "write an Atom feed of documentation changes from git history")
+	staged           = commandLine.Bool("staged", false, "only document files staged in git (implies --cache)")
+	dedupe           = commandLine.Bool("dedupe", false, "collapse dependencies and duplicated contracts in flattened sources")
+	ref              = commandLine.String("ref", "", "document the sources as they are at this git ref")
+	archive          = commandLine.String("archive", "", "document the sources in this .tar.gz or .zip file or URL")
+	stdinName        = commandLine.String("stdin-name", "stdin.sol", "file name for source read from standard input (-)")
+	formatList       = commandLine.String("format", "html", "comma-separated output formats: html, markdown, json (with the solc userdoc and devdoc of each contract), natspec (those alone, as solc writes them), mdbook")
+	socialCards      = commandLine.Bool("social-cards", false, "draw a PNG preview card per page (needs --base-url)")
+	logo             = commandLine.String("logo", "", "image file or URL shown above every page")
+	favicon          = commandLine.String("favicon", "", "image file or URL used as the favicon")
+	partials         = commandLine.String("partials", "", "directory of template blocks (head.html, footer.html, ...) to override")
+	imports          = commandLine.Bool("imports", false, "draw the import graph of the sources on docs/imports.html")
+	useCache         = commandLine.Bool("cache", false, "skip files whose source and settings are unchanged since the last run")
+	links            = commandLine.String("links", "pages", "where relative links in comments go: \"pages\", \"repo\" or \"off\"")
+	rpcURL           = commandLine.String("rpc-url", "", "JSON-RPC endpoint for the \"Try it\" forms of deployed contracts")
+	eventsFlag       = commandLine.Bool("events", false, "write every event with its topic and NatSpec to docs/events.json")
+	methodsFlag      = commandLine.Bool("methods", false, "write the notices of external functions by selector to docs/methods.json")
+	annotateDiff     = commandLine.String("annotate-diff", "", "mark sections new or changed since the build in this docs directory or manifest")
+	outputNameFlag   = commandLine.String("output-name", "", "template for the path of each page under docs/, e.g. \"{{.Dir}}/{{.Contract | kebab}}.html\"")
+	tocSummary       = commandLine.Bool("toc-summary", false, "show the notice, kind and documentation coverage of each file in the table of contents")
+	lintFlag         = commandLine.Bool("lint", false, "report entry points taking Ether and complex functions that lack docs")
+	overviewFlag     = commandLine.Bool("overview", false, "write a dashboard of documentation coverage and complexity to docs/overview.html")
+	jobsFlag         = commandLine.Int("jobs", 0, "render at most this many pages at once (0 for all of them)")
+	strictFlag       = commandLine.Bool("strict", false, "fail when --lint reports anything")
+	keepGoingFlag    = commandLine.Bool("keep-going", false, "render every file even if some fail, failing at the end")
+	highlightStyle   = commandLine.String("highlight-style", "", "color the code with this Chroma (or Pygments) style, e.g. \"friendly\"")
+	darkStyle        = commandLine.String("highlight-dark-style", "", "color the code with this Chroma (or Pygments) style in dark mode, e.g. \"monokai\"")
+	plainKeywords    = commandLine.Bool("plain-keywords", false, "with --highlighter none, mark the keywords and types of Solidity")
+	tokenClassFlag   = commandLine.Bool("token-classes", false, "also give token spans stable classes like tok-keyword, whatever the highlighter")
+	highlighterFlag  = commandLine.String("highlighter", "", "what highlights the code: \"chroma\" (default), \"pygments\", \"none\" or \"command\"")
+	reportFlag       = commandLine.String("report", "", "write the files, sections, warnings and timings of the run to this JSON file")
+	metricsAppend    = commandLine.String("metrics-append", "", "add the coverage and section counts of the run, with its commit, to this NDJSON file")
+	foundryFlag      = commandLine.Bool("foundry-tests", false, "also document the tests under test/ and scripts under script/, in docs/tests/")
+	redactTags       = commandLine.String("redact-tags", "", "comma-separated tags to leave out of the output, e.g. custom:internal,custom:todo")
+	outFlag          = commandLine.String("out", "", "the directory to write the docs to (default docs)")
+	rootFlag         = commandLine.String("root", "", "lay the pages out from this directory, src/a/Token.sol being a/Token.html with --root src (default the working directory)")
+	includeFlag      = commandLine.String("include", "", "comma-separated glob patterns of more sources to document, like 'src/**/*.sol'")
+	excludeFlag      = commandLine.String("exclude", "", "comma-separated glob patterns of sources to leave out, like 'lib/**'")
+	numberSections   = commandLine.Bool("number-sections", false, "number contracts and their sections (1, 1.1, 1.2) across the site, warning when numbers change")
+	audienceFlag     = commandLine.String("audience", "", "build the site for this audience, keeping the docs under <!-- dappspec:if audience=... --> meant for it")
+	docsPrefixFlag   = commandLine.Bool("strip-docs-prefix", false, "title and name the page of docs_Token.sol as Token, as older versions did")
+	testsFlag        = commandLine.String("tests", "", "comma-separated directories of Foundry or Hardhat tests to link each function to its tests from")
+	testMapFlag      = commandLine.String("test-map", "", "a JSON file mapping Contract.function to tests as file:name")
+	exampleCheck     = commandLine.Bool("check-examples", false, "compile the snippets of @custom:example tags with solc")
+)
+
+// Wrap the code in these
+const highlightStart = "<div class=\"highlight\"><pre>"
+const highlightEnd = "</pre></div>"
+
+// ## Main documentation generation functions
+
+// Generate the documentation for a single source file
+// by splitting it into sections, highlighting each section
+// and putting it together.
+// It runs in the page group of `documentFiles`, alongside the other
+// files, and its error is that file's failure
+func generateDocumentation(source string) error {
+	code, err := provider.Read(source)
+	if err != nil {
+		return err
+	}
+	key := cacheKey(source, code)
+	if cached, err := reuseCached(source, key); cached || err != nil {
+		return err
+	}
+	doc, stats := scannedDocument(source)
+	if doc == nil {
+		if doc, stats, err = parseDocument(source, code); err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+	}
+	recordStats(stats)
+	reportPage(source, stats, false)
+	outputs, err := renderDocument(doc)
+	if err != nil {
+		return err
+	}
+	storeCached(source, key, stats, outputs)
+	return nil
+}
+
+// read the sections of `source` out of its `code`, ready to render
+func parseDocument(source string, code []byte) (*Document, FileStats, error) {
+	code, err := decodeSource(source, code)
+	if err != nil {
+		return nil, FileStats{}, err
+	}
+	// Windows line endings would otherwise leave a stray `\r` on every
+	// line of docs and code
+	code = bytes.ReplaceAll(code, []byte("\r\n"), []byte("\n"))
+	code = languageFromDirective(source, code)
+	fallBackToPlain(source)
+	doc := &Document{Source: source}
+	doc.License, code = foldLicense(code)
+	doc.Sections = parseDeduped(source, code)
+	stripFrontMatter(doc)
+	if err := transcludeSections(source, doc.Sections); err != nil {
+		return nil, FileStats{}, err
+	}
+	if err := selectSections(doc.Sections); err != nil {
+		return nil, FileStats{}, err
+	}
+	if err := substituteSections(doc.Sections); err != nil {
+		return nil, FileStats{}, err
+	}
+	redactions := redactSections(source, doc.Sections)
+	footnoteSections(doc.Sections)
+	groupBy := config.GroupBy
+	if isTestSource(source) {
+		groupBy = "test"
+	} else {
+		doc.Coverage = measureCoverage(doc.Sections)
+	}
+	stats := documentStats(doc)
+	stats.Redactions = redactions
+	doc.Metadata = extractMetadata(doc.Sections)
+	arrangeSections(doc.Sections, groupBy, config.Order)
+	return doc, stats, nil
+}
+
+var (
+	// `// dappspec:off` and `// dappspec:on` bracket regions to leave out
+	regionDirective = regexp.MustCompile(`^\s*//+\s*dappspec:(off|on)\s*$`)
+	// `/// @custom:dappspec ignore` leaves out the section it documents
+	ignoreDirective = regexp.MustCompile(`@custom:dappspec\s+ignore\b`)
+)
+
+// Parse splits code into `Section`s
+func parse(source string, code []byte) *list.List {
+	lines := bytes.Split(code, []byte("\n"))
+	sections := new(list.List)
+	sections.Init()
+	language := getLanguage(source)
+
+	var hasCode bool
+	var codeText = getBuffer()
+	var docsText = getBuffer()
+	defer putBuffer(codeText)
+	defer putBuffer(docsText)
+	// inside a `dappspec:off` region
+	var off bool
+	// the current section asked to be left out
+	var ignored bool
+	// the contract the current section is in
+	var contract string
+	// inside a block comment
+	var inBlock bool
+	// inside the braces of a struct or enum
+	var members memberScope
+	// the braces of the code, and whether the contract the current
+	// section is in has been closed since the last section
+	var braces braceScope
+	var unitClosed bool
+	// the footnote for the next line of code, from a note in a body
+	var note string
+
+	// save a new section
+	save := func(docs, code []byte, firstCodeLine string) {
+		if ignored {
+			ignored = false
+			return
+		}
+		// deep copy the slices since slices always refer to the same storage
+		// by default
+		docsCopy, codeCopy := copyText(docs, code)
+
+		if config.ExpandTabs {
+			codeCopy = expandTabs(codeCopy, tabWidth())
+		}
+		symbol := language.parseSymbol(codeCopy)
+		if symbol != nil {
+			if symbol.IsUnit() {
+				contract = symbol.Name
+			} else {
+				symbol.Contract = contract
+			}
+			symbol.Members, codeCopy = structMembers(symbol, codeCopy, docsCopy)
+		}
+		sections.PushBack(&Section{
+			docsText:      docsCopy,
+			codeText:      codeCopy,
+			firstCodeLine: firstCodeLine,
+			symbol:        symbol,
+			unit:          contract,
+		})
+		if unitClosed {
+			contract, unitClosed = "", false
+		}
+	}
+
+	var firstCodeLine string
+	for _, line := range lines {
+		// regions between `dappspec:off` and `dappspec:on` never make it
+		// into the output
+		if m := regionDirective.FindSubmatch(line); m != nil {
+			off = string(m[1]) == "off"
+			continue
+		}
+		if off {
+			continue
+		}
+		var docs []byte
+		var isDocs bool
+		if inBlock || !members.track(language, line) {
+			docs, isDocs = blockDocs(language, line, &inBlock)
+			if !isDocs && !language.plain && language.commentMatcher.Match(line) {
+				docs, isDocs = language.commentMatcher.ReplaceAll(line, nil), true
+			}
+		}
+		// a footnote in a body is for the line below it
+		if n, ok := bodyFootnote(docs); isDocs && hasCode && ok && strings.HasPrefix(language.symbol, "//") {
+			docsText.Write(bytes.TrimSpace(docs))
+			docsText.WriteString("\n")
+			note = n
+			continue
+		}
+		// if the line is a comment
+		if isDocs {
+			// but there was previous code
+			if hasCode {
+				// attributes written above the docs belong to the
+				// declaration below them
+				var carried []byte
+				code := codeText.Bytes()
+				if language.rust {
+					code, carried = trailingAttributes(code)
+				}
+				// we need to save the existing documentation and text
+				// as a section and start a new section since code blocks
+				// have to be delimited before being sent to Pygments
+				save(docsText.Bytes(), code, firstCodeLine)
+				hasCode = false
+				codeText.Reset()
+				docsText.Reset()
+				if len(carried) > 0 {
+					codeText.Write(carried)
+					firstCodeLine = string(bytes.SplitN(carried, []byte("\n"), 2)[0])
+					hasCode = true
+				}
+			}
+			if ignoreDirective.Match(line) {
+				ignored = true
+				continue
+			}
+			docsText.Write(docs)
+			docsText.WriteString("\n")
+		} else {
+			if followsBraces(language) {
+				// a file-level function or constant after other code
+				// starts a section of its own, as do `fallback` and
+				// `receive`
+				if hasCode && braces.startsSection(line) {
+					save(docsText.Bytes(), codeText.Bytes(), firstCodeLine)
+					hasCode = false
+					codeText.Reset()
+					docsText.Reset()
+				}
+				braces.track(line)
+				unitClosed = unitClosed || braces.closed
+			}
+			if note != "" {
+				line = append(bytes.TrimRight(append([]byte{}, line...), " \t"), " // ["+note+"]"...)
+				note = ""
+			}
+			if !hasCode {
+				firstCodeLine = string(line)
+			}
+			hasCode = true
+			codeText.Write(line)
+			codeText.WriteString("\n")
+		}
+	}
+	// save any remaining parts of the source file
+	save(docsText.Bytes(), codeText.Bytes(), firstCodeLine)
+	return sections
+}
+
+var blockMiddle = regexp.MustCompile(`^\s*\*(?:\s|$)`)
+
+// the docs on a line of a block comment, if it is one: the delimiters and
+// the `*` that usually starts each line are stripped. Blocks have to
+// start a line to count as docs.
+func blockDocs(language *Language, line []byte, inBlock *bool) ([]byte, bool) {
+	trimmed := bytes.TrimSpace(line)
+	if !*inBlock {
+		start := ""
+		for _, s := range language.blockStarts {
+			if bytes.HasPrefix(trimmed, []byte(s)) {
+				start = s
+				break
+			}
+		}
+		// `/**/` is an empty comment rather than the start of docs
+		if start == "" || bytes.HasPrefix(trimmed[len(start)-1:], []byte(language.blockEnd)) {
+			return nil, false
+		}
+		*inBlock = true
+		trimmed = bytes.TrimLeft(trimmed[len(start):], "*!")
+	} else {
+		trimmed = blockMiddle.ReplaceAll(trimmed, nil)
+	}
+	if i := bytes.Index(trimmed, []byte(language.blockEnd)); i >= 0 {
+		*inBlock = false
+		trimmed = trimmed[:i]
+	}
+	return bytes.TrimSpace(trimmed), true
+}
+
+// compute the output location (in the output directory) for the file
+func destination(source string) string {
+	return filepath.Join(outputDir(), outputPath(source))
+}
+
+func destinationTOC(source string) string {
+	return outputPath(source)
+}
+
+func getSectionTag(index int, firstCodeLine string) string {
+	if !strings.HasPrefix(firstCodeLine, "notice") &&
+		!strings.HasPrefix(firstCodeLine, "dev") &&
+		!strings.HasPrefix(firstCodeLine, "params") &&
+		!strings.HasPrefix(firstCodeLine, "return") {
+		// not a declaration
+		return fmt.Sprintf("%d", index)
+	}
+	// a type or variable declaration
+	parts := strings.Split(firstCodeLine, " ")
+	return strings.TrimSpace(parts[1])
+}
+
+func getFieldOrType(firstCodeLine string) string {
+	if !strings.HasPrefix(firstCodeLine, "notice") &&
+		!strings.HasPrefix(firstCodeLine, "dev") &&
+		!strings.HasPrefix(firstCodeLine, "params") &&
+		!strings.HasPrefix(firstCodeLine, "return") {
+		// not a declaration, field maybe?
+		parts := strings.Split(firstCodeLine, " ")
+		if len(parts) > 0 {
+			return strings.TrimSpace(parts[0])
+		}
+		return ""
+	}
+	// a type or variable declaration
+	parts := strings.Split(firstCodeLine, " ")
+	if len(parts) > 0 {
+		return strings.TrimSpace(parts[1])
+	}
+	return ""
+}
+
+func highlightRefs(text []byte, ref string) []byte {
+	if len(ref) == 0 {
+		return text
+	}
+	// weird shit as \A and \z matching doesn't work?
+	ref = regexp.QuoteMeta(ref)
+	rx := regexp.MustCompile(fmt.Sprintf(`([\s]+)(%s)`, ref))
+	text = rx.ReplaceAll(text, []byte("$1<strong>$2</strong>"))
+	rx = regexp.MustCompile(fmt.Sprintf(`(%s)([\s]+)`, ref))
+	text = rx.ReplaceAll(text, []byte("<strong>$1</strong>$2"))
+	rx = regexp.MustCompile(fmt.Sprintf(`([\s]+)(%s)([\s]+)`, ref))
+	text = rx.ReplaceAll(text, []byte("$1<strong>$2</strong>$3"))
+	return text
+}
+
+var (
+	referenceRx  = regexp.MustCompile(`@@([\w]+)`)
+	referenceTpl = []byte(`<a href="#section-$1" title="Jump to $1">$1</a>`)
+)
+
+// render the final HTML, returning the paths written
+func generateHTML(doc *Document) ([]string, error) {
+	source := doc.Source
+	title := pageTitle(source)
+
+	dest := destination(source)
+	ensureDirectory(filepath.Dir(dest))
+	// convert every `Section` into corresponding `TemplateSection`
+	views := sectionViews(doc)
+	checkNoticeReferences(doc, views)
+	lintEntryPoints(doc, views)
+	lintComplexity(doc, views)
+	changes := annotateSections(pageOf(source), views)
+	tests := testsOf(pageOf(source), views)
+	numbers := sectionNumbers(doc)
+	sectionsArray := make([]*TemplateSection, 0, len(views))
+	filter := false
+	var entries []*ReferenceEntry
+	for i, sec := range views {
+		if entry := referenceEntry(sec.Section, sec.Tag); entry != nil {
+			entries = append(entries, entry)
+		}
+
+		sec.DocsHTML = linkReferences(pageOf(source), sec.DocsHTML)
+		sec.DocsHTML = rewriteLinks(source, sec.DocsHTML)
+		sec.DocsHTML = highlightRefs(sec.DocsHTML, getFieldOrType(sec.firstCodeLine))
+		sec.DocsHTML = markParams(sec.DocsHTML, sec.symbol)
+		section := &TemplateSection{
+			DocsHTML:   string(sec.DocsHTML),
+			SectionTag: sec.Tag,
+			GroupTitle: sec.GroupTitle,
+
+			ReferencedBy: referencedBy(pageOf(source), "section-"+sec.Tag),
+			Uses:         usesOf(pageOf(source), "section-"+sec.Tag),
+			UsedBy:       usedByOf(pageOf(source), "section-"+sec.Tag, sec.symbol),
+			Deployment:   deployment(sec.Section),
+			Call:         callForm(sec.symbol),
+			Change:       changes[i],
+			Kind:         unitKindOf(sec.symbol),
+			Mutability:   mutabilityOf(sec.symbol),
+			Modifiers:    modifiersOf(pageOf(source), sec.symbol),
+			Metrics:      metricsOf(sec.Section),
+			Tests:        tests[sec.Section],
+			TestKind:     testKindOf(source, sec.symbol),
+			Number:       numbers[sec.Section],
+			Footnotes:    sec.footnotes,
+		}
+		if sec.symbol != nil {
+			section.Members = sec.symbol.Members
+		}
+		if !bytes.HasPrefix(sec.codeText, []byte("pragma")) &&
+			!bytes.HasPrefix(sec.codeText, []byte("import")) {
+			section.CodeHTML = string(linkValueTypes(pageOf(source), "section-"+sec.Tag, sec.CodeHTML))
+		}
+		section.CodeHTML = markFootnotes(sec.Tag, section.CodeHTML, sec.footnotes)
+		if sec.collapsed {
+			section.CodeHTML = fmt.Sprintf(`<details class="collapsed"><summary>%d lines</summary>%s</details>`,
+				bytes.Count(sec.codeText, []byte("\n")), sec.CodeHTML)
+		}
+		// the strings are all the page needs
+		sec.DocsHTML, sec.CodeHTML = nil, nil
+		sectionsArray = append(sectionsArray, section)
+		filter = filter || section.Mutability != ""
+	}
+	// run through the Go template
+	docSources, testSources := splitTestSources(sources)
+	data := TemplateData{
+		Title:      title,
+		Sections:   sectionsArray,
+		Sources:    docSources,
+		Tests:      testSources,
+		Guides:     guideLinks(pageOf(source)),
+		Multiple:   len(sources) > 1,
+		PageChrome: pageChromeAt(pageOf(source)),
+		License:    doc.License,
+		Metadata:   doc.Metadata,
+		EditURL:    editURL(source),
+		Imports:    importsLink(pageOf(source)),
+		Overview:   overviewLink(pageOf(source)),
+
+		Contributors: contributors(source),
+		Filter:       filter,
+	}
+	for i := range data.Metadata {
+		data.Metadata[i].HTML = string(rewriteLinks(source, []byte(data.Metadata[i].HTML)))
+	}
+	for _, entry := range doc.Metadata {
+		if entry.Name == "notice" {
+			data.Description = entry.Text
+			break
+		}
+	}
+	var written []string
+	data.Canonical = canonicalURL(dest)
+	if config.SocialCards && data.Canonical != "" {
+		card := cardDestination(source)
+		ensureDirectory(filepath.Dir(card))
+		if err := writeOutput(card, socialCard(title, mainContract(doc.Sections)), 0644); err != nil {
+			return nil, err
+		}
+		data.SocialImage = canonicalURL(card)
+		written = append(written, card)
+	}
+	if examples := documentExamples(doc); len(examples) > 0 {
+		data.Examples = examplesLink(source)
+		if err := generateExamples(source, title, examples); err != nil {
+			return nil, err
+		}
+		written = append(written, examplesDestination(source))
+	}
+	if config.Reference {
+		data.Reference = referenceLink(source)
+		if err := generateReference(source, title, entries, data.Examples); err != nil {
+			return nil, err
+		}
+		written = append(written, referenceDestination(source))
+	}
+	page, err := dappspecTemplate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", source, err)
+	}
+	html := finishPage(page)
+	log.Println("dappspec: ", source, " -> ", dest)
+	if err := writeOutput(dest, html, 0644); err != nil {
+		return nil, err
+	}
+	return append(written, dest), nil
+}
+
+func dappspecTemplate(data TemplateData) ([]byte, error) {
+	return executeTemplate("dappspec", HTML, data)
+}
+
+// `text` executed with `data`. Pages are rendered concurrently, so a
+// template that fails fails the page it renders, not the process.
+func executeTemplate(name, text string, data interface{}) ([]byte, error) {
+	t, err := parseTemplate(name, text)
+	if err != nil {
+		return nil, err
+	}
+	buf := new(bytes.Buffer)
+	if err := t.Execute(buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// parse a page template along with the partials overriding its blocks
+func parseTemplate(name, text string) (*template.Template, error) {
+	// this hack is required because `ParseFiles` doesn't
+	// seem to work properly, always complaining about empty templates
+	t, err := template.New(name).Funcs(
+		// introduce the functions that the template needs
+		template.FuncMap{
+			"title":       pageTitle,
+			"units":       unitsTOC,
+			"summary":     summaryTOC,
+			"destination": destinationTOC,
+			"number":      pageNumber,
+		}).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	// only the blocks this template has are overridden
+	for _, block := range partialNames {
+		partial, ok := Partials[block]
+		if !ok || t.Lookup(block) == nil {
+			continue
+		}
+		if _, err := t.New(block).Parse(partial); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// get a `Language` given a path
+func getLanguage(source string) *Language {
+	languageOverridesMu.Lock()
+	lang, ok := languageOverrides[source]
+	languageOverridesMu.Unlock()
+	if ok {
+		return lang
+	}
+	if lang := mappedLanguage(source); lang != nil {
+		return lang
+	}
+	return languages[filepath.Ext(source)]
+}
+
+// make sure the output directory `name` exists
+func ensureDirectory(name string) {
+	os.MkdirAll(name, 0755)
+}
+
+func setupLanguages() {
+	languages = builtinLanguages()
+}
+
+// the languages dappspec knows out of the box, by extension
+func builtinLanguages() map[string]*Language {
+	languages := make(map[string]*Language)
+	// you should add more languages here
+	// only the name and comment markers are set here, the rest is
+	// filled in by `compileLanguage`
+	// NatSpec is written with `///` or `/** */`
+	languages[".sol"] = &Language{name: "solidity", symbol: "///", blockStarts: []string{"/**"}, blockEnd: "*/"}
+	// standalone Yul, which the Solidity lexer also reads
+	languages[".yul"] = &Language{name: "solidity", symbol: "///", blockStarts: []string{"/**"}, blockEnd: "*/"}
+	languages[".huff"] = &Language{name: "huff.py:HuffLexer", symbol: "//", blockStarts: []string{"/*"}, blockEnd: "*/"}
+	// Fe and Sway follow Rust: `///` and `/** */` document what follows,
+	// `//!` and `/*! */` the module they are in
+	languages[".fe"] = &Language{name: "rust", symbol: "///", rust: true, alsoDocs: []string{"//!"}, blockStarts: []string{"/**", "/*!"}, blockEnd: "*/"}
+	languages[".sw"] = &Language{name: "rust", symbol: "///", rust: true, alsoDocs: []string{"//!"}, blockStarts: []string{"/**", "/*!"}, blockEnd: "*/"}
+	// ink! and CosmWasm contracts
+	languages[".rs"] = &Language{name: "rust", symbol: "///", rust: true, alsoDocs: []string{"//!"}, blockStarts: []string{"/**", "/*!"}, blockEnd: "*/"}
+	return languages
+}
+
+func setup() {
+	setupLanguages()
+	commandLine.Var(varValues, "var", "`name=value` replacing {{var \"name\"}} in docs (repeatable)")
+	commandLine.Var(extraValues, "extra", "`name=value` exposed to templates as .Extra.name (repeatable)")
+	commandLine.Var(languageValues, "language", "`glob=language` forces the language of matching files (repeatable)")
+	commandLine.Var(addressValues, "address", "`Contract=0x...` where a contract is deployed, for the \"Try it\" forms (repeatable)")
+	commandLine.Var(lexerValues, "lexer", "`.ext=lexer` Pygments lexer (or lexer.py:Class file) for an extension (repeatable)")
+
+	for _, lang := range languages {
+		compileLanguage(lang)
+	}
+}
+
+// what a section of this language declares
+func (lang *Language) parseSymbol(code []byte) *Symbol {
+	if lang.plain {
+		return nil
+	}
+	if lang.rust {
+		return parseRustSymbol(code)
+	}
+	return parseSymbol(code)
+}
+
+// create the regular expressions based on the language comment symbol
+func compileLanguage(lang *Language) {
+	markers := []string{regexp.QuoteMeta(lang.symbol) + "/*"}
+	for _, m := range lang.alsoDocs {
+		markers = append(markers, regexp.QuoteMeta(m))
+	}
+	lang.commentMatcher, _ = regexp.Compile("^\\s*(?:" + strings.Join(markers, "|") + ")\\s?")
+	lang.dividerText = "\n" + lang.symbol + "DIVIDER\n"
+	// lexers tell comments and doc comments apart with different classes
+	lang.dividerHTML, _ = regexp.Compile("\\n*<span class=\"(?:c1?|cs|sd)\">" + regexp.QuoteMeta(lang.symbol) + "DIVIDER<\\/span>\\n*")
+}
+
+// where usage text goes
+func flagOutput() io.Writer {
+	return commandLine.Output()
+}
+
+func usage() {
+	fmt.Fprintln(flagOutput(), "Usage: dappspec [flags] files...")
+	commandLine.PrintDefaults()
+	commandUsage()
+}
+
+// run the command line with the arguments of the process. Let's Go!
+func Main() {
+	setup()
+	setupCommands()
+	resolveVersion()
+
+	commandLine.Usage = usage
+	if dispatchCommand(os.Args[1:]) {
+		return
+	}
+	commandLine.Parse(os.Args[1:])
+	if *showVersion {
+		fmt.Println(versionInfo())
+		return
+	}
+	if *updateCheck {
+		msg, err := checkUpdate()
+		if err != nil {
+			log.Println("dappspec: ", err)
+		} else {
+			log.Println(msg)
+		}
+	}
+	if *assetsDir != "" {
+		if err := printAssets(*assetsDir); err != nil {
+			log.Fatal("dappspec: ", err)
+		}
+		return
+	}
+	configure()
+	generate(commandLine.Args())
+}
+
+// load the config file and the assets, once the flags are parsed
+func configure() {
+	if err := loadSettings(); err != nil {
+		log.Fatal("dappspec: ", err)
+	}
+	if err := checkConfig(); err != nil {
+		log.Fatal("dappspec: ", err)
+	}
+	if err := loadAssets(config.Theme, *cssFile, *templateFile, config.Partials); err != nil {
+		log.Fatal("dappspec: ", err)
+	}
+}
+
+// the settings from the environment, the config file and the flags, in
+// that order
+func loadSettings() error {
+	if err := applyEnvironment(); err != nil {
+		return err
+	}
+	if err := loadConfig(*configFile); err != nil {
+		return err
+	}
+	applyFlags()
+	return nil
+}
+
+// check the settings and set up what they configure
+func checkConfig() error {
+	for _, check := range []func() error{
+		checkFormats,
+		checkHighlighter,
+		loadHighlightStyles,
+		configureLanguages,
+		checkLinks,
+		checkTryIt,
+		func() error { return loadBaseline(config.AnnotateDiff) },
+		checkOutputName,
+		checkInputs,
+	} {
+		if err := check(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// document `files` into the output directory
+func generate(files []string) {
+	p, err := sourceProvider(files)
+	if err != nil {
+		log.Fatal("dappspec: ", err)
+	}
+	generateFrom(p, files)
+}
+
+// document `files` of `p` into the output directory
+func generateFrom(p Provider, files []string) {
+	generating.Lock()
+	defer generating.Unlock()
+	if err := documentFiles(p, files); err != nil {
+		log.Fatal("dappspec: ", err)
+	}
+}
+
+// the steps of `generateFrom`, with the state of the run in place
+func documentFiles(p Provider, files []string) error {
+	var err error
+	resetRun()
+	provider = p
+	if files, err = provider.List(files); err != nil {
+		return err
+	}
+	files = excludeSources(files)
+	if config.FoundryTests {
+		tests, err := foundrySources()
+		if err != nil {
+			return err
+		}
+		files = uniqueSorted(append(files, tests...))
+	}
+	if config.Staged {
+		if files, err = stagedSources(files); err != nil {
+			return err
+		}
+	}
+	if len(files) == 0 {
+		return nil
+	}
+
+	ensureDirectory(outputDir())
+	if err := loadManifest(); err != nil {
+		return err
+	}
+	if err := loadCache(); err != nil {
+		return err
+	}
+	// the table of contents lists everything documented so far, not
+	// just the files regenerated in this run
+	sources = append(cachedSources(), files...)
+	sources = uniqueSorted(sources)
+	if err := scanPages(sources); err != nil {
+		return err
+	}
+	sortPages(sources)
+	scanLayout()
+	if err := outputCollisions(sources); err != nil {
+		return err
+	}
+	scanUnits(sources)
+	scanReferences(sources)
+	scanTitles(sources)
+	scanNumbers(sources)
+	scanValueTypes(sources)
+	scanABITypes(sources)
+	scanModifiers(sources)
+	if err := scanTests(); err != nil {
+		return err
+	}
+
+	resetLint()
+	startReport()
+	pages := newPageGroup(concurrency(len(files)), config.KeepGoing)
+	for _, arg := range files {
+		arg := arg
+		pages.Go(func() error {
+			start := time.Now()
+			err := generateDocumentation(arg)
+			reportTime(arg, time.Since(start), err)
+			return err
+		})
+	}
+	failed := pages.Wait()
+	if failed != nil && !config.KeepGoing {
+		// the pages written are still ours to overwrite next time, which
+		// a manifest that failed to be written would not tell
+		return errors.Join(failed, writeManifest(), writeReport())
+	}
+	// the steps that depend on every page being done, in order; the
+	// pages of imports, overview and guides come before `finishRenderers`,
+	// since the stylesheet is minified to the classes of all of them, and
+	// the manifest and cache after everything they list is written
+	for _, step := range []func() error{
+		writeImports,
+		writeOverview,
+		writeGuides,
+		writeTryIt,
+		writeEvents,
+		writeMethods,
+		checkNumbers,
+		finishRenderers,
+		writeBrand,
+		writeBadges,
+		writeFeed,
+		writeCSPManifest,
+		precompressOutputs,
+		writeManifest,
+		writeCache,
+		checkExamples,
+		writeReport,
+		appendMetrics,
+		checkStrict,
+		// with `--keep-going`, a run with failed pages ends here
+		func() error { return failed },
+		runHooks,
+	} {
+		if err := step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// how many pages are rendered at once, out of `n`
+func concurrency(n int) int {
+	if config.Jobs > 0 && config.Jobs < n {
+		return config.Jobs
+	}
+	return n
+}
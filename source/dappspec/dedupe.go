@@ -0,0 +1,237 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ## Flattened sources
+// A flattened contract carries every dependency inlined above it, which
+// buries the one contract the file is about. With `--dedupe`, top-level
+// contracts, interfaces and libraries that come from a dependency (as
+// told by the `// File: @openzeppelin/...` markers flatteners leave), or
+// whose code is identical to one documented elsewhere in the run, are
+// collapsed into a single section with a note and their code folded away.
+
+// the path comments flatteners put before each inlined file
+var flattenMarker = regexp.MustCompile(`^\s*//\s*(?:File:?\s*)?(\S+\.sol)\s*$`)
+
+// a `unitSpan` is a top-level declaration found in a file's lines
+type unitSpan struct {
+	// lines [start, end), `decl` is the line with the declaration on it
+	start, decl, end int
+	// `contract Token`, `library SafeMath`, ...
+	name string
+	// the path of the file it was flattened from, if known
+	path string
+	// hash of the code, ignoring comments and whitespace
+	hash string
+}
+
+// the braces opened minus those closed on a line, ignoring strings and
+// comments. `inComment` carries block comments over to the next line.
+func braceDelta(line []byte, inComment *bool) int {
+	delta := 0
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case *inComment:
+			if c == '*' && i+1 < len(line) && line[i+1] == '/' {
+				*inComment = false
+				i++
+			}
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return delta
+		case c == '/' && i+1 < len(line) && line[i+1] == '*':
+			*inComment = true
+			i++
+		case c == '"' || c == '\'':
+			for i++; i < len(line) && line[i] != c; i++ {
+				if line[i] == '\\' {
+					i++
+				}
+			}
+		case c == '{':
+			delta++
+		case c == '}':
+			delta--
+		}
+	}
+	return delta
+}
+
+func isCommentLine(line []byte) bool {
+	t := bytes.TrimSpace(line)
+	return bytes.HasPrefix(t, []byte("//")) || bytes.HasPrefix(t, []byte("/*")) || bytes.HasPrefix(t, []byte("*"))
+}
+
+// the top-level declarations in `lines`
+func splitUnits(lines [][]byte) []unitSpan {
+	var units []unitSpan
+	depth, prevEnd := 0, 0
+	inComment := false
+	var current *unitSpan
+	for i, line := range lines {
+		if depth == 0 && current == nil && !inComment {
+			if m := unitMatcher.FindSubmatch(bytes.TrimSpace(line)); m != nil {
+				u := unitSpan{start: i, decl: i, name: spaceMatcher.ReplaceAllString(string(m[0]), " ")}
+				// the doc comment right above belongs with it
+				for u.start > prevEnd && isCommentLine(lines[u.start-1]) && !flattenMarker.Match(lines[u.start-1]) {
+					u.start--
+				}
+				for j := prevEnd; j < u.decl; j++ {
+					if m := flattenMarker.FindSubmatch(lines[j]); m != nil {
+						u.path = string(m[1])
+					}
+				}
+				current = &u
+			}
+		}
+		depth += braceDelta(line, &inComment)
+		if current != nil && depth <= 0 && bytes.ContainsAny(line, "}") {
+			depth = 0
+			current.end = i + 1
+			current.hash = unitHash(lines[current.decl:current.end])
+			units = append(units, *current)
+			prevEnd, current = i+1, nil
+		}
+	}
+	return units
+}
+
+func unitHash(lines [][]byte) string {
+	h := sha256.New()
+	inComment := false
+	for _, line := range lines {
+		// drop comments, so re-documented copies still match
+		var code []byte
+		for i := 0; i < len(line); i++ {
+			if inComment {
+				if line[i] == '*' && i+1 < len(line) && line[i+1] == '/' {
+					inComment = false
+					i++
+				}
+				continue
+			}
+			if line[i] == '/' && i+1 < len(line) && line[i+1] == '/' {
+				break
+			}
+			if line[i] == '/' && i+1 < len(line) && line[i+1] == '*' {
+				inComment = true
+				i++
+				continue
+			}
+			code = append(code, line[i])
+		}
+		for _, field := range bytes.Fields(code) {
+			h.Write(field)
+			h.Write([]byte{' '})
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// whether a flattened file's path is a dependency rather than project code
+func isDependency(path string) bool {
+	return strings.HasPrefix(path, "@") || strings.Contains(path, "node_modules/") ||
+		strings.HasPrefix(path, "lib/") || strings.Contains(path, "/lib/")
+}
+
+// where each unit is documented, by hash: the first source (in sorted
+// order) declaring nothing else, or else the first declaring it at all.
+// Filled before any page is rendered and only read after.
+var unitOwners = map[string]string{}
+
+// read every source once to know where each unit is documented
+func scanUnits(files []string) {
+	if !config.Dedupe {
+		return
+	}
+	found := map[string][]unitSpan{}
+	for _, source := range files {
+		code, err := provider.Read(source)
+		if err != nil {
+			continue
+		}
+		if code, err = decodeSource(source, code); err != nil {
+			continue
+		}
+		code = bytes.ReplaceAll(code, []byte("\r\n"), []byte("\n"))
+		found[source] = splitUnits(bytes.Split(code, []byte("\n")))
+	}
+	for _, single := range []bool{true, false} {
+		for _, source := range files {
+			if units := found[source]; (len(units) == 1) == single {
+				for _, u := range units {
+					if _, ok := unitOwners[u.hash]; !ok {
+						unitOwners[u.hash] = source
+					}
+				}
+			}
+		}
+	}
+}
+
+// `parse`, with dependencies and duplicates collapsed when `--dedupe` is on
+func parseDeduped(source string, code []byte) *list.List {
+	if !config.Dedupe {
+		return parse(source, code)
+	}
+	lines := bytes.Split(code, []byte("\n"))
+	units := splitUnits(lines)
+	notes := make([]string, len(units))
+	seen := map[string]bool{}
+	expanded := 0
+	for i, u := range units {
+		switch owner := unitOwners[u.hash]; {
+		case seen[u.hash]:
+			notes[i] = fmt.Sprintf("`%s`, the same as above.", u.name)
+		case owner != "" && owner != source:
+			notes[i] = fmt.Sprintf("`%s`, the same as in [%s](%s).", u.name, pageTitle(owner), pageLink(pageOf(source), destinationTOC(owner)))
+		case isDependency(u.path):
+			notes[i] = fmt.Sprintf("`%s` from `%s`.", u.name, u.path)
+		default:
+			expanded++
+		}
+		seen[u.hash] = true
+	}
+	// a file of nothing but dependencies is about the last of them
+	if expanded == 0 && len(units) > 0 {
+		notes[len(units)-1] = ""
+	}
+
+	sections := list.New()
+	join := func(from, to int) []byte {
+		if from >= to {
+			return nil
+		}
+		return bytes.Join(lines[from:to], []byte("\n"))
+	}
+	pos := 0
+	for i, u := range units {
+		if notes[i] == "" {
+			continue
+		}
+		if rest := join(pos, u.start); len(bytes.TrimSpace(rest)) > 0 {
+			sections.PushBackList(parse(source, rest))
+		}
+		unitCode := append(join(u.start, u.end), '\n')
+		sections.PushBack(&Section{
+			docsText:      []byte(notes[i]),
+			codeText:      unitCode,
+			firstCodeLine: string(lines[u.decl]),
+			symbol:        parseSymbol(lines[u.decl]),
+			collapsed:     true,
+		})
+		pos = u.end
+	}
+	if rest := join(pos, len(lines)); len(bytes.TrimSpace(rest)) > 0 || pos == 0 {
+		sections.PushBackList(parse(source, rest))
+	}
+	return sections
+}
@@ -0,0 +1,131 @@
+package dappspec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Deployment
+// Whoever deploys a contract reads its docs first, for one thing: what to
+// pass the constructor, or the initializer behind a proxy. So constructors
+// and initializers taking arguments get a "Deploying" block listing the
+// parameters with their types and `@param` docs, and a snippet encoding
+// them with placeholder values.
+
+// a `Deployment` is how to deploy one contract
+type Deployment struct {
+	Contract string `json:"contract"`
+	// `constructor`, or the name of the initializer
+	Via    string            `json:"via"`
+	Params []DeploymentParam `json:"params"`
+	// Solidity encoding the arguments with placeholder values
+	Example string `json:"example"`
+}
+
+// a `DeploymentParam` is one argument
+type DeploymentParam struct {
+	Name       string `json:"name,omitempty"`
+	Type       string `json:"type"`
+	Notice     string `json:"notice,omitempty"`
+	NoticeHTML string `json:"-"`
+}
+
+var (
+	initializerModifier = regexp.MustCompile(`\b(?:initializer|reinitializer)\b`)
+	paramTag            = regexp.MustCompile(`(?s)^(\w+)\s*(.*)$`)
+	sizedType           = regexp.MustCompile(`^(u?int|bytes)\d+$`)
+)
+
+// whether the section declares something a deployer calls
+func deploymentEntry(sym *Symbol) bool {
+	if sym == nil || len(sym.Params) == 0 {
+		return false
+	}
+	if sym.Kind == "constructor" {
+		return true
+	}
+	return sym.Kind == "function" &&
+		(strings.HasPrefix(sym.Name, "initialize") || initializerModifier.MatchString(sym.Signature))
+}
+
+// the deployment block of a section, or nil
+func deployment(sec *Section) *Deployment {
+	sym := sec.symbol
+	if !deploymentEntry(sym) {
+		return nil
+	}
+	notices := map[string]string{}
+	for _, tag := range parseTags(sec.docsText) {
+		if m := paramTag.FindStringSubmatch(tag.Text); tag.Name == "param" && m != nil {
+			notices[m[1]] = m[2]
+		}
+	}
+	d := &Deployment{Contract: sym.Contract, Via: sym.Name}
+	for _, p := range sym.Params {
+		notice := notices[p.Name]
+		d.Params = append(d.Params, DeploymentParam{
+			Name:       p.Name,
+			Type:       p.Type,
+			Notice:     notice,
+			NoticeHTML: string(blackfriday.MarkdownCommon([]byte(notice))),
+		})
+	}
+	d.Example = deploymentExample(d)
+	return d
+}
+
+// a placeholder of type `t`
+func exampleValue(t string) string {
+	t = canonicalType(t)
+	switch {
+	case strings.HasSuffix(t, "[]"):
+		return "new " + t + "(0)"
+	case strings.HasSuffix(t, "]"):
+		return "/* " + t + " */"
+	case t == "address":
+		return "address(0)"
+	case t == "address payable":
+		return "payable(address(0))"
+	case t == "bool":
+		return "false"
+	case t == "string" || t == "bytes":
+		return `""`
+	case sizedType.MatchString(t):
+		return t + "(0)"
+	// interfaces, by convention `IName`
+	case len(t) > 1 && t[0] == 'I' && t[1] >= 'A' && t[1] <= 'Z':
+		return t + "(address(0))"
+	}
+	return "/* " + t + " */"
+}
+
+// the snippet encoding the arguments
+func deploymentExample(d *Deployment) string {
+	var args []string
+	for i, p := range d.Params {
+		arg := "    " + exampleValue(p.Type)
+		if i < len(d.Params)-1 {
+			arg += ","
+		}
+		if p.Name != "" {
+			arg += " // " + p.Name
+		}
+		args = append(args, arg)
+	}
+	contract := d.Contract
+	if contract == "" {
+		contract = "Contract"
+	}
+	if d.Via == "constructor" {
+		return fmt.Sprintf("bytes memory initCode = abi.encodePacked(\n    type(%s).creationCode,\n    abi.encode(\n%s\n    )\n);",
+			contract, indent(strings.Join(args, "\n"), "    "))
+	}
+	return fmt.Sprintf("bytes memory data = abi.encodeCall(%s.%s, (\n%s\n));", contract, d.Via, strings.Join(args, "\n"))
+}
+
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
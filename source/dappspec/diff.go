@@ -0,0 +1,105 @@
+package dappspec
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+// ## Symbol diffs
+// Comparing two versions of a file declaration by declaration, rather
+// than line by line: what was added, what was removed, and what kept its
+// name but changed its signature or its documentation. Feeds, release
+// notes and compatibility checks are all built on this.
+
+// a `DocSymbol` is a declaration together with its documentation
+type DocSymbol struct {
+	*Symbol
+	// The raw doc comment
+	Docs string
+	// Just the `@notice` text
+	Notice string
+}
+
+// the key a declaration is matched on between versions: overloads are
+// told apart by their parameter types, same-named members of different
+// contracts by the contract
+func (s *DocSymbol) Key() string {
+	if s.Contract == "" {
+		return s.Kind + " " + s.Canonical()
+	}
+	return s.Kind + " " + s.Contract + "." + s.Canonical()
+}
+
+// a `Change` is one difference between two versions
+type Change struct {
+	// "added", "removed", "changed" (signature) or "docs" (only the
+	// documentation changed)
+	Kind string
+	Old  *DocSymbol
+	New  *DocSymbol
+}
+
+// the newest version of the symbol, whichever side it is on
+func (c *Change) Symbol() *DocSymbol {
+	if c.New != nil {
+		return c.New
+	}
+	return c.Old
+}
+
+// the documented declarations of a file, keyed by `DocSymbol.Key`
+func docSymbols(source string, code []byte) map[string]*DocSymbol {
+	symbols := map[string]*DocSymbol{}
+	if getLanguage(source) == nil {
+		return symbols
+	}
+	code, err := decodeSource(source, code)
+	if err != nil {
+		return symbols
+	}
+	code = bytes.ReplaceAll(code, []byte("\r\n"), []byte("\n"))
+	_, code = foldLicense(code)
+	sections := parse(source, code)
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if sec.symbol == nil {
+			continue
+		}
+		ds := &DocSymbol{Symbol: sec.symbol, Docs: strings.TrimSpace(string(sec.docsText))}
+		var notices []string
+		for _, tag := range parseTags(sec.docsText) {
+			if tag.Name == "notice" {
+				notices = append(notices, tag.Text)
+			}
+		}
+		ds.Notice = strings.Join(notices, "\n")
+		symbols[ds.Key()] = ds
+	}
+	return symbols
+}
+
+// `diffSymbols` compares two versions of a file, sorted by key
+func diffSymbols(old, new map[string]*DocSymbol) []*Change {
+	var changes []*Change
+	for key, n := range new {
+		o, ok := old[key]
+		switch {
+		case !ok:
+			changes = append(changes, &Change{"added", nil, n})
+		case o.Signature != n.Signature:
+			changes = append(changes, &Change{"changed", o, n})
+		case o.Docs != n.Docs:
+			changes = append(changes, &Change{"docs", o, n})
+		}
+	}
+	for key, o := range old {
+		if _, ok := new[key]; !ok {
+			changes = append(changes, &Change{"removed", o, nil})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Symbol().Key() < changes[j].Symbol().Key()
+	})
+	return changes
+}
@@ -0,0 +1,79 @@
+package dappspec
+
+import (
+	"bytes"
+	"log"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ## Encodings
+// Everything downstream, the comment matcher and the Pygments round-trip
+// in particular, assumes UTF-8 without a byte order mark. Sources are
+// normalized to that before parsing: BOMs are dropped, UTF-16 is
+// transcoded, and anything else that is not valid UTF-8 is read as
+// Latin-1 with a warning pointing at the first offending line.
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+func decodeSource(source string, code []byte) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(code, bomUTF8):
+		code = code[len(bomUTF8):]
+	case bytes.HasPrefix(code, bomUTF16LE):
+		return decodeUTF16(source, code[2:], false)
+	case bytes.HasPrefix(code, bomUTF16BE):
+		return decodeUTF16(source, code[2:], true)
+	}
+	if utf8.Valid(code) {
+		return code, nil
+	}
+	log.Printf("dappspec: %s: line %d is not valid UTF-8, reading the file as Latin-1", source, invalidLine(code))
+	return decodeLatin1(code), nil
+}
+
+func decodeUTF16(source string, code []byte, bigEndian bool) ([]byte, error) {
+	if len(code)%2 != 0 {
+		// a truncated file should not stop the whole run
+		log.Printf("dappspec: %s: truncated UTF-16 input, dropping the last byte", source)
+		code = code[:len(code)-1]
+	}
+	units := make([]uint16, len(code)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(code[2*i])<<8 | uint16(code[2*i+1])
+		} else {
+			units[i] = uint16(code[2*i+1])<<8 | uint16(code[2*i])
+		}
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// every byte of Latin-1 is the code point of the same value
+func decodeLatin1(code []byte) []byte {
+	buf := make([]byte, 0, len(code)+len(code)/8)
+	for _, b := range code {
+		buf = utf8.AppendRune(buf, rune(b))
+	}
+	return buf
+}
+
+// the 1-based line of the first invalid UTF-8 sequence
+func invalidLine(code []byte) int {
+	line := 1
+	for len(code) > 0 {
+		r, size := utf8.DecodeRune(code)
+		if r == utf8.RuneError && size <= 1 {
+			return line
+		}
+		if r == '\n' {
+			line++
+		}
+		code = code[size:]
+	}
+	return line
+}
@@ -0,0 +1,60 @@
+package dappspec
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ## Environment
+// Every flag can also be set from the environment, as `DAPPSPEC_` and
+// the flag's name in capitals with dashes for underscores:
+// `DAPPSPEC_THEME=site/theme`, `DAPPSPEC_MINIFY=true`. The environment
+// comes between the config file and the flags, which is how CI systems
+// tend to configure tools. A few settings have names of their own, like
+// `DAPPSPEC_CONCURRENCY` for `--jobs`.
+
+const envPrefix = "DAPPSPEC_"
+
+// the variables that are not named after a flag
+var envAliases = map[string]string{
+	"CONCURRENCY": "jobs",
+}
+
+// the flags that are actions rather than settings
+var envIgnored = map[string]bool{
+	"version":      true,
+	"check-update": true,
+	"print-assets": true,
+}
+
+// the variable setting the flag `name`
+func envName(name string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// set the flags not given on the command line from the environment
+func applyEnvironment() error {
+	given := map[string]bool{}
+	commandLine.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	names := map[string]string{}
+	commandLine.VisitAll(func(f *flag.Flag) {
+		if !envIgnored[f.Name] {
+			names[envName(f.Name)] = f.Name
+		}
+	})
+	for alias, name := range envAliases {
+		names[envPrefix+alias] = name
+	}
+	for variable, name := range names {
+		value, ok := os.LookupEnv(variable)
+		if !ok || given[name] {
+			continue
+		}
+		if err := commandLine.Set(name, value); err != nil {
+			return fmt.Errorf("%s: %v", variable, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package dappspec
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## Events
+// Indexers start from the events a contract emits. `--events` writes
+// `docs/events.json` with every event of the build: its canonical
+// signature and `topic0`, the signature as a subgraph manifest's
+// `eventHandlers` want it (`Transfer(indexed address,indexed address,uint256)`),
+// an ABI fragment, and the NatSpec of the event and its parameters.
+// Contracts, enums, structs and value types in the parameters are written
+// as their ABI types.
+
+// an `EventEntry` is one event
+type EventEntry struct {
+	Source   string `json:"source"`
+	Contract string `json:"contract,omitempty"`
+	Name     string `json:"name"`
+	// The signature, topic, manifest signature and ABI fragment are left
+	// out when a parameter has a type not declared in the sources
+	Signature string `json:"signature,omitempty"`
+	// Empty for anonymous events, which have no topic for their signature
+	Topic0    string       `json:"topic0,omitempty"`
+	Manifest  string       `json:"manifest,omitempty"`
+	Anonymous bool         `json:"anonymous,omitempty"`
+	Params    []EventParam `json:"params"`
+	Notice    string       `json:"notice,omitempty"`
+	Dev       string       `json:"dev,omitempty"`
+	ABI       *EventABI    `json:"abi,omitempty"`
+}
+
+type EventParam struct {
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type"`
+	Indexed bool   `json:"indexed"`
+	Notice  string `json:"notice,omitempty"`
+}
+
+// an `EventABI` is the event as it appears in a contract's ABI
+type EventABI struct {
+	Type      string     `json:"type"`
+	Name      string     `json:"name"`
+	Inputs    []ABIInput `json:"inputs"`
+	Anonymous bool       `json:"anonymous"`
+}
+
+type ABIInput struct {
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Indexed bool   `json:"indexed"`
+	// The fields of a `tuple`
+	Components []ABIInput `json:"components,omitempty"`
+}
+
+var (
+	eventMatcher     = regexp.MustCompile(`(?s)\bevent\s+(\w+)\s*\(([^;]*?)\)\s*(anonymous\s*)?;`)
+	unitDeclarations = regexp.MustCompile(`(?m)^\s*(?:abstract\s+)?(?:contract|interface|library)\s+(\w+)`)
+)
+
+// the events declared in `doc`. Undocumented events share a section with
+// the code before them, so the code of every section is searched; a
+// section's docs go to the event it starts with.
+func documentEvents(doc *Document) []EventEntry {
+	var events []EventEntry
+	// the contract declared last, also when sections of its own do not
+	// say so
+	contract := ""
+	for _, sec := range sectionViews(doc) {
+		if sec.unit != "" {
+			contract = sec.unit
+		}
+		units := unitDeclarations.FindAllSubmatchIndex(sec.codeText, -1)
+		for _, m := range eventMatcher.FindAllSubmatchIndex(sec.codeText, -1) {
+			for _, u := range units {
+				if u[0] < m[0] {
+					contract = string(sec.codeText[u[2]:u[3]])
+				}
+			}
+			name := string(sec.codeText[m[2]:m[3]])
+			e := EventEntry{
+				Source:    doc.Source,
+				Contract:  contract,
+				Name:      name,
+				Anonymous: m[6] >= 0,
+				Params:    []EventParam{},
+			}
+			notices := map[string]string{}
+			if sym := sec.symbol; sym != nil && sym.Kind == "event" && sym.Name == name {
+				for _, tag := range parseTags(sec.docsText) {
+					switch tag.Name {
+					case "notice":
+						e.Notice = tag.Text
+					case "dev":
+						e.Dev = tag.Text
+					case "param":
+						if m := paramTag.FindStringSubmatch(tag.Text); m != nil {
+							notices[m[1]] = m[2]
+						}
+					}
+				}
+			}
+			var types, manifest []string
+			abi := &EventABI{Type: "event", Name: name, Inputs: []ABIInput{}, Anonymous: e.Anonymous}
+			for _, p := range parseParams(spaceMatcher.ReplaceAllString(string(sec.codeText[m[4]:m[5]]), " ")) {
+				t, ok := abiType(p.Type)
+				in, _ := abiInput(p)
+				if !ok {
+					t, abi = canonicalType(p.Type), nil
+				} else if abi != nil {
+					abi.Inputs = append(abi.Inputs, in)
+				}
+				e.Params = append(e.Params, EventParam{p.Name, t, p.Indexed, notices[p.Name]})
+				types = append(types, t)
+				if p.Indexed {
+					t = "indexed " + t
+				}
+				manifest = append(manifest, t)
+			}
+			if abi != nil {
+				e.Signature = name + "(" + strings.Join(types, ",") + ")"
+				e.Manifest = name + "(" + strings.Join(manifest, ",") + ")"
+				e.ABI = abi
+				if !e.Anonymous {
+					e.Topic0 = "0x" + hex.EncodeToString(keccak256([]byte(e.Signature)))
+				}
+			} else {
+				lintWarn("abi", "%s: %s has a parameter of a type not declared in the sources, so its signature and topic are left out", doc.Source, name)
+			}
+			events = append(events, e)
+		}
+		if len(units) > 0 {
+			u := units[len(units)-1]
+			contract = string(sec.codeText[u[2]:u[3]])
+		}
+	}
+	return events
+}
+
+// write `docs/events.json` for every source of the build
+func writeEvents() error {
+	if !config.Events {
+		return nil
+	}
+	events := []EventEntry{}
+	for _, source := range sources {
+		doc, err := sourceDocument(source)
+		if err != nil {
+			return err
+		}
+		events = append(events, documentEvents(doc)...)
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].Contract != events[j].Contract {
+			return events[i].Contract < events[j].Contract
+		}
+		return events[i].Name < events[j].Name
+	})
+	b, err := json.MarshalIndent(struct {
+		Events []EventEntry `json:"events"`
+	}{events}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(outputDir(), "events.json")
+	log.Println("dappspec: ", len(events), "events ->", dest)
+	return writeOutput(dest, append(b, '\n'), 0644)
+}
@@ -0,0 +1,253 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/sambacha/go-natspec/v2/natspec"
+)
+
+// ## Examples
+// A `@custom:example` tag, or a fenced block tagged ```` ```solidity example ````,
+// holds a snippet showing how to use the declaration it documents. Besides
+// staying where they are on the literate page, the snippets of a file are
+// collected on an "Examples" page of their own, each linking back to its
+// declaration.
+//
+// Examples go stale as the code changes under them. `--check-examples`
+// compiles every snippet with `solc` against the project: a snippet
+// declaring contracts or functions of its own is compiled as a file, and
+// anything else as the body of a function. Either way it imports the file
+// it documents. The build fails if a snippet does not compile.
+
+// an `Example` is one snippet
+type Example struct {
+	// the section tag of the declaration on the literate page
+	Anchor   string
+	Label    string
+	Code     string
+	CodeHTML string
+}
+
+type ExamplesData struct {
+	Title     string
+	Literate  string
+	Reference string
+	Examples  []*Example
+	PageChrome
+}
+
+var (
+	fenceLine = regexp.MustCompile("^\\s*(```+|~~~+)\\s*(.*)$")
+	// snippets starting with one of these are compiled as a file
+	topLevel = regexp.MustCompile(`^(?:pragma|import|abstract|contract|interface|library|function|struct|enum|error|event|type|using)\b`)
+)
+
+// compute the output location of the examples page
+func examplesDestination(source string) string {
+	return strings.TrimSuffix(destination(source), ".html") + ".examples.html"
+}
+
+// the file name of the examples page, relative to the literate one
+func examplesLink(source string) string {
+	return filepath.Base(examplesDestination(source))
+}
+
+// the snippets in `docs`: the text of `@custom:example` tags, without
+// the fence around it if there is one, and the fenced blocks tagged
+// `example`
+func exampleSnippets(docs []byte) []string {
+	var (
+		snippets []string
+		lines    []string
+		// collecting a tag, and collecting a block outside of a tag
+		inTag, inBlock bool
+		fence          string
+	)
+	flush := func() {
+		if snippet := dedent(lines); snippet != "" {
+			snippets = append(snippets, snippet)
+		}
+		lines = nil
+	}
+	for _, line := range strings.Split(string(docs), "\n") {
+		if fence != "" {
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+				if inBlock {
+					inBlock = false
+					flush()
+				}
+			} else if inTag || inBlock {
+				lines = append(lines, line)
+			}
+			continue
+		}
+		if m := fenceLine.FindStringSubmatch(line); m != nil {
+			fence = m[1]
+			info := strings.Fields(m[2])
+			if !inTag && len(info) == 2 && (info[0] == "solidity" || info[0] == "sol") && info[1] == "example" {
+				inBlock = true
+			}
+			continue
+		}
+		if name, text, ok := natspec.TagLine(line); ok {
+			if inTag {
+				flush()
+			}
+			inTag = name == "custom:example"
+			if inTag && strings.TrimSpace(text) != "" {
+				lines = append(lines, text)
+			}
+			continue
+		}
+		if inTag {
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return snippets
+}
+
+// join `lines`, without blank lines around them or the indentation they
+// all share
+func dedent(lines []string) string {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	prefix, first := "", true
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+		if first {
+			prefix, first = indent, false
+		}
+		for !strings.HasPrefix(indent, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimPrefix(line, prefix)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// the examples of every section of `doc`
+func documentExamples(doc *Document) []*Example {
+	var examples []*Example
+	for _, sec := range sectionViews(doc) {
+		for _, code := range exampleSnippets(sec.docsText) {
+			examples = append(examples, &Example{Anchor: sec.Tag, Label: sectionLabel(doc.Source, sec), Code: code})
+		}
+	}
+	return examples
+}
+
+func generateExamples(source, title string, examples []*Example) error {
+	sections := list.New()
+	for _, example := range examples {
+		sections.PushBack(&Section{codeText: []byte(example.Code)})
+	}
+	if err := highlight(source, sections); err != nil {
+		return err
+	}
+	i := 0
+	for e := sections.Front(); e != nil; e = e.Next() {
+		examples[i].CodeHTML = string(e.Value.(*Section).CodeHTML)
+		i++
+	}
+	data := ExamplesData{
+		Title:      title,
+		Literate:   filepath.Base(destination(source)),
+		Examples:   examples,
+		PageChrome: pageChromeAt(pageOf(source)),
+	}
+	if config.Reference {
+		data.Reference = referenceLink(source)
+	}
+	dest := examplesDestination(source)
+	page, err := executeTemplate("examples", mustAsset("assets/examples.html"), data)
+	if err != nil {
+		return err
+	}
+	html := finishPage(page)
+	log.Println("dappspec: ", source, " -> ", dest)
+	return writeOutput(dest, html, 0644)
+}
+
+// compile the examples of every source with `solc`
+func checkExamples() error {
+	if !config.CheckExamples {
+		return nil
+	}
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		return fmt.Errorf("--check-examples needs solc on the PATH: %v", err)
+	}
+	dir, err := os.MkdirTemp("", "dappspec-examples")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	// the project's import remappings, as Foundry keeps them
+	var remappings []string
+	if text, err := os.ReadFile("remappings.txt"); err == nil {
+		remappings = strings.Fields(string(text))
+	}
+
+	checked, failed := 0, 0
+	for _, source := range sources {
+		doc, err := sourceDocument(source)
+		if err != nil {
+			return err
+		}
+		for _, example := range documentExamples(doc) {
+			checked++
+			file := filepath.Join(dir, fmt.Sprintf("Example%d.sol", checked))
+			if err := os.WriteFile(file, exampleUnit(source, example.Code, checked), 0644); err != nil {
+				return err
+			}
+			args := append([]string{"--base-path", ".", "--allow-paths", ".," + dir}, remappings...)
+			out, err := exec.Command(solc, append(args, file)...).CombinedOutput()
+			if err != nil {
+				failed++
+				log.Println("dappspec: ", "example for", example.Label, "in", source, "does not compile:\n"+
+					strings.ReplaceAll(string(out), file, "example"))
+			}
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d examples do not compile", failed, checked)
+	}
+	log.Println("dappspec: ", checked, "examples compile")
+	return nil
+}
+
+// the file compiling the snippet `code` against `source`
+func exampleUnit(source, code string, n int) []byte {
+	var b bytes.Buffer
+	b.WriteString("// SPDX-License-Identifier: UNLICENSED\n")
+	if !strings.HasPrefix(code, "pragma") {
+		b.WriteString("pragma solidity >=0.0.0;\n")
+	}
+	fmt.Fprintf(&b, "import %q;\n\n", filepath.ToSlash(filepath.Clean(source)))
+	if topLevel.MatchString(code) {
+		b.WriteString(code)
+	} else {
+		fmt.Fprintf(&b, "contract DappspecExample%d {\n    function example() public {\n%s\n    }\n}\n", n, indent(code, "        "))
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
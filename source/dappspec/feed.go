@@ -0,0 +1,166 @@
+package dappspec
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ## Change feed
+// `--feed` writes `docs/changes.atom`, an Atom feed with one entry per
+// commit (or, with `feed.by` set to `tag`, per release tag) that changed
+// documented declarations, listing what was added, removed or changed
+// along with its `@notice`. Integrators can subscribe to it to follow the
+// API without watching the repository.
+
+// `Feed` configures the change feed
+type Feed struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// "commit" (default) or "tag"
+	By string `json:"by,omitempty"`
+	// How many entries to keep (default 20)
+	Entries int `json:"entries,omitempty"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link,omitempty"`
+	Content atomContent `xml:"content"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+var changeVerbs = map[string]string{
+	"added":   "Added",
+	"removed": "Removed",
+	"changed": "Changed",
+	"docs":    "Documentation updated for",
+}
+
+// an HTML summary of what changed, per file
+func changesHTML(changes map[string][]*Change) string {
+	var b strings.Builder
+	var files []string
+	for source := range changes {
+		files = append(files, source)
+	}
+	sort.Strings(files)
+	for _, source := range files {
+		fmt.Fprintf(&b, "<h3>%s</h3>\n<ul>\n", html.EscapeString(repoPath(source)))
+		for _, c := range changes[source] {
+			sym := c.Symbol()
+			fmt.Fprintf(&b, "<li>%s <code>%s</code>", changeVerbs[c.Kind], html.EscapeString(sym.Signature))
+			if sym.Notice != "" {
+				fmt.Fprintf(&b, ": %s", html.EscapeString(sym.Notice))
+			}
+			b.WriteString("</li>\n")
+		}
+		b.WriteString("</ul>\n")
+	}
+	return b.String()
+}
+
+// the tag URI identifying something in this feed
+func feedID(parts ...string) string {
+	if base := baseURL(); base != "" {
+		return base + "/changes.atom#" + strings.Join(parts, "/")
+	}
+	return "urn:dappspec:" + strings.Join(parts, ":")
+}
+
+func writeFeed() error {
+	if !config.Feed.Enabled {
+		return nil
+	}
+	limit := config.Feed.Entries
+	if limit <= 0 {
+		limit = 20
+	}
+	paths := make([]string, len(sources))
+	for i, source := range sources {
+		paths[i] = repoPath(source)
+	}
+	var revs []*Revision
+	var err error
+	if config.Feed.By == "tag" {
+		revs, err = recentTags(limit)
+	} else {
+		// one more than needed, as the oldest only serves as a baseline
+		revs, err = commitsTouching(paths, limit+1)
+	}
+	if err != nil {
+		return err
+	}
+
+	feed := atomFeed{ID: feedID("changes"), Title: "Documentation changes"}
+	if base := baseURL(); base != "" {
+		feed.Links = []atomLink{{Href: base + "/changes.atom", Rel: "self"}}
+	}
+	for i, rev := range revs {
+		if len(feed.Entries) == limit {
+			break
+		}
+		var from string
+		if i+1 < len(revs) {
+			from = revs[i+1].Ref
+		} else if config.Feed.By != "tag" {
+			from = rev.Hash + "^"
+		}
+		changes := changesBetween(from, rev.Ref, sources)
+		if len(changes) == 0 {
+			continue
+		}
+		title := rev.Subject
+		if config.Feed.By == "tag" {
+			title = rev.Ref
+		}
+		entry := atomEntry{
+			ID:      feedID(rev.Hash),
+			Title:   title,
+			Updated: rev.Date.UTC().Format(time.RFC3339),
+			Author:  atomAuthor{rev.Author},
+			Content: atomContent{"html", changesHTML(changes)},
+		}
+		if config.Repo.URL != "" {
+			entry.Links = []atomLink{{Href: strings.TrimSuffix(config.Repo.URL, "/") + "/commit/" + rev.Hash}}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	feed.Updated = time.Unix(0, 0).UTC().Format(time.RFC3339)
+	if len(feed.Entries) > 0 {
+		feed.Updated = feed.Entries[0].Updated
+	}
+	b, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(filepath.Join(outputDir(), "changes.atom"), append([]byte(xml.Header), append(b, '\n')...), 0644)
+}
@@ -0,0 +1,93 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"regexp"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Footnotes
+// A tricky algorithm is best walked through line by line. A line of docs
+// `[1] Rounds down, in favour of the vault` is a footnote to the line of
+// code of the section ending in `// [1]`: the marker becomes a link to the
+// note, and the notes are listed under the code. Without such a line it is
+// left in the docs, so a numbered list of references stays where it was
+// written. Inside a body, a doc comment `/// [1] ...` is a note for the
+// line below it, which gets the marker without splitting the section.
+
+// a `Footnote` explains a line of the code of a section
+type Footnote struct {
+	Number string `json:"number"`
+	Text   string `json:"text"`
+	HTML   string `json:"-"`
+}
+
+var (
+	footnoteLine = regexp.MustCompile(`^\s*\[(\d+)\]\s+(.*?)\s*$`)
+	// a marker as it is in the code, and as it is once highlighted
+	footnoteMarker     = regexp.MustCompile(`//[ \t]*\[(\d+)\][ \t]*$`)
+	footnoteMarkerHTML = regexp.MustCompile(`(?m)(?:<span class="[^"]*">)?//[ \t]*\[(\d+)\](?:</span>)?[ \t]*$`)
+)
+
+// the number of the note in the docs line `docs` of a body, if it is one
+func bodyFootnote(docs []byte) (string, bool) {
+	m := footnoteLine.FindSubmatch(docs)
+	if m == nil {
+		return "", false
+	}
+	return string(m[1]), true
+}
+
+// take the footnotes out of the docs of every section
+func footnoteSections(sections *list.List) {
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if !bytes.Contains(sec.docsText, []byte("[")) {
+			continue
+		}
+		markers := map[string]bool{}
+		for _, line := range bytes.Split(sec.codeText, []byte("\n")) {
+			if m := footnoteMarker.FindSubmatch(line); m != nil {
+				markers[string(m[1])] = true
+			}
+		}
+		var kept [][]byte
+		for _, line := range bytes.Split(sec.docsText, []byte("\n")) {
+			m := footnoteLine.FindSubmatch(line)
+			if m == nil || !markers[string(m[1])] {
+				kept = append(kept, line)
+				continue
+			}
+			text := string(m[2])
+			html := bytes.TrimSpace(blackfriday.MarkdownCommon(m[2]))
+			html = bytes.TrimSuffix(bytes.TrimPrefix(html, []byte("<p>")), []byte("</p>"))
+			sec.footnotes = append(sec.footnotes, Footnote{string(m[1]), text, string(html)})
+		}
+		if len(sec.footnotes) == 0 {
+			continue
+		}
+		sec.docsText = bytes.Join(kept, []byte("\n"))
+	}
+}
+
+// the highlighted code of the section `tag` with its markers linked to
+// its footnotes
+func markFootnotes(tag, code string, notes []Footnote) string {
+	if len(notes) == 0 {
+		return code
+	}
+	numbers := map[string]bool{}
+	for _, note := range notes {
+		numbers[note.Number] = true
+	}
+	return footnoteMarkerHTML.ReplaceAllStringFunc(code, func(marker string) string {
+		n := footnoteMarkerHTML.FindStringSubmatch(marker)[1]
+		if !numbers[n] {
+			return marker
+		}
+		return fmt.Sprintf(`<a class="footnote-ref" id="section-%s-ref-%s" href="#section-%s-note-%s"><sup>%s</sup></a>`, tag, n, tag, n, n)
+	})
+}
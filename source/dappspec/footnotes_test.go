@@ -0,0 +1,50 @@
+package dappspec
+
+import (
+	"container/list"
+	"testing"
+)
+
+// A numbered line of docs is a footnote only if a line of the code refers
+// to it; a list of references no line refers to stays in the docs.
+func TestFootnoteSections(t *testing.T) {
+	tests := []struct {
+		docs, code string
+		kept       string
+		notes      []string
+	}{
+		{
+			docs:  "Shares of the vault\n[1] Rounds down, in favour of the vault",
+			code:  "function shares(uint assets) view returns (uint) {\n    return assets * supply / total; // [1]\n}",
+			kept:  "Shares of the vault",
+			notes: []string{"1"},
+		},
+		{
+			docs: "Follows the papers\n[1] Uniswap v2, 2020\n[2] Uniswap v3, 2021",
+			code: "function quote() view returns (uint) {}",
+			kept: "Follows the papers\n[1] Uniswap v2, 2020\n[2] Uniswap v3, 2021",
+		},
+		{
+			docs:  "[1] Checked above\n[2] Not a note of this code",
+			code:  "x = y; // [1]",
+			kept:  "[2] Not a note of this code",
+			notes: []string{"1"},
+		},
+	}
+	for _, tt := range tests {
+		sections := list.New()
+		sec := &Section{docsText: []byte(tt.docs), codeText: []byte(tt.code)}
+		sections.PushBack(sec)
+		footnoteSections(sections)
+		if string(sec.docsText) != tt.kept {
+			t.Errorf("docs of %q: %q, want %q", tt.docs, sec.docsText, tt.kept)
+		}
+		var notes []string
+		for _, note := range sec.footnotes {
+			notes = append(notes, note.Number)
+		}
+		if len(notes) != len(tt.notes) || (len(notes) > 0 && notes[0] != tt.notes[0]) {
+			t.Errorf("footnotes of %q: %v, want %v", tt.docs, notes, tt.notes)
+		}
+	}
+}
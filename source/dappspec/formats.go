@@ -0,0 +1,286 @@
+package dappspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+)
+
+// ## Output formats
+// Pages are HTML by default, but `--format html,markdown,json,natspec,mdbook`
+// writes any mix of formats from the same parse. HTML keeps going to
+// `docs/`, the others each get a subdirectory of their own.
+
+// the formats of this run, HTML if none are configured
+func formats() []string {
+	if len(config.Formats) == 0 {
+		return []string{"html"}
+	}
+	return config.Formats
+}
+
+func wantsFormat(name string) bool {
+	for _, f := range formats() {
+		if f == name {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFormats() error {
+	var names []string
+	for _, r := range allRenderers {
+		names = append(names, r.Name())
+	}
+	for _, f := range formats() {
+		known := false
+		for _, name := range names {
+			known = known || f == name
+		}
+		if !known {
+			return fmt.Errorf("unknown format %q (want %s)", f, strings.Join(names, ", "))
+		}
+	}
+	return nil
+}
+
+// where a non-HTML format of `source` goes, under `docs/<dir>`
+func formatDestination(source, dir, ext string) string {
+	return filepath.Join(outputDir(), dir, filepath.FromSlash(strings.TrimSuffix(outputPath(source), ".html"))+ext)
+}
+
+func writeFormat(source, dest string, content []byte) ([]string, error) {
+	ensureDirectory(filepath.Dir(dest))
+	log.Println("dappspec: ", source, " -> ", dest)
+	if err := writeOutput(dest, content, 0644); err != nil {
+		return nil, err
+	}
+	return []string{dest}, nil
+}
+
+// a file as Markdown: the docs as prose, the code fenced. With
+// `frontMatter`, the page starts with the YAML front matter static site
+// generators like MkDocs and Docusaurus read its title and position from.
+func markdownPage(doc *Document, frontMatter bool) []byte {
+	lang := fenceLanguage(doc.Source)
+	var b bytes.Buffer
+	if frontMatter {
+		title, _ := json.Marshal(pageTitle(doc.Source))
+		fmt.Fprintf(&b, "---\ntitle: %s\n", title)
+		if order := pageMetas[doc.Source].Order; order != nil {
+			fmt.Fprintf(&b, "sidebar_position: %d\n", *order)
+		}
+		b.WriteString("---\n\n")
+	}
+	fmt.Fprintf(&b, "# %s\n\n", pageTitle(doc.Source))
+	for _, entry := range doc.Metadata {
+		fmt.Fprintf(&b, "- **%s**: %s\n", entry.Name, entry.Text)
+	}
+	if len(doc.Metadata) > 0 {
+		b.WriteString("\n")
+	}
+	numbers := sectionNumbers(doc)
+	for _, sec := range sectionViews(doc) {
+		if sec.GroupTitle != "" {
+			fmt.Fprintf(&b, "## %s\n\n", sec.GroupTitle)
+		}
+		if sec.symbol != nil && !sec.collapsed {
+			if n := numbers[sec.Section]; n != "" {
+				fmt.Fprintf(&b, "### %s `%s`\n\n", n, sec.symbol.Name)
+			} else {
+				fmt.Fprintf(&b, "### `%s`\n\n", sec.symbol.Name)
+			}
+		}
+		if k := unitKindOf(sec.symbol); k != nil {
+			fmt.Fprintf(&b, "> **%s** `%s`: %s\n\n", k.Kind, k.Name, k.Note)
+		}
+		if docs := markdownDocs(sec.docsText); len(docs) > 0 {
+			b.Write(docs)
+			b.WriteString("\n\n")
+		}
+		if sec.symbol != nil && len(sec.symbol.Members) > 0 {
+			b.Write(memberTable(sec.symbol.Members))
+		}
+		code := bytes.Trim(sec.codeText, "\n")
+		switch {
+		case sec.collapsed:
+			fmt.Fprintf(&b, "<details><summary>%d lines</summary>\n\n```%s\n%s\n```\n\n</details>\n\n", bytes.Count(code, []byte("\n"))+1, lang, code)
+		case len(bytes.TrimSpace(code)) > 0:
+			fmt.Fprintf(&b, "```%s\n%s\n```\n\n", lang, code)
+		}
+		for _, note := range sec.footnotes {
+			fmt.Fprintf(&b, "- [%s] %s\n", note.Number, note.Text)
+		}
+		if len(sec.footnotes) > 0 {
+			b.WriteString("\n")
+		}
+	}
+	return append(bytes.TrimRight(b.Bytes(), "\n"), '\n')
+}
+
+// the docs of a section as prose: notices and `@dev` notes as paragraphs,
+// parameters and returns as lists, other tags by name
+func markdownDocs(docs []byte) []byte {
+	docs = bytes.TrimSpace(docs)
+	if !hasTags(docs) {
+		return docs
+	}
+	var text, params, returns, other []string
+	for _, tag := range parseTags(docs) {
+		body := strings.ReplaceAll(strings.TrimSpace(tag.Text), "\n", "\n  ")
+		switch tag.Name {
+		case "notice", "dev":
+			text = append(text, strings.TrimSpace(tag.Text))
+		case "param":
+			name, rest, _ := strings.Cut(body, " ")
+			params = append(params, fmt.Sprintf("- `%s`: %s", name, strings.TrimSpace(rest)))
+		case "return":
+			returns = append(returns, "- "+body)
+		default:
+			other = append(other, fmt.Sprintf("- **%s**: %s", tag.Name, body))
+		}
+	}
+	parts := text
+	if len(params) > 0 {
+		parts = append(parts, "**Parameters**\n\n"+strings.Join(params, "\n"))
+	}
+	if len(returns) > 0 {
+		parts = append(parts, "**Returns**\n\n"+strings.Join(returns, "\n"))
+	}
+	if len(other) > 0 {
+		parts = append(parts, strings.Join(other, "\n"))
+	}
+	return []byte(strings.Join(parts, "\n\n"))
+}
+
+// `markdownRenderer` writes `docs/markdown/<name>.md`, or wherever the
+// HTML page would go with `.md` for `.html`
+type markdownRenderer struct{}
+
+func (markdownRenderer) Name() string { return "markdown" }
+
+func (markdownRenderer) Render(doc *Document) ([]string, error) {
+	dest := formatDestination(doc.Source, "markdown", ".md")
+	return writeFormat(doc.Source, dest, markdownPage(doc, true))
+}
+
+func (markdownRenderer) Finish() error { return nil }
+
+// `mdbookRenderer` writes the same Markdown as an mdBook in
+// `docs/mdbook/`, ready for `mdbook build`
+type mdbookRenderer struct{}
+
+func (mdbookRenderer) Name() string { return "mdbook" }
+
+func (mdbookRenderer) Render(doc *Document) ([]string, error) {
+	dest := formatDestination(doc.Source, filepath.Join("mdbook", "src"), ".md")
+	return writeFormat(doc.Source, dest, markdownPage(doc, false))
+}
+
+func (mdbookRenderer) Finish() error {
+	var summary bytes.Buffer
+	summary.WriteString("# Summary\n\n")
+	for _, source := range sources {
+		fmt.Fprintf(&summary, "- [%s](%s)\n", pageTitle(source), strings.TrimSuffix(outputPath(source), ".html")+".md")
+	}
+	if err := writeOutput(filepath.Join(outputDir(), "mdbook", "src", "SUMMARY.md"), summary.Bytes(), 0644); err != nil {
+		return err
+	}
+	book := "[book]\ntitle = \"Contracts\"\nsrc = \"src\"\n"
+	return writeOutput(filepath.Join(outputDir(), "mdbook", "book.toml"), []byte(book), 0644)
+}
+
+// a `JSONSection` is a section in the JSON output
+type JSONSection struct {
+	Anchor string  `json:"anchor"`
+	Group  string  `json:"group,omitempty"`
+	Docs   string  `json:"docs,omitempty"`
+	Code   string  `json:"code,omitempty"`
+	Symbol *Symbol `json:"symbol,omitempty"`
+	// Folded away as a dependency or duplicate
+	Collapsed bool `json:"collapsed,omitempty"`
+	// The sections mentioning this one with `@@`
+	ReferencedBy []Backlink `json:"referencedBy,omitempty"`
+	// The libraries attached here with `using`, or the attachments of a
+	// library
+	Uses   []Backlink `json:"uses,omitempty"`
+	UsedBy []Backlink `json:"usedBy,omitempty"`
+	// What to pass a constructor or initializer
+	Deployment *Deployment `json:"deployment,omitempty"`
+	// The size of a function
+	Metrics *FunctionMetrics `json:"metrics,omitempty"`
+	// The tests exercising a function
+	Tests []Backlink `json:"tests,omitempty"`
+	// With `numberSections`, like `1.2`
+	Number string `json:"number,omitempty"`
+	// Notes on lines of the code
+	Footnotes []Footnote `json:"footnotes,omitempty"`
+}
+
+// a `JSONDocument` is a file in the JSON output
+type JSONDocument struct {
+	Source   string          `json:"source"`
+	Title    string          `json:"title"`
+	License  *License        `json:"license,omitempty"`
+	Metadata []MetadataEntry `json:"metadata,omitempty"`
+	Coverage Coverage        `json:"coverage"`
+	Sections []JSONSection   `json:"sections"`
+	// The `userdoc` and `devdoc` of each contract, as solc emits them
+	Contracts map[string]*ContractDocs `json:"contracts,omitempty"`
+}
+
+// `jsonRenderer` writes `docs/json/<name>.json`, for other tools to
+// build on
+type jsonRenderer struct{}
+
+func (jsonRenderer) Name() string { return "json" }
+
+func (jsonRenderer) Render(doc *Document) ([]string, error) {
+	out := JSONDocument{
+		Source:   filepath.ToSlash(doc.Source),
+		Title:    pageTitle(doc.Source),
+		License:  doc.License,
+		Metadata: doc.Metadata,
+		Coverage: doc.Coverage,
+	}
+	if strings.HasSuffix(doc.Source, ".sol") {
+		out.Contracts = contractDocs(doc)
+	}
+	views := sectionViews(doc)
+	tests := testsOf(pageOf(doc.Source), views)
+	numbers := sectionNumbers(doc)
+	for _, sec := range views {
+		out.Sections = append(out.Sections, JSONSection{
+			Anchor: "section-" + sec.Tag,
+			Group:  sec.group,
+			Docs:   string(bytes.TrimSpace(sec.docsText)),
+			Code:   string(bytes.Trim(sec.codeText, "\n")),
+			Symbol: sec.symbol,
+
+			Collapsed:    sec.collapsed,
+			ReferencedBy: referencedBy(pageOf(doc.Source), "section-"+sec.Tag),
+			Uses:         usesOf(pageOf(doc.Source), "section-"+sec.Tag),
+			UsedBy:       usedByOf(pageOf(doc.Source), "section-"+sec.Tag, sec.symbol),
+			Deployment:   deployment(sec.Section),
+			Metrics:      metricsOf(sec.Section),
+			Tests:        tests[sec.Section],
+			Number:       numbers[sec.Section],
+			Footnotes:    sec.footnotes,
+		})
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	dest := formatDestination(doc.Source, "json", ".json")
+	return writeFormat(doc.Source, dest, b.Bytes())
+}
+
+func (jsonRenderer) Finish() error { return nil }
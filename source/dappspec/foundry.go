@@ -0,0 +1,105 @@
+package dappspec
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ## Foundry tests and scripts
+// What a test checks is documentation too. With `foundryTests`
+// (`--foundry-tests`), the tests under `test/` (`*.t.sol`) and the scripts
+// under `script/` (`*.s.sol`) are documented along with the sources, on
+// pages of their own under `docs/tests/` listed apart in the table of
+// contents. Their sections are grouped the way a test reads: setup
+// (`setUp` and constructors) first, then the tests, fuzz tests (tests
+// taking arguments), invariants, the entry points of scripts (`run`) and
+// the helpers. Invariants, fuzz tests and tests expected to revert
+// (`testFail...`, `test_Revert...`) are flagged. Tests and scripts do not
+// count towards the documentation coverage.
+
+// the directories tests and scripts are found in
+var foundryDirs = []string{"test", "script"}
+
+// whether `source` is a Foundry test or script documented as such
+func isTestSource(source string) bool {
+	return config.FoundryTests && (strings.HasSuffix(source, ".t.sol") || strings.HasSuffix(source, ".s.sol"))
+}
+
+// the tests and scripts in the working tree
+func foundrySources() ([]string, error) {
+	var found []string
+	for _, dir := range foundryDirs {
+		if _, err := os.Stat(dir); err != nil {
+			continue
+		}
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && isTestSource(path) {
+				found = append(found, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// `all` split into the sources and the tests and scripts
+func splitTestSources(all []string) (docs, tests []string) {
+	if !config.FoundryTests {
+		return all, nil
+	}
+	for _, source := range all {
+		if isTestSource(source) {
+			tests = append(tests, source)
+		} else {
+			docs = append(docs, source)
+		}
+	}
+	return docs, tests
+}
+
+// the group of a section of a test or script
+func testGroupOf(sec *Section) string {
+	sym := sec.symbol
+	if sym == nil || sym.IsUnit() {
+		return ""
+	}
+	switch {
+	case sym.Kind == "constructor" || sym.Kind == "function" && sym.Name == "setUp":
+		return "setup"
+	case sym.Kind != "function":
+		return groupOf(sec)
+	case strings.HasPrefix(sym.Name, "invariant"):
+		return "invariant"
+	case strings.HasPrefix(sym.Name, "test") && len(sym.Params) > 0:
+		return "fuzz"
+	case strings.HasPrefix(sym.Name, "test"):
+		return "test"
+	case strings.HasPrefix(sym.Name, "run"):
+		return "script"
+	}
+	return "helper"
+}
+
+// what a test is flagged as: `invariant`, `fuzz`, `reverts` or nothing
+func testKindOf(source string, sym *Symbol) string {
+	if !isTestSource(source) || sym == nil || sym.Kind != "function" {
+		return ""
+	}
+	switch name := sym.Name; {
+	case strings.HasPrefix(name, "invariant"):
+		return "invariant"
+	case strings.HasPrefix(name, "testFail") || strings.HasPrefix(strings.TrimLeft(strings.TrimPrefix(name, "test"), "_"), "Revert"):
+		return "reverts"
+	case strings.HasPrefix(name, "test") && len(sym.Params) > 0:
+		return "fuzz"
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package dappspec
+
+import "regexp"
+
+// ## File-level declarations
+// Since Solidity 0.7 functions and constants can be declared outside of
+// any contract. The parser follows the braces of the code to know when a
+// contract ends, so what comes after it is not taken for one of its
+// members, and a file-level function or constant starts a section of its
+// own even without docs, instead of being lumped into the code of the
+// section before it. So do `fallback` and `receive` in a contract, which
+// should never go unnoticed.
+
+var (
+	freeDeclaration = regexp.MustCompile(`^\s*(?:function\s+\w+\s*\(|[\w.\[\]]+\s+constant\s+\w+\s*=)`)
+	entryPoint      = regexp.MustCompile(`^\s*(?:fallback|receive)\s*\(`)
+)
+
+// a `braceScope` counts the braces of the code read so far
+type braceScope struct {
+	depth int
+	// a brace at the top level was closed on the last line
+	closed bool
+}
+
+// whether the language's braces are followed
+func followsBraces(language *Language) bool {
+	return language.name == "solidity" && !language.rust && !language.plain
+}
+
+// count the braces of a line of code, outside of comments and strings
+func (s *braceScope) track(line []byte) {
+	s.closed = false
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return
+		case c == '{':
+			s.depth++
+		case c == '}':
+			if s.depth > 0 {
+				s.depth--
+				s.closed = s.closed || s.depth == 0
+			}
+		}
+	}
+}
+
+// whether `line` starts a section of its own: a file-level function or
+// constant, or a contract's `fallback` or `receive`
+func (s *braceScope) startsSection(line []byte) bool {
+	return s.depth == 0 && freeDeclaration.Match(line) || s.depth == 1 && entryPoint.Match(line)
+}
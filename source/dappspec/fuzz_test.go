@@ -0,0 +1,89 @@
+package dappspec
+
+import (
+	"container/list"
+	"io"
+	"log"
+	"sync"
+	"testing"
+)
+
+// ## Fuzzing
+// dappspec runs over third-party code, so nothing in a source file should
+// be able to crash it. These targets cover everything between reading a
+// file and highlighting it:
+//
+//	go test -fuzz FuzzParse
+//	go test -fuzz FuzzSplitHighlighted
+
+var setupFuzz sync.Once
+
+func fuzzLanguages() []string {
+	setupFuzz.Do(func() {
+		// warnings about unknown languages and the like
+		log.SetOutput(io.Discard)
+		setupLanguages()
+		for _, lang := range languages {
+			compileLanguage(lang)
+		}
+	})
+	return []string{"a.sol", "a.yul", "a.huff", "a.fe", "a.sw", "a.rs", "a.txt"}
+}
+
+var fuzzSeeds = []string{
+	"/// @notice A token\ncontract Token {\n    /// @param to where\n    function transfer(address to) external returns (bool) {}\n}\n",
+	"// SPDX-License-Identifier: MIT\npragma solidity ^0.8.0;\n/// dappspec:language=rust\n",
+	"/// dappspec:language=yul\n/// docs\nobject \"A\" {}\n",
+	"/**\n * @notice block\n */\nfunction f() {}\n/** unterminated",
+	"//!\n#[ink(message)]\n\n/// doc\npub fn f(&self) {}\n#[",
+	"// dappspec:off\n/// gone\n// dappspec:on\n/// @custom:dappspec ignore\nuint x;\n",
+	"// File: @openzeppelin/contracts/a.sol\ncontract A {}\n// File: b.sol\ncontract A {\n",
+	"\xff\xfe/\x00/\x00/\x00",
+	"\xef\xbb\xbf///\xc3\x28\n)",
+	"mapping(address => uint) = ;\nfunction (((\ntype is;\nevent E(;\n",
+}
+
+// document `code` as `source` up to the point where it is highlighted
+func fuzzDocument(source string, code []byte) *list.List {
+	code, err := decodeSource(source, code)
+	if err != nil {
+		return nil
+	}
+	code = languageFromDirective(source, code)
+	fallBackToPlain(source)
+	_, code = foldLicense(code)
+	sections := parseDeduped(source, code)
+	measureCoverage(sections)
+	extractMetadata(sections)
+	arrangeSections(sections, "kind", "alpha")
+	return sections
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed)
+	}
+	sources := fuzzLanguages()
+	f.Fuzz(func(t *testing.T, code string) {
+		for _, source := range sources {
+			fuzzDocument(source, []byte(code))
+		}
+	})
+}
+
+func FuzzSplitHighlighted(f *testing.F) {
+	for _, seed := range fuzzSeeds {
+		f.Add(seed, highlightStart+"<span class=\"c1\">///DIVIDER</span>\n"+highlightEnd)
+	}
+	sources := fuzzLanguages()
+	f.Fuzz(func(t *testing.T, code, output string) {
+		for _, source := range sources {
+			sections := fuzzDocument(source, []byte(code))
+			// plain text is never highlighted
+			if sections == nil || getLanguage(source).plain {
+				continue
+			}
+			splitHighlighted(getLanguage(source), []byte(output), sections)
+		}
+	})
+}
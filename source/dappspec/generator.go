@@ -0,0 +1,205 @@
+package dappspec
+
+import "sync"
+
+// ## Generators
+// A `Generator` documents one project: it carries the project's settings,
+// the languages they configure and the sources documented so far, so a
+// program importing this package can document several projects, each
+// with settings of its own, without going through the flags: `New` takes
+// the settings as options. The command line runs with the settings made
+// from its flags instead.
+//
+// The rest of dappspec reads the state of a run from package variables;
+// a generator puts its own there for the length of a run and takes it
+// back after, and what a run reads from the output directory (the
+// manifest, the cache) is forgotten when the next one starts. So runs
+// are serialized: generators used from several goroutines take turns,
+// one run at a time in a process, and the goroutines rendering the pages
+// of a run only ever see the state of that run. Documenting projects in
+// parallel takes a process each.
+
+type Generator struct {
+	state generatorState
+}
+
+// a `generatorState` is what a run reads from the package variables
+type generatorState struct {
+	config    Config
+	languages map[string]*Language
+	overrides map[string]*Language
+	sources   []string
+	provider  Provider
+	baseline  map[string]map[string]string
+	// The stylesheet, page template and partials of the theme, and the
+	// rules of the highlight styles
+	css, html string
+	partials  map[string]string
+	styles    string
+}
+
+// held for the length of a run
+var generating sync.Mutex
+
+// the state in the package variables
+func currentState() generatorState {
+	languageOverridesMu.Lock()
+	defer languageOverridesMu.Unlock()
+	return generatorState{
+		config:    config,
+		languages: languages,
+		overrides: languageOverrides,
+		sources:   sources,
+		provider:  provider,
+		baseline:  baseline,
+		css:       Css,
+		html:      HTML,
+		partials:  Partials,
+		styles:    styleCSS,
+	}
+}
+
+// put `s` in the package variables
+func (s generatorState) install() {
+	languageOverridesMu.Lock()
+	defer languageOverridesMu.Unlock()
+	config, languages, languageOverrides = s.config, s.languages, s.overrides
+	sources, provider, baseline = s.sources, s.provider, s.baseline
+	Css, HTML, Partials, styleCSS = s.css, s.html, s.partials, s.styles
+}
+
+// run `f` with the state of `g` installed, keeping what it changes
+func (g *Generator) with(f func() error) error {
+	generating.Lock()
+	defer generating.Unlock()
+	saved := currentState()
+	g.state.install()
+	defer func() {
+		g.state = currentState()
+		saved.install()
+	}()
+	return f()
+}
+
+// an `Option` changes the settings of a generator made by `New`
+type Option func(*Config)
+
+// a generator with the default settings changed by `opts`, in order
+//
+//	g, err := New(WithOutputDir("site/api"), WithFormats("html", "json"), WithStrict(true))
+func New(opts ...Option) (*Generator, error) {
+	var c Config
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return NewGenerator(c)
+}
+
+// start from `c`, as read from a config file, say
+func WithConfig(c Config) Option {
+	return func(dst *Config) { *dst = c }
+}
+
+// write the docs to `dir` instead of `docs`
+func WithOutputDir(dir string) Option {
+	return func(c *Config) { c.Out = dir }
+}
+
+// the formats to write, `html` by default
+func WithFormats(formats ...string) Option {
+	return func(c *Config) { c.Formats = formats }
+}
+
+// the theme directory, as `--theme`
+func WithTheme(dir string) Option {
+	return func(c *Config) { c.Theme = dir }
+}
+
+// highlight the code with `h`, which needs no process to run, rather
+// than the `highlighter` setting
+func WithHighlighter(h Highlighter) Option {
+	return func(c *Config) { c.HighlightWith = h }
+}
+
+// how many pages are rendered at once, all of them if 0
+func WithConcurrency(n int) Option {
+	return func(c *Config) { c.Jobs = n }
+}
+
+// fail runs when the lint checks report anything, as `--strict`
+func WithStrict(strict bool) Option {
+	return func(c *Config) { c.Strict = strict }
+}
+
+// overwrite files in the output directory that dappspec did not
+// generate, as `--force`
+func WithForce(force bool) Option {
+	return func(c *Config) { c.Force = force }
+}
+
+// a generator for the settings `c`, with the built-in theme unless `c`
+// names one
+func NewGenerator(c Config) (*Generator, error) {
+	g := &Generator{generatorState{
+		config:    c,
+		languages: builtinLanguages(),
+		overrides: map[string]*Language{},
+		css:       mustAsset("assets/dappspec.css"),
+		html:      mustAsset("assets/dappspec.html"),
+		partials:  map[string]string{},
+	}}
+	for _, lang := range g.state.languages {
+		compileLanguage(lang)
+	}
+	err := g.with(func() error {
+		if err := checkConfig(); err != nil {
+			return err
+		}
+		return loadAssets(config.Theme, "", "", config.Partials)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// document `files` into the output directory, as the command line does
+func (g *Generator) Generate(files ...string) error {
+	return g.with(func() error {
+		p, err := sourceProvider(files)
+		if err != nil {
+			return err
+		}
+		return documentFiles(p, files)
+	})
+}
+
+// the sources documented so far
+func (g *Generator) Sources() []string {
+	generating.Lock()
+	defer generating.Unlock()
+	return append([]string(nil), g.state.sources...)
+}
+
+// forget what earlier runs, of this generator or another, wrote, read
+// and counted
+func resetRun() {
+	outputsMu.Lock()
+	outputs, changed = map[string]*Output{}, map[string]bool{}
+	previous = map[string]*Output{}
+	outputsMu.Unlock()
+	sectionsMu.Lock()
+	sectionPrints = map[string]map[string]string{}
+	sectionsMu.Unlock()
+	cacheMu.Lock()
+	cache = map[string]*CacheEntry{}
+	cacheMu.Unlock()
+	unitOwners = map[string]string{}
+	runStatsMu.Lock()
+	runStats.Coverage = Coverage{}
+	runStats.Pragmas, runStats.Licenses = map[string]bool{}, map[string]bool{}
+	runStatsMu.Unlock()
+	usedClassesMu.Lock()
+	usedClasses = map[string]bool{}
+	usedClassesMu.Unlock()
+}
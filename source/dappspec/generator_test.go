@@ -0,0 +1,105 @@
+package dappspec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Two generators in one process write to directories of their own: what
+// one has generated is not the other's to overwrite.
+func TestGeneratorsKeepApart(t *testing.T) {
+	wd, _ := os.Getwd()
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	code := "/// @notice A token\ncontract Token {\n    /// @notice Sends tokens\n    function transfer(address to, uint256 amount) external {}\n}\n"
+	if err := os.WriteFile("Token.sol", []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll("b", 0755); err != nil {
+		t.Fatal(err)
+	}
+	handwritten := []byte("<p>not generated</p>\n")
+	if err := os.WriteFile(filepath.Join("b", "Token.html"), handwritten, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := New(WithOutputDir("a"), WithHighlighter(plainHighlighter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := New(WithOutputDir("b"), WithHighlighter(plainHighlighter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the second run of `a` reads back what the first wrote
+	for i := 0; i < 2; i++ {
+		if err := a.Generate("Token.sol"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Generate("Token.sol"); err == nil || !strings.Contains(err.Error(), "refusing to overwrite") {
+		t.Fatalf("b overwrote a page it did not generate: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join("b", "Token.html")); string(got) != string(handwritten) {
+		t.Fatalf("b/Token.html was changed to %q", got)
+	}
+	// a generator forcing it may, and the page is its own from then on
+	f, err := New(WithOutputDir("b"), WithHighlighter(plainHighlighter{}), WithForce(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Generate("Token.sol"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Generate("Token.sol"); err != nil {
+		t.Fatal(err)
+	}
+	// and a run of `a` again does not see the pages of `b`
+	if err := a.Generate("Token.sol"); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := os.ReadFile(filepath.Join("a", ".dappspec-manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(manifest), `"Token.html"`) || strings.Contains(string(manifest), "b/") {
+		t.Fatalf("manifest of a:\n%s", manifest)
+	}
+}
+
+// a `Highlighter` that fails every page, after putting a directory where
+// the manifest goes, which the manifest then cannot replace
+type failingHighlighter struct{}
+
+func (failingHighlighter) Highlight(*Language, [][]byte) ([][]byte, error) {
+	os.MkdirAll(filepath.Join(outputDir(), ".dappspec-manifest.json", "x"), 0755)
+	return nil, errors.New("no colors today")
+}
+
+// A run stopped by a failed page still reports a manifest it could not
+// write, since the next run would not know its pages otherwise.
+func TestFailedRunReportsManifest(t *testing.T) {
+	wd, _ := os.Getwd()
+	dir := t.TempDir()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	if err := os.WriteFile("Token.sol", []byte("/// @notice A token\ncontract Token {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	g, err := New(WithHighlighter(failingHighlighter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.Generate("Token.sol")
+	if err == nil || !strings.Contains(err.Error(), "no colors today") || !strings.Contains(err.Error(), ".dappspec-manifest.json") {
+		t.Fatalf("Generate = %v, want the page's and the manifest's errors", err)
+	}
+}
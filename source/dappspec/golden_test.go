@@ -0,0 +1,90 @@
+package dappspec
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ## Golden files
+// Every fixture in `testdata/golden/` is documented the way the command
+// line would, and its HTML and JSON pages compared with the ones next to
+// it. A change to parsing, highlighting or rendering shows up as a diff of
+// those files in review; accept it with
+//
+//	go test -run TestGolden -update
+//
+// Highlighting comes from Chroma, so the pages change with its version in
+// `go.mod`.
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+func TestGolden(t *testing.T) {
+	golden, err := filepath.Abs("testdata/golden")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fixtures, _ := filepath.Glob(filepath.Join(golden, "*.sol"))
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata/golden")
+	}
+
+	// document copies of the fixtures, as `dappspec --format html,json`
+	// run in their directory would, into a directory of the test's
+	dir, out := t.TempDir(), t.TempDir()
+	var sources []string
+	for _, fixture := range fixtures {
+		b, err := os.ReadFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := filepath.Base(fixture)
+		if err := os.WriteFile(filepath.Join(dir, name), b, 0644); err != nil {
+			t.Fatal(err)
+		}
+		sources = append(sources, name)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+
+	// the pages do not depend on the build
+	defer func(v, c, d string) { version, commit, date = v, c, d }(version, commit, date)
+	version, commit, date = "", "", ""
+	g, err := New(WithFormats("html", "json"), WithOutputDir(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Generate(sources...); err != nil {
+		t.Fatalf("documenting the fixtures: %v", err)
+	}
+
+	for _, source := range sources {
+		name := strings.TrimSuffix(source, ".sol")
+		for _, page := range []string{name + ".html", filepath.Join("json", name+".json")} {
+			got, err := os.ReadFile(filepath.Join(out, page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := filepath.Join(golden, filepath.Base(page))
+			if *update {
+				if err := os.WriteFile(want, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+				continue
+			}
+			expected, err := os.ReadFile(want)
+			if err != nil {
+				t.Fatalf("%v (run with -update to create it)", err)
+			}
+			if !bytes.Equal(got, expected) {
+				t.Errorf("%s differs from %s (run with -update to accept it)", page, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Guides
+// Walkthroughs read better as prose than as a tour of the sources. The
+// `guides` setting lists Markdown files that become pages of their own
+// under `docs/guides/`, and a line `{{include Vault.deposit}}` in one of
+// them embeds the docs and code of that section, linked to it, as they
+// are in the sources at build time. A reference is `Contract.name`, a
+// contract, interface or library by itself, or a name declared anywhere;
+// one that matches nothing fails the run, so guides cannot drift from the
+// code unnoticed. A guide is titled after its first `#` heading, and its
+// links are left as written, relative to `docs/guides/`.
+
+var includeDirective = regexp.MustCompile(`(?m)^[ \t]*\{\{\s*include\s+([\w.]+)\s*\}\}[ \t]*$`)
+
+// a `GuideLink` is a guide in the table of contents
+type GuideLink struct {
+	Title string
+	Href  string
+}
+
+// a `GuideData` is what the guide template sees
+type GuideData struct {
+	Title string
+	HTML  string
+	PageChrome
+}
+
+// the page of the guide `file` under `docs/`
+func guidePage(file string) string {
+	base := filepath.Base(file)
+	return path.Join("guides", strings.TrimSuffix(base, filepath.Ext(base))+".html")
+}
+
+// the title of a guide: its first heading, or its file name
+func guideTitle(file string, text []byte) string {
+	for _, line := range bytes.Split(text, []byte("\n")) {
+		if bytes.HasPrefix(line, []byte("# ")) {
+			return string(bytes.TrimSpace(line[2:]))
+		}
+	}
+	return strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+}
+
+// the guides for the table of contents of `page`
+func guideLinks(page string) []GuideLink {
+	var links []GuideLink
+	for _, file := range config.Guides {
+		text, _ := os.ReadFile(file)
+		links = append(links, GuideLink{guideTitle(file, text), pageLink(page, guidePage(file))})
+	}
+	return links
+}
+
+// a section found for an `include`
+type includedSection struct {
+	source string
+	view   SectionView
+}
+
+// the section `ref` names, among the sections of every source
+func findIncluded(ref string) (*includedSection, error) {
+	unit, name := "", ref
+	if i := strings.LastIndex(ref, "."); i >= 0 {
+		unit, name = ref[:i], ref[i+1:]
+	}
+	for _, source := range sources {
+		doc, err := sourceDocument(source)
+		if err != nil {
+			return nil, err
+		}
+		for _, sec := range sectionViews(doc) {
+			sym := sec.symbol
+			if sym == nil || sec.collapsed || sym.Name != name {
+				continue
+			}
+			if unit == "" || sec.unit == unit && !sym.IsUnit() {
+				return &includedSection{source, sec}, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no section declares %s", ref)
+}
+
+// the HTML embedding the section `ref` in the guide page `page`
+func includeHTML(page, ref string) (string, error) {
+	found, err := findIncluded(ref)
+	if err != nil {
+		return "", err
+	}
+	section := &Section{codeText: found.view.codeText, docsText: found.view.docsText}
+	sections := list.New()
+	sections.PushBack(section)
+	if err := highlight(found.source, sections); err != nil {
+		return "", err
+	}
+	href := pageLink(page, pageOf(found.source)) + "#section-" + found.view.Tag
+	return fmt.Sprintf(`<div class="included"><p class="for"><a href="%s">%s</a></p><div class="docs">%s</div>%s</div>`,
+		href, ref, section.DocsHTML, section.CodeHTML), nil
+}
+
+// render the guide `file`
+func renderGuide(file string) ([]byte, error) {
+	text, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	if text, err = selectAudience(text); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	if text, err = substituteVars(text); err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	page := guidePage(file)
+	// the sections go in after the Markdown is rendered, standing in for
+	// paragraphs of their own until then
+	var included []string
+	var failed error
+	text = includeDirective.ReplaceAllFunc(text, func(line []byte) []byte {
+		ref := string(includeDirective.FindSubmatch(line)[1])
+		html, err := includeHTML(page, ref)
+		if err != nil && failed == nil {
+			failed = fmt.Errorf("%s: include %s: %v", file, ref, err)
+		}
+		included = append(included, html)
+		return []byte(fmt.Sprintf("\n\nDAPPSPECINCLUDE%d\n\n", len(included)-1))
+	})
+	if failed != nil {
+		return nil, failed
+	}
+	html := string(blackfriday.MarkdownCommon(text))
+	for i, fragment := range included {
+		html = strings.Replace(html, fmt.Sprintf("<p>DAPPSPECINCLUDE%d</p>", i), fragment, 1)
+	}
+	rendered, err := executeTemplate("guide", mustAsset("assets/guide.html"), GuideData{
+		Title:      guideTitle(file, text),
+		HTML:       html,
+		PageChrome: pageChromeAt(page),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", file, err)
+	}
+	return finishPage(rendered), nil
+}
+
+// write every guide
+func writeGuides() error {
+	for _, file := range config.Guides {
+		page, err := renderGuide(file)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(outputDir(), guidePage(file))
+		ensureDirectory(filepath.Dir(dest))
+		log.Println("dappspec: ", file, " -> ", dest)
+		if err := writeOutput(dest, page, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Highlighters
+// The code column is highlighted by a `Highlighter`, picked with the
+// `highlighter` setting or `--highlighter`:
+//
+//   - `chroma`, the default, highlights in process with Chroma (see
+//     `chromaHighlighter`), handing languages it has no lexer for to
+//     Pygments when the setting is left empty
+//   - `pygments` pipes each file through `pygmentize`
+//   - `none` only escapes the code, for machines without Pygments (see
+//     `plainHighlighter`)
+//   - `command` pipes each file through the `highlightCommand`, which is
+//     given the lexer as its last argument and must answer like
+//     `pygmentize -f html` does
+//
+// A highlighter is handed the code of every section of a file in one go,
+// with the `Language` of the file: its lexer comes from the `languages`
+// setting, so each extension can have its own. Files in a plain language
+// are only ever escaped.
+
+// a `Highlighter` turns code into HTML
+type Highlighter interface {
+	// Highlight returns the HTML of each piece of `code`, without the
+	// `<div class="highlight"><pre>` around it
+	Highlight(lang *Language, code [][]byte) ([][]byte, error)
+}
+
+var highlighterNames = []string{"chroma", "pygments", "none", "command"}
+
+// the highlighter the settings ask for
+func activeHighlighter() Highlighter {
+	if config.HighlightWith != nil {
+		return config.HighlightWith
+	}
+	switch config.Highlighter {
+	case "none":
+		return plainHighlighter{config.PlainKeywords}
+	case "command":
+		return commandHighlighter{config.HighlightCommand}
+	case "pygments":
+		return pygmentsHighlighter{}
+	}
+	return chromaHighlighter{}
+}
+
+// the commands looked up on the `PATH`, and whether they were missing
+var lookedUp sync.Map
+
+// whether `command` cannot be found, warning about it the first time
+func missingCommand(command string) bool {
+	if missing, ok := lookedUp.Load(command); ok {
+		return missing.(bool)
+	}
+	_, err := exec.LookPath(command)
+	if _, seen := lookedUp.LoadOrStore(command, err != nil); !seen && err != nil {
+		log.Println("dappspec: ", command, " not found, highlighting with --highlighter none --plain-keywords")
+	}
+	return err != nil
+}
+
+func checkHighlighter() error {
+	known := config.Highlighter == ""
+	for _, name := range highlighterNames {
+		known = known || config.Highlighter == name
+	}
+	switch {
+	case !known:
+		return fmt.Errorf("unknown highlighter %q (want %s)", config.Highlighter, strings.Join(highlighterNames, ", "))
+	case config.Highlighter == "command" && len(config.HighlightCommand) == 0:
+		return fmt.Errorf("highlighter: command needs a highlightCommand")
+	}
+	return nil
+}
+
+// `highlight` gives each `Section` the HTML of its code and docs
+func highlight(source string, sections *list.List) error {
+	language := getLanguage(source)
+	var h Highlighter = plainHighlighter{}
+	if !language.plain {
+		h = activeHighlighter()
+	}
+	code := make([][]byte, 0, sections.Len())
+	for e := sections.Front(); e != nil; e = e.Next() {
+		code = append(code, e.Value.(*Section).codeText)
+	}
+	fragments, err := h.Highlight(language, code)
+	if err != nil {
+		return fmt.Errorf("%s: %v", source, err)
+	}
+	setHTML(sections, fragments)
+	return nil
+}
+
+// hand each section its fragment of highlighted code, and its docs
+func setHTML(sections *list.List, fragments [][]byte) {
+	i := 0
+	for e := sections.Front(); e != nil; e = e.Next() {
+		fragment := addTokenClasses(fragments[i])
+		i++
+		if config.CodeWrap == "wrap" {
+			fragment = wrapLines(fragment)
+		}
+		e.Value.(*Section).CodeHTML = bytes.Join([][]byte{[]byte(highlightStart), []byte(highlightEnd)}, fragment)
+		e.Value.(*Section).DocsHTML = blackfriday.MarkdownCommon(e.Value.(*Section).docsText)
+	}
+}
+
+// `pygmentsHighlighter` runs `pygmentize` with the language's lexer
+type pygmentsHighlighter struct{}
+
+func (pygmentsHighlighter) Highlight(lang *Language, code [][]byte) ([][]byte, error) {
+	return pipeHighlighted(exec.Command(pygmentize(), pygmentsArgs(lang)...), lang, code)
+}
+
+// `commandHighlighter` runs a program of the user's with the lexer as its
+// last argument
+type commandHighlighter struct {
+	command []string
+}
+
+func (h commandHighlighter) Highlight(lang *Language, code [][]byte) ([][]byte, error) {
+	args := append(append([]string{}, h.command[1:]...), lang.name)
+	return pipeHighlighted(exec.Command(h.command[0], args...), lang, code)
+}
+
+// pipe the code to `cmd`, section by section delimited by the language's
+// `dividerText`, then read back the highlighted output, which is written
+// the way Pygments does, and cut it back apart at the dividers. A command
+// that fails, say for a lexer it does not know, fails the page with what
+// it wrote to stderr.
+func pipeHighlighted(cmd *exec.Cmd, lang *Language, code [][]byte) ([][]byte, error) {
+	input, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	output, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// start the process before we start piping data to it
+	// otherwise the pipe may block
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	// the process may stop reading before it exits, so what it says when
+	// it does is worth more than the failed write
+	var writeErr error
+	for i, c := range code {
+		if _, writeErr = input.Write(c); writeErr != nil {
+			break
+		}
+		if i < len(code)-1 {
+			if _, writeErr = io.WriteString(input, lang.dividerText); writeErr != nil {
+				break
+			}
+		}
+	}
+	input.Close()
+
+	buf := getBuffer()
+	defer putBuffer(buf)
+	_, readErr := io.Copy(buf, output)
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("%s %s: %v: %s", filepath.Base(cmd.Args[0]), lang.name, err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	for _, err := range []error{writeErr, readErr} {
+		if err != nil {
+			return nil, fmt.Errorf("%s %s: %v", filepath.Base(cmd.Args[0]), lang.name, err)
+		}
+	}
+	return ownFragments(splitOutput(lang, buf.Bytes(), len(code))), nil
+}
+
+// the `n` fragments of highlighted `output`, cut at the dividers.
+// `output` is overwritten.
+func splitOutput(language *Language, output []byte, n int) [][]byte {
+	output = removeAll(output, []byte(highlightStart))
+	output = removeAll(output, []byte(highlightEnd))
+
+	fragments := make([][]byte, n)
+	for i := range fragments {
+		index := language.dividerHTML.FindIndex(output)
+		if index == nil {
+			index = []int{len(output), len(output)}
+		}
+		fragments[i] = output[0:index[0]]
+		output = output[index[1]:]
+	}
+	return fragments
+}
+
+// hand each section its part of the highlighted `output`. `output` is
+// overwritten.
+func splitHighlighted(language *Language, output []byte, sections *list.List) {
+	setHTML(sections, splitOutput(language, output, sections.Len()))
+}
+
+// `fragments` copied out of the buffer they point into, in one allocation
+func ownFragments(fragments [][]byte) [][]byte {
+	size := 0
+	for _, f := range fragments {
+		size += len(f)
+	}
+	text := make([]byte, 0, size)
+	out := make([][]byte, len(fragments))
+	for i, f := range fragments {
+		start := len(text)
+		text = append(text, f...)
+		out[i] = text[start:len(text):len(text)]
+	}
+	return out
+}
@@ -0,0 +1,113 @@
+package dappspec
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// ## Git history
+// Helpers for reading older versions of the sources straight out of git,
+// without touching the work tree.
+
+// a `Revision` is one commit or tag
+type Revision struct {
+	// The commit hash, or the tag name
+	Ref     string
+	Hash    string
+	Date    time.Time
+	Subject string
+	Author  string
+}
+
+func git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	if root := workTree(); root != "" {
+		cmd.Dir = root
+	}
+	out, err := cmd.Output()
+	if err != nil {
+		if exit, ok := err.(*exec.ExitError); ok && len(exit.Stderr) > 0 {
+			return nil, fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exit.Stderr)))
+		}
+		return nil, fmt.Errorf("git %s: %v", args[0], err)
+	}
+	return out, nil
+}
+
+const logFormat = "%H%x1f%cI%x1f%s%x1f%an"
+
+func parseRevisions(out []byte) []*Revision {
+	var revs []*Revision
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[1])
+		revs = append(revs, &Revision{fields[0], fields[0], date, fields[2], fields[3]})
+	}
+	return revs
+}
+
+// the most recent commits touching any of `paths`, newest first
+func commitsTouching(paths []string, limit int) ([]*Revision, error) {
+	args := []string{"log", "--format=" + logFormat, fmt.Sprintf("-n%d", limit), "--"}
+	out, err := git(append(args, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return parseRevisions(out), nil
+}
+
+// the most recent tags, newest first
+func recentTags(limit int) ([]*Revision, error) {
+	out, err := git("tag", "--sort=-creatordate")
+	if err != nil {
+		return nil, err
+	}
+	var revs []*Revision
+	for _, tag := range strings.Fields(string(out)) {
+		if len(revs) == limit+1 {
+			break
+		}
+		info, err := git("log", "-1", "--format="+logFormat, tag)
+		if err != nil {
+			return nil, err
+		}
+		for _, rev := range parseRevisions(info) {
+			rev.Ref = tag
+			revs = append(revs, rev)
+		}
+	}
+	return revs, nil
+}
+
+// the contents of `path` (relative to the repository root) at `ref`, or
+// nil if it did not exist there
+func fileAt(ref, path string) []byte {
+	out, err := git("show", ref+":"+path)
+	if err != nil {
+		return nil
+	}
+	return out
+}
+
+// the symbol changes to `sources` between two refs. An empty `from`
+// compares against nothing, so everything counts as added.
+func changesBetween(from, to string, sources []string) map[string][]*Change {
+	changes := map[string][]*Change{}
+	for _, source := range sources {
+		path := repoPath(source)
+		var old map[string]*DocSymbol
+		if from != "" {
+			old = docSymbols(source, fileAt(from, path))
+		}
+		diff := diffSymbols(old, docSymbols(source, fileAt(to, path)))
+		if len(diff) > 0 {
+			changes[source] = diff
+		}
+	}
+	return changes
+}
@@ -0,0 +1,77 @@
+package dappspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+)
+
+// ## Post-build hooks
+// Once a run has finished, dappspec can tell the outside world: a shell
+// command (`--post-build-command`) runs with the manifest location in its
+// environment, and a URL (`--post-build-url`) receives the manifest as a
+// JSON POST. Both see which files actually changed, so a notifier can stay
+// quiet when a rebuild produced nothing new.
+
+// a `BuildReport` is what the hooks receive
+type BuildReport struct {
+	Version string    `json:"version"`
+	Files   []*Output `json:"files"`
+	Changed []string  `json:"changed"`
+}
+
+func runHooks() error {
+	if config.Hooks.Command == "" && config.Hooks.URL == "" {
+		return nil
+	}
+	report := BuildReport{versionString(), writtenOutputs(), changedOutputs()}
+	if config.Hooks.Command != "" {
+		if err := runHookCommand(config.Hooks.Command, report); err != nil {
+			return fmt.Errorf("post-build command: %v", err)
+		}
+	}
+	if config.Hooks.URL != "" {
+		if err := postHook(config.Hooks.URL, report); err != nil {
+			return fmt.Errorf("post-build hook %s: %v", config.Hooks.URL, err)
+		}
+	}
+	return nil
+}
+
+func runHookCommand(command string, report BuildReport) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(),
+		"DAPPSPEC_MANIFEST="+manifestFile(),
+		"DAPPSPEC_OUT="+outputDir(),
+		fmt.Sprintf("DAPPSPEC_CHANGED=%d", len(report.Changed)),
+	)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+func postHook(url string, report BuildReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
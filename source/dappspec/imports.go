@@ -0,0 +1,330 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"log"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## Import graph
+// With `--imports`, the `import` statements of every source make up a
+// dependency graph, drawn on `docs/imports.html` as an SVG (no scripts
+// needed) and written as Mermaid to `docs/imports.mmd`. Every page links
+// to it. Sources import each other top to bottom; imports from outside the
+// build (`@openzeppelin/...`) are drawn greyed out. Import cycles are
+// reported as warnings and drawn in red.
+
+var importMatcher = regexp.MustCompile(`(?m)^\s*import\s+(?:[^;"']*?\bfrom\s+)?["']([^"']+)["']`)
+
+const (
+	importsPage    = "imports.html"
+	importsMermaid = "imports.mmd"
+)
+
+// an `ImportNode` is a file in the graph
+type ImportNode struct {
+	// The source path, or the import path for files outside the build
+	Name string
+	// The page of the source, empty for files outside the build
+	Page string
+	// Where it is drawn
+	X, Y, Width int
+}
+
+// an `ImportEdge` is one import statement
+type ImportEdge struct {
+	From, To *ImportNode
+	// Part of an import cycle
+	Cycle bool
+}
+
+type ImportsData struct {
+	Title string
+	SVG   string
+	// The cycles, as `A.sol → B.sol → A.sol`
+	Cycles []string
+	// Where the Mermaid version of the graph is
+	Mermaid string
+	PageChrome
+}
+
+// the link to the import graph from `page`, if there is one
+func importsLink(page string) string {
+	if !config.Imports {
+		return ""
+	}
+	return pageLink(page, importsPage)
+}
+
+// the files `code` of `source` imports, resolved against its directory
+// when relative
+func importsOf(source string, code []byte) []string {
+	var paths []string
+	for _, m := range importMatcher.FindAllSubmatch(code, -1) {
+		p := string(m[1])
+		if strings.HasPrefix(p, "./") || strings.HasPrefix(p, "../") {
+			p = path.Join(filepath.ToSlash(filepath.Dir(source)), p)
+		}
+		paths = append(paths, path.Clean(p))
+	}
+	return paths
+}
+
+// the graph of all sources, laid out in rows
+func importGraph() ([]*ImportNode, []*ImportEdge) {
+	nodes := map[string]*ImportNode{}
+	node := func(name string) *ImportNode {
+		if n, ok := nodes[name]; ok {
+			return n
+		}
+		n := &ImportNode{Name: name}
+		nodes[name] = n
+		return n
+	}
+	for _, source := range sources {
+		node(filepath.ToSlash(source)).Page = pageOf(source)
+	}
+	var edges []*ImportEdge
+	for _, source := range sources {
+		code, err := provider.Read(source)
+		if err != nil {
+			continue
+		}
+		from := node(filepath.ToSlash(source))
+		for _, p := range importsOf(source, code) {
+			edges = append(edges, &ImportEdge{From: from, To: node(p)})
+		}
+	}
+	list := make([]*ImportNode, 0, len(nodes))
+	for _, n := range nodes {
+		list = append(list, n)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	markCycles(list, edges)
+	layOut(list, edges)
+	return list, edges
+}
+
+// mark the edges closing a cycle, found depth first
+func markCycles(nodes []*ImportNode, edges []*ImportEdge) {
+	out := map[*ImportNode][]*ImportEdge{}
+	for _, e := range edges {
+		out[e.From] = append(out[e.From], e)
+	}
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := map[*ImportNode]int{}
+	var visit func(n *ImportNode)
+	visit = func(n *ImportNode) {
+		state[n] = visiting
+		for _, e := range out[n] {
+			switch state[e.To] {
+			case unvisited:
+				visit(e.To)
+			case visiting:
+				e.Cycle = true
+			}
+		}
+		state[n] = done
+	}
+	for _, n := range nodes {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+}
+
+// the cycles closed by the marked edges, as paths back to their start
+func importCycles(edges []*ImportEdge) []string {
+	out := map[*ImportNode][]*ImportNode{}
+	for _, e := range edges {
+		if !e.Cycle {
+			out[e.From] = append(out[e.From], e.To)
+		}
+	}
+	var cycles []string
+	for _, e := range edges {
+		if !e.Cycle {
+			continue
+		}
+		// the path from where the cycle closes to where it started
+		trail := pathBetween(out, e.To, e.From)
+		names := []string{}
+		for _, n := range trail {
+			names = append(names, n.Name)
+		}
+		cycles = append(cycles, strings.Join(append(names, e.To.Name), " → "))
+	}
+	sort.Strings(cycles)
+	return cycles
+}
+
+func pathBetween(out map[*ImportNode][]*ImportNode, from, to *ImportNode) []*ImportNode {
+	seen := map[*ImportNode]bool{}
+	var walk func(n *ImportNode) []*ImportNode
+	walk = func(n *ImportNode) []*ImportNode {
+		if n == to {
+			return []*ImportNode{n}
+		}
+		seen[n] = true
+		for _, next := range out[n] {
+			if !seen[next] {
+				if rest := walk(next); rest != nil {
+					return append([]*ImportNode{n}, rest...)
+				}
+			}
+		}
+		return nil
+	}
+	if p := walk(from); p != nil {
+		return p
+	}
+	return []*ImportNode{from, to}
+}
+
+const (
+	nodeHeight = 30
+	rowHeight  = 80
+	nodeGap    = 20
+	charWidth  = 7
+	margin     = 20
+)
+
+// put every file on the row below the lowest file importing it
+func layOut(nodes []*ImportNode, edges []*ImportEdge) {
+	row := map[*ImportNode]int{}
+	// longest paths over the edges that are not part of a cycle; there
+	// are at most as many rows as nodes
+	for i := 0; i < len(nodes); i++ {
+		changed := false
+		for _, e := range edges {
+			if !e.Cycle && row[e.To] < row[e.From]+1 {
+				row[e.To] = row[e.From] + 1
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+	x := map[int]int{}
+	for _, n := range nodes {
+		r := row[n]
+		if x[r] == 0 {
+			x[r] = margin
+		}
+		n.Width = len([]rune(n.Name))*charWidth + 20
+		n.X, n.Y = x[r], margin+r*rowHeight
+		x[r] += n.Width + nodeGap
+	}
+}
+
+// draw the graph
+func importsSVG(nodes []*ImportNode, edges []*ImportEdge) string {
+	width, height := 0, 0
+	for _, n := range nodes {
+		if n.X+n.Width+margin > width {
+			width = n.X + n.Width + margin
+		}
+		if n.Y+nodeHeight+margin > height {
+			height = n.Y + nodeHeight + margin
+		}
+	}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg class="imports" xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, width, height, width, height)
+	b.WriteString(`<defs><marker id="arrow" viewBox="0 0 10 10" refX="10" refY="5" markerWidth="6" markerHeight="6" orient="auto"><path d="M0,0L10,5L0,10z"/></marker></defs>`)
+	for _, e := range edges {
+		x1, y1 := e.From.X+e.From.Width/2, e.From.Y+nodeHeight
+		x2, y2 := e.To.X+e.To.Width/2, e.To.Y
+		if e.To.Y <= e.From.Y {
+			// pointing back up, into a cycle
+			y1, y2 = e.From.Y, e.To.Y+nodeHeight
+		}
+		class := "edge"
+		if e.Cycle {
+			class = "edge cycle"
+		}
+		fmt.Fprintf(&b, `<line class="%s" x1="%d" y1="%d" x2="%d" y2="%d" marker-end="url(#arrow)"/>`, class, x1, y1, x2, y2)
+	}
+	for _, n := range nodes {
+		class := "node"
+		if n.Page == "" {
+			class = "node external"
+		}
+		box := fmt.Sprintf(`<g class="%s"><rect x="%d" y="%d" width="%d" height="%d" rx="4"/><text x="%d" y="%d">%s</text></g>`,
+			class, n.X, n.Y, n.Width, nodeHeight, n.X+10, n.Y+nodeHeight/2+4, html.EscapeString(n.Name))
+		if n.Page != "" {
+			box = fmt.Sprintf(`<a href="%s">%s</a>`, n.Page, box)
+		}
+		b.WriteString(box)
+	}
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// the graph in Mermaid's syntax
+func importsMermaidText(nodes []*ImportNode, edges []*ImportEdge) string {
+	ids := map[*ImportNode]string{}
+	var b bytes.Buffer
+	b.WriteString("graph TD\n")
+	for i, n := range nodes {
+		ids[n] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(&b, "  %s[%q]\n", ids[n], n.Name)
+		if n.Page != "" {
+			fmt.Fprintf(&b, "  click %s %q\n", ids[n], n.Page)
+		}
+	}
+	for _, e := range edges {
+		arrow := "-->"
+		if e.Cycle {
+			arrow = "-.->"
+		}
+		fmt.Fprintf(&b, "  %s %s %s\n", ids[e.From], arrow, ids[e.To])
+	}
+	return b.String()
+}
+
+func writeImports() error {
+	if !config.Imports {
+		return nil
+	}
+	nodes, edges := importGraph()
+	cycles := importCycles(edges)
+	for _, c := range cycles {
+		log.Println("dappspec: ", "import cycle:", c)
+	}
+	mermaid := importsMermaidText(nodes, edges)
+	page, err := executeTemplate("imports", mustAsset("assets/imports.html"), ImportsData{
+		Title:      "Imports",
+		SVG:        importsSVG(nodes, edges),
+		Cycles:     cycles,
+		Mermaid:    importsMermaid,
+		PageChrome: pageChrome(),
+	})
+	if err != nil {
+		return err
+	}
+	page = finishPage(page)
+	for _, out := range []struct {
+		dest    string
+		content []byte
+	}{
+		{filepath.Join(outputDir(), importsPage), page},
+		{filepath.Join(outputDir(), importsMermaid), []byte(mermaid)},
+	} {
+		log.Println("dappspec: ", "imports", " -> ", out.dest)
+		if err := writeOutput(out.dest, out.content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ## Shared docs
+// Text that many sections share, like a disclaimer about the risks of an
+// external call, can live once in a Markdown file and be pulled into
+// docs with a line `@custom:include shared/risks.md#reentrancy`. The path
+// is relative to the file including it; `#reentrancy` picks the part of
+// the file under the heading that reads as `reentrancy` in kebab case
+// (`## Reentrancy`), up to the next heading as high, and without it the
+// whole file is included. Included text can include more; an include of
+// itself, however far down, fails the file.
+
+var (
+	includeTag  = regexp.MustCompile(`(?m)^[ \t]*@custom:include[ \t]+(\S+)[ \t]*$`)
+	headingLine = regexp.MustCompile(`^(#+)\s+(.*?)\s*#*\s*$`)
+	// what starts a line of docs in the code
+	commentMarkers = regexp.MustCompile(`(?m)^[ \t]*(?:/\*\*|//+|\*)`)
+)
+
+// replace the includes in the docs of every section of `source`
+func transcludeSections(source string, sections *list.List) error {
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if !includeTag.Match(sec.docsText) {
+			continue
+		}
+		docs, err := transclude(source, sec.docsText, nil, nil)
+		if err != nil {
+			return err
+		}
+		sec.docsText = docs
+	}
+	return nil
+}
+
+// `docs` of the file `from` with its includes replaced, `seen` being the
+// includes on the way there. Every file read is passed to `read`, if set.
+func transclude(from string, docs []byte, seen []string, read func(file string, b []byte)) ([]byte, error) {
+	var failed error
+	out := includeTag.ReplaceAllFunc(docs, func(line []byte) []byte {
+		if failed != nil {
+			return line
+		}
+		ref := string(includeTag.FindSubmatch(line)[1])
+		file, anchor, _ := strings.Cut(ref, "#")
+		file = path.Join(filepath.ToSlash(filepath.Dir(from)), file)
+		at := file
+		if anchor != "" {
+			at += "#" + anchor
+		}
+		for _, s := range seen {
+			if s == at {
+				failed = fmt.Errorf("include cycle: %s -> %s", strings.Join(seen, " -> "), at)
+				return line
+			}
+		}
+		b, err := provider.Read(filepath.FromSlash(file))
+		if err != nil {
+			failed = fmt.Errorf("include %s: %v", ref, err)
+			return line
+		}
+		if read != nil {
+			read(file, b)
+		}
+		fragment, ok := fragmentOf(b, anchor)
+		if !ok {
+			failed = fmt.Errorf("include %s: no heading %s in %s", ref, anchor, file)
+			return line
+		}
+		fragment, err = transclude(filepath.FromSlash(file), fragment, append(seen, at), read)
+		if err != nil {
+			failed = err
+			return line
+		}
+		return bytes.TrimRight(fragment, "\n")
+	})
+	return out, failed
+}
+
+// the part of the Markdown `text` under the heading `anchor`, or all of
+// it for no anchor
+func fragmentOf(text []byte, anchor string) ([]byte, bool) {
+	text = bytes.ReplaceAll(text, []byte("\r\n"), []byte("\n"))
+	if anchor == "" {
+		return text, true
+	}
+	lines := bytes.Split(text, []byte("\n"))
+	for i, line := range lines {
+		m := headingLine.FindSubmatch(line)
+		if m == nil || strings.Join(nameWords(string(m[2])), "-") != anchor {
+			continue
+		}
+		level := len(m[1])
+		end := len(lines)
+		for j := i + 1; j < len(lines); j++ {
+			if n := headingLine.FindSubmatch(lines[j]); n != nil && len(n[1]) <= level {
+				end = j
+				break
+			}
+		}
+		return bytes.TrimSpace(bytes.Join(lines[i+1:end], []byte("\n"))), true
+	}
+	return nil, false
+}
+
+// the contents of the files `code` includes, however deep, for the cache
+func includedFiles(source string, code []byte) []byte {
+	if !bytes.Contains(code, []byte("@custom:include")) {
+		return nil
+	}
+	var b bytes.Buffer
+	docs := commentMarkers.ReplaceAll(code, nil)
+	transclude(source, docs, nil, func(file string, text []byte) {
+		b.WriteString(file)
+		b.WriteByte(0)
+		b.Write(text)
+		b.WriteByte(0)
+	})
+	return b.Bytes()
+}
@@ -0,0 +1,192 @@
+package dappspec
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ## Inputs
+// Large projects have too many sources to name one by one. An argument
+// can be a directory, or `src/...` as in Go, for every source under it in
+// a language dappspec knows, or a glob pattern, where `*` and `?` match
+// within a path element and `**` matches any number of directories:
+// `dappspec 'src/**/*.sol'`. Hidden directories and `node_modules` are not
+// walked. The `include` setting (`--include`) adds patterns to the
+// arguments, and what `exclude` (`--exclude 'lib/**'`) matches is left out,
+// wherever the sources come from.
+
+var (
+	// directories never walked into
+	skippedDirs = map[string]bool{"node_modules": true}
+	// the `exclude` patterns, compiled
+	excludes []excludePattern
+)
+
+type excludePattern struct {
+	dir   string
+	match *regexp.Regexp
+}
+
+func checkInputs() error {
+	excludes = nil
+	for _, pattern := range config.Include {
+		if _, err := globRegexp(pattern); err != nil {
+			return fmt.Errorf("include %s: %v", pattern, err)
+		}
+	}
+	for _, pattern := range config.Exclude {
+		pattern = strings.TrimPrefix(path.Clean(filepath.ToSlash(pattern)), "./")
+		match, err := globRegexp(pattern)
+		if err != nil {
+			return fmt.Errorf("exclude %s: %v", pattern, err)
+		}
+		excludes = append(excludes, excludePattern{pattern, match})
+	}
+	return nil
+}
+
+// the sources the arguments and `include` name in the working tree
+func expandInputs(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range append(append([]string{}, args...), config.Include...) {
+		found, err := expandInput(arg)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, found...)
+	}
+	return excludeSources(files), nil
+}
+
+// the sources of one argument
+func expandInput(arg string) ([]string, error) {
+	if dir, ok := strings.CutSuffix(filepath.ToSlash(arg), "/..."); ok {
+		return walkSources(filepath.FromSlash(dir), nil)
+	}
+	if isGlob(arg) {
+		slashed := path.Clean(filepath.ToSlash(arg))
+		match, err := globRegexp(slashed)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", arg, err)
+		}
+		return walkSources(filepath.FromSlash(globRoot(slashed)), match)
+	}
+	if info, err := os.Stat(arg); err == nil && info.IsDir() {
+		return walkSources(arg, nil)
+	}
+	return []string{arg}, nil
+}
+
+// the sources under `root`, those matching `match` if it is set
+func walkSources(root string, match *regexp.Regexp) ([]string, error) {
+	var found []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if p != root && (strings.HasPrefix(name, ".") || skippedDirs[name] || excluded(filepath.ToSlash(p)+"/")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		slashed := filepath.ToSlash(p)
+		if _, known := languages[filepath.Ext(p)]; !known || match != nil && !match.MatchString(slashed) {
+			return nil
+		}
+		found = append(found, p)
+		return nil
+	})
+	return found, err
+}
+
+// `files` without those `exclude` matches
+func excludeSources(files []string) []string {
+	if len(excludes) == 0 {
+		return files
+	}
+	var kept []string
+	for _, f := range files {
+		if !excluded(filepath.ToSlash(f)) {
+			kept = append(kept, f)
+		}
+	}
+	return kept
+}
+
+// whether `name` (with forward slashes) is excluded, or within an
+// excluded directory
+func excluded(name string) bool {
+	name = strings.TrimPrefix(name, "./")
+	for _, e := range excludes {
+		if e.match.MatchString(name) || strings.HasPrefix(name, e.dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// the directory a walk for `pattern` starts from: its leading elements
+// without wildcards
+func globRoot(pattern string) string {
+	var dirs []string
+	elems := strings.Split(pattern, "/")
+	for _, elem := range elems[:len(elems)-1] {
+		if isGlob(elem) {
+			break
+		}
+		dirs = append(dirs, elem)
+	}
+	if len(dirs) == 0 {
+		return "."
+	}
+	if dirs[0] == "" {
+		return "/" + path.Join(dirs[1:]...)
+	}
+	return path.Join(dirs...)
+}
+
+// `pattern` as a regular expression matching whole paths
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			if end := strings.IndexByte(pattern[i:], ']'); end > 0 {
+				class := pattern[i+1 : i+end]
+				if strings.HasPrefix(class, "!") {
+					class = "^" + class[1:]
+				}
+				b.WriteString("[" + class + "]")
+				i += end
+			} else {
+				b.WriteString(`\[`)
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
@@ -0,0 +1,85 @@
+package dappspec
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/bits"
+)
+
+// ## Keccak-256
+// Function selectors are the first four bytes of the Keccak-256 hash of the
+// canonical signature. This is the original Keccak padding Ethereum uses,
+// not SHA-3's, so the hash is computed here rather than with a library.
+
+var keccakRounds = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+var (
+	keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakLanes     = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// the Keccak-f[1600] permutation
+func keccakF(state *[25]uint64) {
+	var c [5]uint64
+	for round := 0; round < 24; round++ {
+		// θ
+		for i := 0; i < 5; i++ {
+			c[i] = state[i] ^ state[i+5] ^ state[i+10] ^ state[i+15] ^ state[i+20]
+		}
+		for i := 0; i < 5; i++ {
+			d := c[(i+4)%5] ^ bits.RotateLeft64(c[(i+1)%5], 1)
+			for j := 0; j < 25; j += 5 {
+				state[j+i] ^= d
+			}
+		}
+		// ρ and π
+		lane := state[1]
+		for i := 0; i < 24; i++ {
+			j := keccakLanes[i]
+			lane, state[j] = state[j], bits.RotateLeft64(lane, keccakRotations[i])
+		}
+		// χ
+		for j := 0; j < 25; j += 5 {
+			copy(c[:], state[j:j+5])
+			for i := 0; i < 5; i++ {
+				state[j+i] ^= ^c[(i+1)%5] & c[(i+2)%5]
+			}
+		}
+		// ι
+		state[0] ^= keccakRounds[round]
+	}
+}
+
+func keccak256(data []byte) []byte {
+	const rate = 136
+	var state [25]uint64
+	padded := append(append([]byte{}, data...), 0x01)
+	for len(padded)%rate != 0 {
+		padded = append(padded, 0)
+	}
+	padded[len(padded)-1] |= 0x80
+	for ; len(padded) > 0; padded = padded[rate:] {
+		for i := 0; i < rate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(padded[i*8:])
+		}
+		keccakF(&state)
+	}
+	hash := make([]byte, 32)
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(hash[i*8:], state[i])
+	}
+	return hash
+}
+
+// the selector of a canonical signature like `transfer(address,uint256)`,
+// as `0xa9059cbb`
+func selector(canonical string) string {
+	return "0x" + hex.EncodeToString(keccak256([]byte(canonical))[:4])
+}
@@ -0,0 +1,90 @@
+package dappspec
+
+import "bytes"
+
+// ## Contract kinds
+// Whether a unit can be deployed changes how it is read: an interface is a
+// promise about other contracts, an abstract contract a base to inherit
+// from, a library code shared by contracts. The section declaring each
+// contract, interface or library says which it is above its docs, as does
+// the section of a user-defined value type.
+//
+// `fallback` and `receive` are labelled the same way: they run on calls no
+// other function handles, often with Ether, and are easily missed in a
+// list of functions. With `--lint`, one that takes Ether without docs is
+// reported.
+
+// a `UnitKind` is what the section of a unit says about it
+type UnitKind struct {
+	// `contract`, `abstract contract`, `interface`, `library`, `type`,
+	// `fallback` or `receive`
+	Kind string
+	// The kind in a word, as a class
+	Badge string
+	Name  string
+	// What the kind means for deploying or calling it
+	Note string
+}
+
+var unitNotes = map[string]string{
+	"contract":          "deployable",
+	"abstract contract": "not deployable on its own; inherited by other contracts",
+	"interface":         "not deployable; declares what implementations provide",
+	"library":           "deployed once and linked, or inlined into its callers",
+}
+
+// the badge for a unit of `kind`, empty for kinds other than Solidity's
+func unitBadge(kind string) string {
+	switch kind {
+	case "abstract contract":
+		return "abstract"
+	case "contract", "interface", "library":
+		return kind
+	}
+	return ""
+}
+
+// the kind of the unit `sym` declares, if it declares one, or of the
+// user-defined value type
+func unitKindOf(sym *Symbol) *UnitKind {
+	if sym != nil && sym.Kind == "type" {
+		if t := underlyingType(sym); t != "" {
+			return &UnitKind{"type", "value-type", sym.Name, "a value type over " + t + ", converted with " +
+				sym.Name + ".wrap and " + sym.Name + ".unwrap"}
+		}
+	}
+	if sym != nil && (sym.Kind == "fallback" || sym.Kind == "receive") {
+		return &UnitKind{sym.Kind, "entry-point", sym.Kind + "()", entryPointNote(sym)}
+	}
+	if !sym.IsUnit() || unitBadge(sym.Kind) == "" {
+		return nil
+	}
+	return &UnitKind{sym.Kind, unitBadge(sym.Kind), sym.Name, unitNotes[sym.Kind]}
+}
+
+// when `fallback` or `receive` runs
+func entryPointNote(sym *Symbol) string {
+	if sym.Kind == "receive" {
+		return "runs on plain Ether transfers, with empty calldata"
+	}
+	if sym.Mutability == "payable" {
+		return "runs when no function matches the calldata, and accepts Ether"
+	}
+	return "runs when no function matches the calldata"
+}
+
+// report the entry points of `doc` that take Ether without docs
+func lintEntryPoints(doc *Document, views []SectionView) {
+	if !linting() {
+		return
+	}
+	for _, sec := range views {
+		sym := sec.symbol
+		if sym == nil || sec.collapsed || sym.Kind != "receive" && (sym.Kind != "fallback" || sym.Mutability != "payable") {
+			continue
+		}
+		if len(bytes.TrimSpace(sec.docsText)) == 0 {
+			lintWarn("payable", "%s: %s takes Ether and is not documented", doc.Source, sectionLabel(doc.Source, sec))
+		}
+	}
+}
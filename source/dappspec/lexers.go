@@ -0,0 +1,164 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ## Lexers
+// Each extension is highlighted with a lexer, named as Chroma and Pygments
+// both name them. The `languages` setting changes the lexer of an
+// extension, adds Pygments options, or teaches dappspec a new extension
+// altogether:
+//
+//	"languages": {
+//	  ".sol":  {"lexer": "solidity", "options": ["stripall=True"]},
+//	  ".huff": {"lexer": "lexers/huff.py:HuffLexer", "comment": "///"}
+//	}
+//
+// A lexer ending in `.py` (optionally followed by `:Class`) is a custom
+// lexer file, which Pygments loads with `-x` and Chroma leaves to it.
+// Options are Pygments' alone: highlighting with Chroma, they are ignored
+// with a warning.
+// Lexers for languages neither knows, like Huff, are embedded and written to the user
+// cache directory when needed.
+
+// a `LanguageConfig` is the setting for one extension
+type LanguageConfig struct {
+	// The lexer name, or a `file.py[:Class]` custom Pygments lexer
+	Lexer string `json:"lexer,omitempty"`
+	// Extra Pygments options, as `name=value`
+	Options []string `json:"options,omitempty"`
+	// The doc comment marker, required for new extensions
+	Comment string `json:"comment,omitempty"`
+}
+
+func configureLanguages() error {
+	for ext, lc := range config.Languages {
+		if !strings.HasPrefix(ext, ".") {
+			return fmt.Errorf("languages: %q is not an extension (want a leading dot)", ext)
+		}
+		lang, known := languages[ext]
+		if !known {
+			if lc.Lexer == "" || lc.Comment == "" {
+				return fmt.Errorf("languages: new extension %s needs a lexer and a comment marker", ext)
+			}
+			lang = &Language{}
+			languages[ext] = lang
+		}
+		if lc.Lexer != "" {
+			lang.name = lc.Lexer
+		}
+		if lc.Comment != "" {
+			lang.symbol = lc.Comment
+		}
+		lang.options = lc.Options
+		compileLanguage(lang)
+		// Chroma lexers take no options, so they would go unseen
+		if _, chroma := activeHighlighter().(chromaHighlighter); chroma && len(lc.Options) > 0 && chromaLexer(lang) != nil {
+			log.Printf("dappspec: languages: %s: Chroma ignores the Pygments options %s; --highlighter pygments applies them", ext, strings.Join(lc.Options, " "))
+		}
+	}
+	return checkLanguageMappings()
+}
+
+// whether the lexer is a file of Python rather than a Pygments name
+func customLexer(name string) bool {
+	file, _, _ := strings.Cut(name, ":")
+	return strings.HasSuffix(file, ".py")
+}
+
+var (
+	embeddedLexersOnce sync.Once
+	embeddedLexersDir  string
+)
+
+// where a custom lexer file is: as given if it exists, else the embedded
+// lexer of that name
+func lexerPath(name string) string {
+	file, class, _ := strings.Cut(name, ":")
+	if _, err := os.Stat(file); err == nil {
+		return name
+	}
+	if _, err := assets.ReadFile("assets/lexers/" + filepath.Base(file)); err != nil {
+		return name
+	}
+	embeddedLexersOnce.Do(func() {
+		// the first of the cache directories that can be written to, the
+		// temporary directory last for read-only file systems
+		for _, cache := range cacheDirs() {
+			dir := filepath.Join(cache, "lexers")
+			if writeEmbeddedLexers(dir) == nil {
+				embeddedLexersDir = dir
+				return
+			}
+		}
+	})
+	if embeddedLexersDir == "" {
+		return name
+	}
+	path := filepath.Join(embeddedLexersDir, filepath.Base(file))
+	if class != "" {
+		path += ":" + class
+	}
+	return path
+}
+
+// where dappspec may keep files of its own: `$DAPPSPEC_CACHE_DIR`, else
+// the user cache directory (`$XDG_CACHE_HOME` on Linux), else the
+// temporary directory
+func cacheDirs() []string {
+	var dirs []string
+	if dir := os.Getenv("DAPPSPEC_CACHE_DIR"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if cache, err := os.UserCacheDir(); err == nil {
+		dirs = append(dirs, filepath.Join(cache, "dappspec"))
+	}
+	return append(dirs, filepath.Join(os.TempDir(), "dappspec"))
+}
+
+// write the embedded lexers to `dir`, unless they are there already
+func writeEmbeddedLexers(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	entries, _ := assets.ReadDir("assets/lexers")
+	for _, e := range entries {
+		b, _ := assets.ReadFile("assets/lexers/" + e.Name())
+		path := filepath.Join(dir, e.Name())
+		if old, err := os.ReadFile(path); err != nil || !bytes.Equal(old, b) {
+			if err := writeAtomic(path, b, 0644); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// the command line highlighting a language
+func pygmentsArgs(lang *Language) []string {
+	args := []string{"-l", lang.name, "-f", "html", "-O", "encoding=utf-8"}
+	if customLexer(lang.name) {
+		args[1] = lexerPath(lang.name)
+		args = append(args, "-x")
+	}
+	for _, opt := range lang.options {
+		args = append(args, "-O", opt)
+	}
+	return args
+}
+
+// the info string of fenced code blocks in Markdown output
+func fenceLanguage(source string) string {
+	lang := getLanguage(source)
+	if customLexer(lang.name) {
+		return strings.TrimPrefix(filepath.Ext(source), ".")
+	}
+	return lang.name
+}
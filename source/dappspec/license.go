@@ -0,0 +1,76 @@
+package dappspec
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// ## License headers
+// Most Solidity files open with an SPDX identifier and often a full
+// license banner. Rendering that as the first section of every page buries
+// the actual documentation, so it is lifted out and shown as a small
+// collapsible note instead.
+
+// a `License` is the header folded away from the top of a file
+type License struct {
+	// the SPDX identifier, if there was one
+	ID string `json:"id,omitempty"`
+	// the full text of the header, comment markers stripped
+	Text string `json:"text"`
+}
+
+var (
+	spdxMatcher    = regexp.MustCompile(`SPDX-License-Identifier:\s*([^\s*]+)`)
+	licenseMatcher = regexp.MustCompile(`(?i)\b(license|licensed|copyright|permission is hereby granted|all rights reserved)\b`)
+	// plain `//` comments, but not `///` NatSpec
+	lineComment = regexp.MustCompile(`^\s*//(?:[^/]|$)`)
+)
+
+// `foldLicense` splits a leading license header off `code`. Only comments
+// before the first line of code are considered, and a block is only
+// taken if it looks like a license; the rest of the file is returned
+// untouched otherwise.
+func foldLicense(code []byte) (*License, []byte) {
+	lines := bytes.Split(code, []byte("\n"))
+	var header []string
+	var end int
+	inBlock := false
+scan:
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(string(line))
+		switch {
+		case inBlock:
+			if strings.Contains(trimmed, "*/") {
+				inBlock = false
+				trimmed = strings.TrimSpace(strings.SplitN(trimmed, "*/", 2)[0])
+			}
+			header = append(header, strings.TrimSpace(strings.TrimPrefix(trimmed, "*")))
+		case trimmed == "":
+			header = append(header, "")
+		case lineComment.MatchString(string(line)):
+			header = append(header, strings.TrimSpace(strings.TrimPrefix(trimmed, "//")))
+		case strings.HasPrefix(trimmed, "/*") && !strings.HasPrefix(trimmed, "/**"):
+			body := strings.TrimPrefix(trimmed, "/*")
+			if strings.Contains(body, "*/") {
+				body = strings.SplitN(body, "*/", 2)[0]
+			} else {
+				inBlock = true
+			}
+			header = append(header, strings.TrimSpace(body))
+		default:
+			break scan
+		}
+		end = i + 1
+	}
+	text := strings.TrimSpace(strings.Join(header, "\n"))
+	if inBlock || text == "" || !licenseMatcher.MatchString(text) {
+		return nil, code
+	}
+	license := &License{Text: text}
+	if m := spdxMatcher.FindStringSubmatch(text); m != nil {
+		license.ID = m[1]
+	}
+	rest := bytes.Join(lines[end:], []byte("\n"))
+	return license, rest
+}
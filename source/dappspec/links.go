@@ -0,0 +1,97 @@
+package dappspec
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ## Relative links
+// Comments link to other sources the way they would on GitHub, as in
+// `[the vault](./Vault.sol)` or `../interfaces/IVault.sol`. Left alone,
+// those links break once the docs are published. The `links` setting
+// decides where they go instead:
+//
+//   - `pages` (the default) sends links to sources documented in this
+//     build to their page, and the others to the repository (with a
+//     `repo.url`), or leaves them as they are;
+//   - `repo` sends every one of them to the repository;
+//   - `off` leaves them all alone.
+
+var linkModes = []string{"pages", "repo", "off"}
+
+var hrefMatcher = regexp.MustCompile(`href="([^"]*)"`)
+
+func checkLinks() error {
+	if config.Links == "" {
+		config.Links = linkModes[0]
+	}
+	for _, mode := range linkModes {
+		if config.Links == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown links setting %q, want one of %s", config.Links, strings.Join(linkModes, ", "))
+}
+
+// whether `href` points at a file relative to the page's source
+func relativeLink(href string) bool {
+	if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "/") {
+		return false
+	}
+	// anything with a scheme, like `https:` or `mailto:`
+	if i := strings.IndexAny(href, ":/"); i >= 0 && href[i] == ':' {
+		return false
+	}
+	return true
+}
+
+// point the relative links in the rendered docs of `source` at pages or
+// the repository
+func rewriteLinks(source string, html []byte) []byte {
+	if config.Links == "off" {
+		return html
+	}
+	return hrefMatcher.ReplaceAllFunc(html, func(m []byte) []byte {
+		href := string(hrefMatcher.FindSubmatch(m)[1])
+		if !relativeLink(href) {
+			return m
+		}
+		if to := linkTarget(source, href); to != "" {
+			return []byte(`href="` + to + `"`)
+		}
+		return m
+	})
+}
+
+// where the link `href` in the docs of `source` should go, or empty to
+// leave it
+func linkTarget(source, href string) string {
+	target, fragment, _ := strings.Cut(href, "#")
+	// links to pages are already right
+	if strings.HasSuffix(target, ".html") {
+		return ""
+	}
+	target = path.Clean(path.Join(filepath.ToSlash(filepath.Dir(source)), target))
+	if config.Links == "pages" {
+		for _, s := range sources {
+			if path.Clean(filepath.ToSlash(s)) == target {
+				// line anchors like `#L10` mean nothing on a page
+				if strings.HasPrefix(fragment, "section-") {
+					return pageLink(pageOf(source), pageOf(s)) + "#" + fragment
+				}
+				return pageLink(pageOf(source), pageOf(s))
+			}
+		}
+	}
+	if config.Repo.URL == "" {
+		return ""
+	}
+	to := strings.TrimSuffix(config.Repo.URL, "/") + "/blob/" + repoBranch() + "/" + repoPath(filepath.FromSlash(target))
+	if fragment != "" {
+		to += "#" + fragment
+	}
+	return to
+}
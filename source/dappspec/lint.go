@@ -0,0 +1,43 @@
+package dappspec
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+)
+
+// ## Strictness
+// What `--lint` reports is only a warning, unless the run is `--strict`:
+// then the run fails once the pages are written, so CI can insist on
+// docs for what is risky to leave without them.
+
+// how many findings `--lint` has reported in this run
+var lintFindings int64
+
+// whether the lint checks run
+func linting() bool {
+	return config.Lint || config.Strict
+}
+
+// report a finding of `--lint`, of the kind `category`
+func lintWarn(category, format string, args ...interface{}) {
+	atomic.AddInt64(&lintFindings, 1)
+	reportWarning(category)
+	log.Println("dappspec: ", fmt.Sprintf(format, args...))
+}
+
+func resetLint() {
+	atomic.StoreInt64(&lintFindings, 0)
+}
+
+// fail a strict run with findings
+func checkStrict() error {
+	n := atomic.LoadInt64(&lintFindings)
+	if !config.Strict || n == 0 {
+		return nil
+	}
+	if n == 1 {
+		return fmt.Errorf("--strict: lint reported 1 finding")
+	}
+	return fmt.Errorf("--strict: lint reported %d findings", n)
+}
@@ -0,0 +1,167 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Struct and enum members
+// The fields of a struct and the values of an enum are documented where
+// they are declared, with `///` lines above them or a `///` comment after
+// them on the same line:
+//
+//     struct Order {
+//         /// who placed the order
+//         address maker;
+//         uint256 amount; /// how much, in wei
+//     }
+//
+// Inside the braces these comments do not start a section of their own.
+// They are taken out of the code and shown as a table of the members under
+// the struct's docs instead, along with the `@param` tags of the struct
+// for members without a comment of their own.
+
+// a `Member` is a field of a struct or value of an enum
+type Member struct {
+	Name string `json:"name"`
+	// Empty for enum values
+	Type       string `json:"type,omitempty"`
+	Notice     string `json:"notice,omitempty"`
+	NoticeHTML string `json:"-"`
+}
+
+var (
+	memberStart   = regexp.MustCompile(`^\s*(?:struct|enum)\s+\w+`)
+	memberDocs    = regexp.MustCompile(`^\s*///\s?(.*)$`)
+	trailingDocs  = regexp.MustCompile(`\s*///\s?(.*)$`)
+	structField   = regexp.MustCompile(`^\s*(.+?)\s+(\w+)\s*;`)
+	enumValue     = regexp.MustCompile(`^\s*(\w+)\s*,?\s*$`)
+	leadingNotice = regexp.MustCompile(`^@(?:notice|dev)\s+`)
+)
+
+// a `memberScope` follows the braces of a struct or enum body
+type memberScope struct {
+	inside bool
+	opened bool
+	depth  int
+}
+
+// whether `line` is part of a struct or enum declaration, which keeps its
+// comments as code
+func (m *memberScope) track(language *Language, line []byte) bool {
+	if !m.inside {
+		if language.name != "solidity" || language.rust || language.plain || !memberStart.Match(line) {
+			return false
+		}
+		*m = memberScope{inside: true}
+	}
+	code := line
+	if i := bytes.Index(code, []byte("//")); i >= 0 {
+		code = code[:i]
+	}
+	m.depth += bytes.Count(code, []byte("{")) - bytes.Count(code, []byte("}"))
+	m.opened = m.opened || bytes.Contains(code, []byte("{"))
+	if m.opened && m.depth <= 0 {
+		m.inside = false
+	}
+	return true
+}
+
+// the members of the struct or enum `sym` declared in `code`, whose member
+// comments are taken out of it, with `@param` tags of `docs` filling in
+func structMembers(sym *Symbol, code, docs []byte) ([]Member, []byte) {
+	if sym == nil || sym.Kind != "struct" && sym.Kind != "enum" {
+		return nil, code
+	}
+	params := map[string]string{}
+	for _, tag := range parseTags(docs) {
+		if tag.Name != "param" {
+			continue
+		}
+		if m := paramTag.FindStringSubmatch(tag.Text); m != nil {
+			params[m[1]] = m[2]
+		}
+	}
+	var members []Member
+	var kept [][]byte
+	var pending []string
+	body := false
+	for _, line := range bytes.Split(code, []byte("\n")) {
+		if !body {
+			kept = append(kept, line)
+			body = bytes.Contains(line, []byte("{"))
+			continue
+		}
+		if m := memberDocs.FindSubmatch(line); m != nil {
+			pending = append(pending, string(m[1]))
+			continue
+		}
+		decl := line
+		var trailing string
+		if loc := trailingDocs.FindSubmatchIndex(line); loc != nil {
+			trailing = string(line[loc[2]:loc[3]])
+			decl = line[:loc[0]]
+		}
+		kept = append(kept, decl)
+		var member Member
+		if sym.Kind == "struct" {
+			m := structField.FindSubmatch(decl)
+			if m == nil {
+				pending = nil
+				continue
+			}
+			member = Member{Name: string(m[2]), Type: spaceMatcher.ReplaceAllString(string(m[1]), " ")}
+		} else {
+			m := enumValue.FindSubmatch(bytes.SplitN(decl, []byte("}"), 2)[0])
+			if m == nil {
+				pending = nil
+				continue
+			}
+			member = Member{Name: string(m[1])}
+		}
+		if trailing != "" {
+			pending = append(pending, trailing)
+		}
+		notice := strings.TrimSpace(strings.Join(pending, "\n"))
+		notice = leadingNotice.ReplaceAllString(notice, "")
+		if notice == "" {
+			notice = params[member.Name]
+		}
+		pending = nil
+		member.Notice = notice
+		if notice != "" {
+			member.NoticeHTML = string(blackfriday.MarkdownCommon([]byte(notice)))
+		}
+		members = append(members, member)
+	}
+	for _, member := range members {
+		if member.Notice != "" {
+			return members, bytes.Join(kept, []byte("\n"))
+		}
+	}
+	return nil, code
+}
+
+// the members as a Markdown table
+func memberTable(members []Member) []byte {
+	var b bytes.Buffer
+	if members[0].Type != "" {
+		b.WriteString("| Field | Type | |\n| --- | --- | --- |\n")
+	} else {
+		b.WriteString("| Value | |\n| --- | --- |\n")
+	}
+	for _, m := range members {
+		notice := strings.ReplaceAll(strings.ReplaceAll(m.Notice, "|", "\\|"), "\n", " ")
+		if m.Type != "" {
+			fmt.Fprintf(&b, "| `%s` | `%s` | %s |\n", m.Name, m.Type, notice)
+		} else {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", m.Name, notice)
+		}
+	}
+	b.WriteString("\n")
+	return b.Bytes()
+}
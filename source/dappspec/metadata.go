@@ -0,0 +1,44 @@
+package dappspec
+
+import (
+	"container/list"
+	"regexp"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Contract metadata
+// The NatSpec above the first contract (`@title`, `@author`, `@notice`,
+// `@dev`, `@custom:*`) describes the whole page, so it is rendered as a
+// definition list at the top instead of as an ordinary section.
+
+// a `MetadataEntry` is one row of that list
+type MetadataEntry struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
+	HTML string `json:"-"`
+}
+
+var contractMatcher = regexp.MustCompile(`^\s*(abstract\s+contract|contract|interface|library)\s+\w+`)
+
+// `extractMetadata` takes the tagged docs off the section declaring the
+// first contract, leaving its code in place
+func extractMetadata(sections *list.List) []MetadataEntry {
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if sec.collapsed || !contractMatcher.MatchString(sec.firstCodeLine) {
+			continue
+		}
+		if !hasTags(sec.docsText) {
+			return nil
+		}
+		var entries []MetadataEntry
+		for _, tag := range parseTags(sec.docsText) {
+			html := blackfriday.MarkdownCommon([]byte(tag.Text))
+			entries = append(entries, MetadataEntry{tag.Name, tag.Text, string(html)})
+		}
+		sec.docsText = nil
+		return entries
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## Method registry
+// Wallets show "Sends 100 DAI to vitalik.eth" rather than calldata when
+// they know what a function does. `--methods` writes `docs/methods.json`,
+// a registry in the spirit of EIP-719 keyed by function selector: each
+// entry has the signature, the `@notice`, and the notice as a template
+// with its parameter references (`` `amount` ``) turned into `{amount}`
+// placeholders for the wallet to fill in from the decoded arguments.
+// Functions taking a type not declared in the sources are left out, as
+// their selectors cannot be known.
+
+// a `MethodEntry` describes one function
+type MethodEntry struct {
+	Signature string        `json:"signature"`
+	Contract  string        `json:"contract,omitempty"`
+	Source    string        `json:"source"`
+	Params    []MethodParam `json:"params"`
+	Notice    string        `json:"notice,omitempty"`
+	Template  string        `json:"template,omitempty"`
+}
+
+type MethodParam struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+var codeSpan = regexp.MustCompile("`([^`\n]+)`")
+
+// `notice` with the code spans naming a parameter in `params` replaced by
+// `{name}`
+func noticeTemplate(notice string, params []Param) string {
+	names := map[string]bool{}
+	for _, p := range params {
+		if p.Name != "" {
+			names[p.Name] = true
+		}
+	}
+	return codeSpan.ReplaceAllStringFunc(notice, func(span string) string {
+		if name := strings.Trim(span, "`"); names[name] {
+			return "{" + name + "}"
+		}
+		return span
+	})
+}
+
+// the externally callable functions documented in `doc`, by selector
+func documentMethods(doc *Document) map[string][]MethodEntry {
+	methods := map[string][]MethodEntry{}
+	for _, sec := range sectionViews(doc) {
+		sym := sec.symbol
+		if sym == nil || sym.Kind != "function" || sym.Visibility != "external" && sym.Visibility != "public" {
+			continue
+		}
+		var notices []string
+		for _, tag := range parseTags(sec.docsText) {
+			if tag.Name == "notice" {
+				notices = append(notices, tag.Text)
+			}
+		}
+		notice := strings.Join(notices, "\n\n")
+		signature, ok := abiSignature(sym)
+		if !ok {
+			lintWarn("abi", "%s: %s has a parameter of a type not declared in the sources, so it has no selector", doc.Source, sym.Canonical())
+			continue
+		}
+		types, _ := abiTypeList(sym.Params)
+		m := MethodEntry{
+			Signature: signature,
+			Contract:  sym.Contract,
+			Source:    doc.Source,
+			Params:    []MethodParam{},
+			Notice:    notice,
+			Template:  noticeTemplate(notice, sym.Params),
+		}
+		for i, p := range sym.Params {
+			m.Params = append(m.Params, MethodParam{p.Name, types[i]})
+		}
+		sel := selector(m.Signature)
+		methods[sel] = append(methods[sel], m)
+	}
+	return methods
+}
+
+// write `docs/methods.json` for every source of the build
+func writeMethods() error {
+	if !config.Methods {
+		return nil
+	}
+	methods := map[string][]MethodEntry{}
+	for _, source := range sources {
+		doc, err := sourceDocument(source)
+		if err != nil {
+			return err
+		}
+		for sel, entries := range documentMethods(doc) {
+			methods[sel] = append(methods[sel], entries...)
+		}
+	}
+	for sel, entries := range methods {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Contract < entries[j].Contract })
+		// the same selector for different functions, which a wallet
+		// cannot tell apart from the calldata
+		for _, e := range entries[1:] {
+			if e.Signature != entries[0].Signature {
+				log.Println("dappspec: ", "selector", sel, "collision:", entries[0].Signature, "and", e.Signature)
+			}
+		}
+	}
+	b, err := json.MarshalIndent(struct {
+		Methods map[string][]MethodEntry `json:"methods"`
+	}{methods}, "", "  ")
+	if err != nil {
+		return err
+	}
+	dest := filepath.Join(outputDir(), "methods.json")
+	log.Println("dappspec: ", len(methods), "selectors ->", dest)
+	return writeOutput(dest, append(b, '\n'), 0644)
+}
+
+// ## Parameter references
+// The same references are marked on the pages, so readers see what a
+// wallet would fill in. A reference in a `@notice` to a name that is
+// neither a parameter nor declared in the file is most likely a renamed
+// parameter, and is reported.
+
+var codeElement = regexp.MustCompile(`<code>([A-Za-z_$][\w$]*)</code>`)
+
+// words that are fine in a code span without being declared
+var solidityWords = map[string]bool{
+	"true": true, "false": true, "this": true, "msg": true, "block": true, "tx": true,
+	"address": true, "bool": true, "string": true, "bytes": true, "uint256": true, "int256": true,
+	"payable": true, "view": true, "pure": true, "external": true, "public": true,
+	"internal": true, "private": true, "constructor": true, "fallback": true, "receive": true,
+}
+
+// whether the parameters of `sym` can be referred to in its docs
+func hasParamList(sym *Symbol) bool {
+	if sym == nil {
+		return false
+	}
+	switch sym.Kind {
+	case "function", "constructor", "modifier", "event", "error":
+		return true
+	}
+	return false
+}
+
+// mark the code spans of rendered docs naming a parameter of `sym`
+func markParams(html []byte, sym *Symbol) []byte {
+	if !hasParamList(sym) {
+		return html
+	}
+	types := map[string]string{}
+	for _, p := range sym.Params {
+		if p.Name != "" {
+			types[p.Name] = canonicalType(p.Type)
+		}
+	}
+	return codeElement.ReplaceAllFunc(html, func(m []byte) []byte {
+		name := string(codeElement.FindSubmatch(m)[1])
+		if t, ok := types[name]; ok {
+			return []byte(fmt.Sprintf(`<code class="param" title="%s %s">%s</code>`, t, name, name))
+		}
+		return m
+	})
+}
+
+// report the references in the notices of `doc` to names its sections
+// do not declare
+func checkNoticeReferences(doc *Document, views []SectionView) {
+	declared := map[string]bool{}
+	for _, sec := range views {
+		if sec.symbol != nil {
+			declared[sec.symbol.Name] = true
+		}
+	}
+	for _, sec := range views {
+		sym := sec.symbol
+		if !hasParamList(sym) {
+			continue
+		}
+		known := map[string]bool{}
+		for _, p := range append(append([]Param{}, sym.Params...), sym.Returns...) {
+			known[p.Name] = true
+		}
+		for _, tag := range parseTags(sec.docsText) {
+			if tag.Name != "notice" {
+				continue
+			}
+			for _, m := range codeSpan.FindAllStringSubmatch(tag.Text, -1) {
+				name := m[1]
+				if !isIdentifier(name) || known[name] || declared[name] || solidityWords[name] {
+					continue
+				}
+				log.Println("dappspec: ", fmt.Sprintf("%s: the notice of %s refers to `%s`, which is not a parameter",
+					doc.Source, sectionLabel(doc.Source, sec), name))
+			}
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package dappspec
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// ## Function metrics
+// A few numbers per function, for the templates and the JSON output: how
+// many lines it is, its cyclomatic complexity (one plus its branches:
+// `if`, loops, `catch`, `case`, `&&`, `||` and `?`), how many external
+// calls it makes and how many modifiers it applies. External calls are
+// recognized by their look, a call on a cast address
+// (`IERC20(token).transfer(...)`) or a low-level `call`, `delegatecall`,
+// `staticcall`, `transfer` or `send`. With `--lint`, a function at least as
+// complex as `complexityLimit` is reported when its docs or some `@param`
+// are missing.
+
+// a `FunctionMetrics` describes the size of a function
+type FunctionMetrics struct {
+	Lines         int `json:"lines"`
+	Complexity    int `json:"complexity"`
+	ExternalCalls int `json:"externalCalls"`
+	Modifiers     int `json:"modifiers"`
+}
+
+const complexityLimit = 10
+
+var (
+	branchWords   = regexp.MustCompile(`\b(?:if|for|while|catch|case)\b|&&|\|\||\?`)
+	externalCalls = regexp.MustCompile(`\b[A-Z]\w*\s*\([^()]*\)\s*\.\s*\w+\s*[({]|\.\s*(?:call|delegatecall|staticcall|transfer|send)\s*[({]`)
+)
+
+// `line` without its `//` comment and the contents of its strings
+func codeOnly(line []byte) []byte {
+	var out []byte
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+				out = append(out, c)
+			}
+			continue
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '/' && i+1 < len(line) && line[i+1] == '/':
+			return out
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// the metrics of the function a section declares, or nil
+func metricsOf(sec *Section) *FunctionMetrics {
+	sym := sec.symbol
+	if mutabilityOf(sym) == "" {
+		return nil
+	}
+	body := declarationBody(sec.codeText)
+	m := &FunctionMetrics{Lines: bytes.Count(body, []byte("\n")) + 1, Complexity: 1}
+	for _, line := range bytes.Split(body, []byte("\n")) {
+		line = codeOnly(line)
+		m.Complexity += len(branchWords.FindAll(line, -1))
+		m.ExternalCalls += len(externalCalls.FindAll(line, -1))
+	}
+	for _, call := range appliedModifiers(sym.Signature) {
+		name := call
+		if i := strings.Index(call, "("); i >= 0 {
+			name = call[:i]
+		}
+		// the constructors of bases are called like modifiers
+		if sym.Kind != "constructor" || len(modifierDecls[name]) > 0 {
+			m.Modifiers++
+		}
+	}
+	return m
+}
+
+// report the complex functions of `doc` that are not fully documented
+func lintComplexity(doc *Document, views []SectionView) {
+	if !linting() {
+		return
+	}
+	for _, sec := range views {
+		m := metricsOf(sec.Section)
+		if m == nil || sec.collapsed || m.Complexity < complexityLimit {
+			continue
+		}
+		documented := map[string]bool{}
+		tags := parseTags(sec.docsText)
+		for _, tag := range tags {
+			if p := paramTag.FindStringSubmatch(tag.Text); tag.Name == "param" && p != nil {
+				documented[p[1]] = true
+			}
+		}
+		missing := len(tags) == 0
+		for _, p := range sec.symbol.Params {
+			missing = missing || p.Name != "" && !documented[p.Name]
+		}
+		if missing {
+			lintWarn("complexity", "%s: %s has a complexity of %d and is not fully documented",
+				doc.Source, sectionLabel(doc.Source, sec), m.Complexity)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package dappspec
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ## Minification
+// `--minify` strips comments and the whitespace between block-level tags
+// from every page, compacts the stylesheet, and drops the syntax
+// highlighting rules for token classes no page uses. A Docco-style two
+// column layout is mostly markup, so this roughly halves large sites.
+
+const blockTags = `html|head|title|meta|link|script|body|div|nav|table|tbody|tr|td|th|p|dl|dt|dd|ul|ol|li|h[1-6]|details|summary|pre`
+
+var (
+	cssComment  = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	cssSpace    = regexp.MustCompile(`\s+`)
+	cssPunct    = regexp.MustCompile(`\s*([{}:;,>])\s*`)
+	cssRule     = regexp.MustCompile(`(?m)^(?:body|\.highlight) \.(\w+) \{[^}]*\}[^\n]*\n?`)
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+	preBlock    = regexp.MustCompile(`(?s)<pre[ >].*?</pre>`)
+	classAttr   = regexp.MustCompile(`class="([^"]*)"`)
+	spaceBefore = regexp.MustCompile(`\s+(</?(?:` + blockTags + `)\b)`)
+	spaceAfter  = regexp.MustCompile(`(</?(?:` + blockTags + `)\b[^>]*>)\s+`)
+	spaceRun    = regexp.MustCompile(`\s{2,}`)
+)
+
+// every class used by the pages written so far
+var (
+	usedClassesMu sync.Mutex
+	usedClasses   = map[string]bool{}
+)
+
+func minifyCSS(css string) string {
+	css = cssComment.ReplaceAllString(css, "")
+	css = cssSpace.ReplaceAllString(css, " ")
+	css = cssPunct.ReplaceAllString(css, "$1")
+	css = strings.ReplaceAll(css, ";}", "}")
+	return strings.TrimSpace(css)
+}
+
+// drop the highlighting rules (`body .xx { ... }`) for classes that never
+// appear in a page. This has to run on the unminified stylesheet, where
+// every rule is on a line of its own.
+func pruneCSS(css string) string {
+	usedClassesMu.Lock()
+	defer usedClassesMu.Unlock()
+	return cssRule.ReplaceAllStringFunc(css, func(rule string) string {
+		if usedClasses[cssRule.FindStringSubmatch(rule)[1]] {
+			return rule
+		}
+		return ""
+	})
+}
+
+// remember the classes a page uses, for `pruneCSS`
+func recordClasses(html []byte) {
+	usedClassesMu.Lock()
+	defer usedClassesMu.Unlock()
+	for _, m := range classAttr.FindAllSubmatch(html, -1) {
+		for _, class := range strings.Fields(string(m[1])) {
+			usedClasses[class] = true
+		}
+	}
+}
+
+// `minifyHTML` leaves `<pre>` blocks alone, since whitespace is
+// significant there
+func minifyHTML(html []byte) []byte {
+	recordClasses(html)
+	var out []byte
+	last := 0
+	for _, loc := range preBlock.FindAllIndex(html, -1) {
+		out = append(out, minifyMarkup(html[last:loc[0]])...)
+		out = append(out, html[loc[0]:loc[1]]...)
+		last = loc[1]
+	}
+	return append(out, minifyMarkup(html[last:])...)
+}
+
+func minifyMarkup(html []byte) []byte {
+	html = htmlComment.ReplaceAll(html, nil)
+	html = spaceBefore.ReplaceAll(html, []byte("$1"))
+	html = spaceAfter.ReplaceAll(html, []byte("$1"))
+	// outside `<pre>` any run of whitespace renders as a single space
+	return spaceRun.ReplaceAll(html, []byte(" "))
+}
+
+// the page as it should be written for this run
+func finishPage(html []byte) []byte {
+	if !config.Minify {
+		return html
+	}
+	return minifyHTML(html)
+}
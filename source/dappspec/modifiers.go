@@ -0,0 +1,196 @@
+package dappspec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Modifiers
+// The access checks of a function are in its modifiers, declared
+// somewhere else in the file or in a base contract in another one. Every
+// function lists the modifiers it applies, each unfolding to the docs and
+// body of the modifier, so readers need not jump to it and back.
+// Modifiers declared outside the docs are listed by name.
+
+// a `ModifierUse` is a modifier as a function applies it
+type ModifierUse struct {
+	// How it is applied, as in `onlyRole(ADMIN)`
+	Call string
+	// The declaration, if it is in the docs
+	Href     string
+	DocsHTML string
+	Code     string
+}
+
+// a `modifierDecl` is where a modifier is declared
+type modifierDecl struct {
+	At   Anchor `json:"at"`
+	Docs string `json:"docs"`
+	Code string `json:"code"`
+}
+
+var (
+	modifierDeclaration = regexp.MustCompile(`(?m)^\s*modifier\s+\w+`)
+	// the words after a parameter list that are not modifiers
+	attributeWords = map[string]bool{
+		"external": true, "public": true, "internal": true, "private": true,
+		"view": true, "pure": true, "payable": true, "virtual": true, "override": true,
+		"constant": true, "immutable": true, "returns": true,
+	}
+)
+
+// Filled before any page is rendered and only read after.
+var (
+	// the modifiers the sources declare, by name, in source order
+	modifierDecls = map[string][]modifierDecl{}
+	// a digest of the above, for the cache
+	modifiersKey string
+)
+
+// find the modifiers every source declares. Only the files declaring one
+// are parsed.
+func scanModifiers(files []string) {
+	modifierDecls, modifiersKey = map[string][]modifierDecl{}, ""
+	for _, source := range files {
+		code, err := provider.Read(source)
+		if err != nil || !modifierDeclaration.Match(code) {
+			continue
+		}
+		doc, err := scanDocument(source)
+		if err != nil {
+			continue
+		}
+		for _, sec := range sectionViews(doc) {
+			if sym := sec.symbol; sym != nil && sym.Kind == "modifier" && !sec.collapsed {
+				modifierDecls[sym.Name] = append(modifierDecls[sym.Name], modifierDecl{
+					At:   Anchor{pageOf(source), "section-" + sec.Tag},
+					Docs: strings.TrimSpace(string(sec.docsText)),
+					Code: string(declarationBody(sec.codeText)),
+				})
+			}
+		}
+	}
+	if len(modifierDecls) == 0 {
+		return
+	}
+	b, _ := json.Marshal(modifierDecls)
+	sum := sha256.Sum256(b)
+	modifiersKey = hex.EncodeToString(sum[:])
+}
+
+// the code of the declaration `code` starts with, up to the brace closing
+// its body
+func declarationBody(code []byte) []byte {
+	var braces braceScope
+	lines := bytes.Split(bytes.Trim(code, "\n"), []byte("\n"))
+	for i, line := range lines {
+		braces.track(line)
+		if braces.closed {
+			return bytes.Join(lines[:i+1], []byte("\n"))
+		}
+	}
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// the modifiers applied in `signature`, as written
+func appliedModifiers(signature string) []string {
+	open := strings.Index(signature, "(")
+	if open < 0 {
+		return nil
+	}
+	// skip the parameter list
+	i, depth := open, 0
+	for ; i < len(signature); i++ {
+		if signature[i] == '(' {
+			depth++
+		} else if signature[i] == ')' {
+			if depth--; depth == 0 {
+				break
+			}
+		}
+	}
+	var calls []string
+	rest := signature[i+1:]
+	for len(rest) > 0 {
+		rest = strings.TrimLeft(rest, " ")
+		n := 0
+		for n < len(rest) && (rest[n] == '_' || rest[n] == '$' || rest[n] == '.' ||
+			'a' <= rest[n] && rest[n] <= 'z' || 'A' <= rest[n] && rest[n] <= 'Z' || '0' <= rest[n] && rest[n] <= '9') {
+			n++
+		}
+		if n == 0 {
+			break
+		}
+		name := rest[:n]
+		if name == "returns" {
+			break
+		}
+		call := name
+		rest = rest[n:]
+		if strings.HasPrefix(strings.TrimLeft(rest, " "), "(") {
+			rest = strings.TrimLeft(rest, " ")
+			end, depth := 0, 0
+			for ; end < len(rest); end++ {
+				if rest[end] == '(' {
+					depth++
+				} else if rest[end] == ')' {
+					if depth--; depth == 0 {
+						break
+					}
+				}
+			}
+			if end < len(rest) {
+				end++
+			}
+			call += rest[:end]
+			rest = rest[end:]
+		}
+		if !attributeWords[name] {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// the modifiers the function `sym` applies, seen from `page`
+func modifiersOf(page string, sym *Symbol) []ModifierUse {
+	if sym == nil || sym.Kind != "function" && sym.Kind != "constructor" &&
+		sym.Kind != "fallback" && sym.Kind != "receive" {
+		return nil
+	}
+	var uses []ModifierUse
+	for _, call := range appliedModifiers(sym.Signature) {
+		name := call
+		if i := strings.Index(call, "("); i >= 0 {
+			name = call[:i]
+		}
+		decls := modifierDecls[name]
+		if len(decls) == 0 {
+			// a constructor calls the constructors of its bases the same way
+			if sym.Kind != "constructor" {
+				uses = append(uses, ModifierUse{Call: call})
+			}
+			continue
+		}
+		decl := decls[0]
+		for _, d := range decls {
+			if d.At.Page == page {
+				decl = d
+				break
+			}
+		}
+		uses = append(uses, ModifierUse{
+			Call:     call,
+			Href:     decl.At.href(page),
+			DocsHTML: string(blackfriday.MarkdownCommon([]byte(decl.Docs))),
+			Code:     decl.Code,
+		})
+	}
+	return uses
+}
@@ -0,0 +1,25 @@
+package dappspec
+
+// ## Mutability
+// Whether a function can change state, or take Ether, is the first thing
+// to know about it. Every function section is badged `view`, `pure`,
+// `payable` or `nonpayable`, and pages with functions get a filter to show
+// just the state-changing, read-only or payable ones. The filter is plain
+// CSS, so it also works with `--no-js` and `--csp`.
+
+// the mutability of the function `sym` declares, empty for other
+// declarations
+func mutabilityOf(sym *Symbol) string {
+	if sym == nil {
+		return ""
+	}
+	switch sym.Kind {
+	case "function", "constructor", "fallback", "receive":
+	default:
+		return ""
+	}
+	if sym.Mutability == "" {
+		return "nonpayable"
+	}
+	return sym.Mutability
+}
@@ -0,0 +1,214 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+)
+
+// ## Output names
+// Pages are named after their source, `Token.sol` becoming
+// `docs/Token.html`, in the directories the sources are in below the
+// working directory, or the `root` setting (`--root`): `src/a/Token.sol`
+// is `docs/src/a/Token.html`, or `docs/a/Token.html` with `--root src`.
+// The root does not depend on which files a run is given, so a page keeps
+// its URL when files are added or only some are rebuilt; a source above
+// it loses its leading `../`. Tests and scripts are laid out the same way
+// under `docs/tests/`. `outputName` is a template for
+// the path under the output directory instead, so the URLs can follow an
+// existing site's routes:
+// `{{.Dir}}/{{.Contract | kebab}}.html` puts `src/tokens/ERC20Permit.sol`
+// at `docs/src/tokens/erc20-permit.html`. The template sees the source's
+// `.Dir`, its `.Name` without the extension and its `.Contract`, the first
+// contract, interface or library it declares (or `.Name`), and has
+// `lower`, `upper`, `kebab` and `snake` to change their case. Links
+// between pages in different directories are made relative.
+
+// an `OutputNameData` is what the `outputName` template sees
+type OutputNameData struct {
+	Dir      string
+	Name     string
+	Contract string
+}
+
+var (
+	// the directory the pages are laid out from
+	layoutRoot string
+
+	outputTemplate *template.Template
+	outputNamesMu  sync.Mutex
+	outputNames    = map[string]string{}
+
+	firstUnit = regexp.MustCompile(`(?m)^\s*(?:abstract\s+)?(?:contract|interface|library)\s+(\w+)`)
+)
+
+func checkOutputName() error {
+	outputTemplate = nil
+	outputNamesMu.Lock()
+	outputNames = map[string]string{}
+	outputNamesMu.Unlock()
+	if config.OutputName == "" {
+		return nil
+	}
+	t, err := template.New("outputName").Funcs(template.FuncMap{
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+		"kebab": func(s string) string { return strings.Join(nameWords(s), "-") },
+		"snake": func(s string) string { return strings.Join(nameWords(s), "_") },
+	}).Parse(config.OutputName)
+	if err != nil {
+		return fmt.Errorf("outputName: %v", err)
+	}
+	outputTemplate = t
+	return nil
+}
+
+// the lowercase words of an identifier like `ERC20Permit` or `my_token`
+func nameWords(s string) []string {
+	var words []string
+	var word []rune
+	runes := []rune(s)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(word) > 0 {
+				words = append(words, string(word))
+			}
+			word = nil
+			continue
+		}
+		if i > 0 && unicode.IsUpper(r) && len(word) > 0 {
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				words = append(words, string(word))
+				word = nil
+			}
+		}
+		word = append(word, unicode.ToLower(r))
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	return words
+}
+
+// the path of the page of `source` under the output directory, with
+// forward slashes
+func outputPath(source string) string {
+	base := filepath.Base(source)
+	name := base[0:strings.LastIndex(base, filepath.Ext(base))]
+	if config.StripDocsPrefix {
+		name = strings.TrimPrefix(name, "docs_")
+	}
+	if slug := pageMetas[source].Slug; slug != "" {
+		if isTestSource(source) {
+			return "tests/" + slugPage(slug)
+		}
+		return slugPage(slug)
+	}
+	if outputTemplate == nil {
+		if isTestSource(source) {
+			return "tests/" + layoutPath(layoutRoot, source, name) + ".html"
+		}
+		return layoutPath(layoutRoot, source, name) + ".html"
+	}
+	outputNamesMu.Lock()
+	defer outputNamesMu.Unlock()
+	if p, ok := outputNames[source]; ok {
+		return p
+	}
+	data := OutputNameData{Dir: filepath.ToSlash(filepath.Dir(source)), Name: name, Contract: name}
+	if data.Dir == "." {
+		data.Dir = ""
+	}
+	if code, err := provider.Read(source); err == nil {
+		if m := firstUnit.FindSubmatch(code); m != nil {
+			data.Contract = string(m[1])
+		}
+	}
+	var b bytes.Buffer
+	p := name + ".html"
+	if err := outputTemplate.Execute(&b, data); err != nil {
+		log.Println("dappspec: ", "outputName:", source, err)
+	} else {
+		p = path.Clean("/" + strings.TrimSpace(b.String()))[1:]
+	}
+	if path.Ext(p) != ".html" {
+		p += ".html"
+	}
+	if isTestSource(source) {
+		p = "tests/" + p
+	}
+	outputNames[source] = p
+	return p
+}
+
+// find the directory the pages are laid out from
+func scanLayout() {
+	layoutRoot = config.Root
+	if layoutRoot == "" {
+		layoutRoot = "."
+	}
+}
+
+// the path of the page of `source`, named `name`, below `root`, without
+// the extension
+func layoutPath(root, source, name string) string {
+	dir := filepath.Dir(source)
+	absRoot, err1 := filepath.Abs(root)
+	absDir, err2 := filepath.Abs(dir)
+	if rel, err := filepath.Rel(absRoot, absDir); err1 == nil && err2 == nil && err == nil {
+		dir = rel
+	}
+	dir = filepath.ToSlash(dir)
+	// what cannot be below the output directory is left out
+	for dir != "" && dir != "." {
+		if rest, ok := strings.CutPrefix(dir, "../"); ok {
+			dir = rest
+		} else if rest, ok := strings.CutPrefix(dir, "/"); ok {
+			dir = rest
+		} else {
+			break
+		}
+	}
+	if dir == "" || dir == "." || dir == ".." {
+		return name
+	}
+	return dir + "/" + name
+}
+
+// the pages of `sources` that more than one of them would be written to
+func outputCollisions(sources []string) error {
+	seen := map[string]string{}
+	for _, source := range sources {
+		p := outputPath(source)
+		if other, ok := seen[p]; ok {
+			return fmt.Errorf("%s and %s would both be written to %s", other, source, filepath.Join(outputDir(), p))
+		}
+		seen[p] = source
+	}
+	return nil
+}
+
+// the link to the page `to` from the page `from`, both under the output
+// directory
+func pageLink(from, to string) string {
+	rel, err := filepath.Rel(filepath.Dir(filepath.FromSlash(from)), filepath.FromSlash(to))
+	if err != nil {
+		return to
+	}
+	return filepath.ToSlash(rel)
+}
+
+// the way back to the output directory from the page `page`: empty, or `../` for every
+// directory it is in
+func pageRoot(page string) string {
+	return strings.Repeat("../", strings.Count(page, "/"))
+}
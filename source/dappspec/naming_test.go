@@ -0,0 +1,23 @@
+package dappspec
+
+import "testing"
+
+// The page of a source is below the root whatever other files a run has.
+func TestLayoutPath(t *testing.T) {
+	tests := []struct {
+		root, source, want string
+	}{
+		{".", "Token.sol", "Token"},
+		{".", "src/a/Token.sol", "src/a/Token"},
+		{"src", "src/a/Token.sol", "a/Token"},
+		{"src", "src/Token.sol", "Token"},
+		{"src/", "./src/a/Token.sol", "a/Token"},
+		{"src", "other/Token.sol", "other/Token"},
+		{"src/a", "../x/Token.sol", "x/Token"},
+	}
+	for _, tt := range tests {
+		if got := layoutPath(tt.root, tt.source, "Token"); got != tt.want {
+			t.Errorf("layoutPath(%q, %q) = %q, want %q", tt.root, tt.source, got, tt.want)
+		}
+	}
+}
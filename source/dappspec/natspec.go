@@ -0,0 +1,23 @@
+package dappspec
+
+import "github.com/sambacha/go-natspec/v2/natspec"
+
+// ## NatSpec
+// Doc comments are mostly passed straight through Markdown, but a few
+// features need to know which `@tag` a line belongs to. The tags are read
+// by the `natspec` package, which other Go programs can import to read
+// NatSpec themselves.
+
+// a `Tag` is a single NatSpec tag and its (possibly multi-line) text
+type Tag = natspec.Tag
+
+// split doc text into tags. Text before the first tag is an implicit
+// `@notice`, as in solc.
+func parseTags(docs []byte) []Tag {
+	return natspec.Tags(docs)
+}
+
+// whether any line of the docs starts with a NatSpec tag
+func hasTags(docs []byte) bool {
+	return natspec.HasTags(docs)
+}
@@ -0,0 +1,156 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// ## Section numbers
+// Specs and audit reports refer to sections by number. With
+// `numberSections` (`--number-sections`), every contract, interface and
+// library is numbered across the site in the order of the table of
+// contents, and the declarations in it after it in the order of the page,
+// so `transfer` in the first contract is `1.2`. The numbers show next to
+// each section, in the table of contents, in the Markdown headings and in
+// the JSON. Numbers follow the code, so adding a function early in a
+// contract renumbers the ones after it; each run keeps the numbers in
+// `docs/.dappspec-numbers.json` and warns about those that changed, for the
+// references to them to be updated.
+
+// Filled before any page is rendered and only read after: the number
+// of the first unit of each source, less one
+var unitBase map[string]int
+
+func numbersFile() string {
+	return filepath.Join(outputDir(), ".dappspec-numbers.json")
+}
+
+// number the units of `files`, tests and scripts aside
+func scanNumbers(files []string) {
+	unitBase = map[string]int{}
+	if !config.NumberSections {
+		return
+	}
+	n := 0
+	for _, source := range files {
+		t, ok := titles[source]
+		if !ok || isTestSource(source) {
+			continue
+		}
+		unitBase[source] = n
+		n += len(t.Units)
+	}
+}
+
+// the number of the unit `name` of `source`, or 0
+func unitNumber(source, name string) int {
+	base, ok := unitBase[source]
+	if !ok {
+		return 0
+	}
+	for i, u := range titles[source].Units {
+		if u.Name == name {
+			return base + i + 1
+		}
+	}
+	return 0
+}
+
+// the number of each numbered section of `doc`
+func sectionNumbers(doc *Document) map[*Section]string {
+	if _, ok := unitBase[doc.Source]; !ok {
+		return nil
+	}
+	numbers := map[*Section]string{}
+	counts := map[int]int{}
+	for _, sec := range sectionViews(doc) {
+		sym := sec.symbol
+		if sym == nil || sec.collapsed {
+			continue
+		}
+		if sym.IsUnit() {
+			if n := unitNumber(doc.Source, sym.Name); n > 0 {
+				numbers[sec.Section] = strconv.Itoa(n)
+			}
+			continue
+		}
+		if n := unitNumber(doc.Source, unitOf(doc.Source, sec.Section)); n > 0 {
+			counts[n]++
+			numbers[sec.Section] = fmt.Sprintf("%d.%d", n, counts[n])
+		}
+	}
+	return numbers
+}
+
+// the unit `sec` is in. The declaration of the unit can share its section
+// with the `pragma` above it, leaving `unit` unset, so a file declaring a
+// single unit has everything in it.
+func unitOf(source string, sec *Section) string {
+	if t := titles[source]; sec.unit == "" && len(t.Units) == 1 {
+		return t.Units[0].Name
+	}
+	return sec.unit
+}
+
+// the number of the page of `source` in the table of contents, when it
+// declares a single unit
+func pageNumber(source string) string {
+	if t, ok := titles[source]; ok && len(t.Units) == 1 {
+		if n := unitNumber(source, t.Units[0].Name); n > 0 {
+			return strconv.Itoa(n)
+		}
+	}
+	return ""
+}
+
+// what the numbers are kept under: the unit, and the canonical
+// signature of a member
+func numberKey(source string, sec *Section) string {
+	if sec.symbol.IsUnit() {
+		return sec.symbol.Name
+	}
+	return unitOf(source, sec) + "." + sec.symbol.Canonical()
+}
+
+// warn about the sections numbered differently than in the last run, and
+// keep the numbers of this one
+func checkNumbers() error {
+	if !config.NumberSections {
+		return nil
+	}
+	previous := map[string]string{}
+	if b, err := os.ReadFile(numbersFile()); err == nil {
+		if err := json.Unmarshal(b, &previous); err != nil {
+			return fmt.Errorf("%s: %v", numbersFile(), err)
+		}
+	}
+	current := map[string]string{}
+	for _, source := range sources {
+		doc, err := sourceDocument(source)
+		if err != nil {
+			return err
+		}
+		for sec, n := range sectionNumbers(doc) {
+			current[numberKey(source, sec)] = n
+		}
+	}
+	keys := make([]string, 0, len(current))
+	for key := range current {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if was, ok := previous[key]; ok && was != current[key] {
+			lintWarn("numbering", "%s was numbered %s, now %s", key, was, current[key])
+		}
+	}
+	b, err := json.MarshalIndent(current, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(numbersFile(), append(b, '\n'), 0644)
+}
@@ -0,0 +1,137 @@
+package dappspec
+
+import (
+	"container/list"
+	"sort"
+)
+
+// ## Grouping and ordering
+// Large contracts read better as an API reference: constructor first,
+// then functions by visibility, then events and errors. Sections that
+// declare nothing (pragmas, prose) stay where they are, and every
+// contract in a file is arranged on its own.
+
+// the groups, in the order they appear on the page
+var groups = []struct {
+	name  string
+	title string
+}{
+	{"", ""},
+	{"constructor", "Constructor"},
+	{"external", "External functions"},
+	{"public", "Public functions"},
+	{"internal", "Internal functions"},
+	{"private", "Private functions"},
+	{"modifier", "Modifiers"},
+	{"event", "Events"},
+	{"error", "Errors"},
+	{"type", "Types"},
+	{"variable", "State variables"},
+	{"file", "File-level declarations"},
+	// the groups of tests and scripts
+	{"setup", "Setup"},
+	{"test", "Tests"},
+	{"fuzz", "Fuzz tests"},
+	{"invariant", "Invariants"},
+	{"script", "Script entry points"},
+	{"helper", "Helpers"},
+}
+
+// the group a section belongs in
+func groupOf(sec *Section) string {
+	sym := sec.symbol
+	if sym == nil {
+		return ""
+	}
+	if sec.unit == "" && !sym.IsUnit() {
+		return "file"
+	}
+	switch sym.Kind {
+	case "constructor":
+		return "constructor"
+	case "function", "fallback", "receive":
+		if sym.Visibility == "" {
+			return "public"
+		}
+		return sym.Visibility
+	case "modifier", "event", "error", "variable":
+		return sym.Kind
+	case "struct", "enum", "type":
+		return "type"
+	}
+	return ""
+}
+
+func groupRank(name string) int {
+	for i, g := range groups {
+		if g.name == name {
+			return i
+		}
+	}
+	return 0
+}
+
+func groupTitle(name string) string {
+	return groups[groupRank(name)].title
+}
+
+// `arrangeSections` reorders the list in place according to `groupBy`
+// ("kind", "test" for tests and scripts, or nothing) and `order` ("alpha"
+// or source order)
+func arrangeSections(sections *list.List, groupBy, order string) {
+	if groupBy == "" && order != "alpha" {
+		return
+	}
+	var all, run []*Section
+	unit := ""
+	flush := func() {
+		sort.SliceStable(run, func(i, j int) bool {
+			a, b := run[i], run[j]
+			// sections that declare nothing keep their place up front
+			if (a.symbol == nil) != (b.symbol == nil) {
+				return a.symbol == nil
+			}
+			if a.symbol == nil {
+				return false
+			}
+			if groupBy != "" {
+				ra, rb := groupRank(a.group), groupRank(b.group)
+				if ra != rb {
+					return ra < rb
+				}
+			}
+			if order == "alpha" {
+				return a.symbol.Name < b.symbol.Name
+			}
+			return false
+		})
+		all = append(all, run...)
+		run = nil
+	}
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		switch groupBy {
+		case "kind":
+			sec.group = groupOf(sec)
+		case "test":
+			sec.group = testGroupOf(sec)
+		}
+		// a new contract starts a new run, as does the end of one
+		if sec.symbol.IsUnit() {
+			flush()
+			all = append(all, sec)
+			unit = sec.unit
+			continue
+		}
+		if sec.unit != unit {
+			flush()
+			unit = sec.unit
+		}
+		run = append(run, sec)
+	}
+	flush()
+	sections.Init()
+	for _, sec := range all {
+		sections.PushBack(sec)
+	}
+}
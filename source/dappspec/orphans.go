@@ -0,0 +1,119 @@
+package dappspec
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## Orphans
+// `dappspec orphans [flags] files...` builds the docs as usual, then
+// reports what has drifted away from the code: documented declarations
+// that no other section uses or mentions, and pages in `docs/` that the
+// table of contents no longer leads to. External and public declarations
+// are left out, since they are used from outside. The command fails if it
+// reports anything, so it can run in CI.
+
+var (
+	// set by the command to have every source parsed before rendering
+	scanAll bool
+
+	identifierWords = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+)
+
+func runOrphans(args []string) error {
+	scanAll = true
+	if err := generateFromArgs(args); err != nil {
+		return err
+	}
+	symbols := unreferencedSymbols()
+	pages, err := orphanedPages()
+	if err != nil {
+		return err
+	}
+	for _, s := range symbols {
+		log.Println("dappspec: ", "unreferenced:", s)
+	}
+	for _, p := range pages {
+		log.Println("dappspec: ", "orphaned page:", p)
+	}
+	if len(symbols)+len(pages) > 0 {
+		return fmt.Errorf("%d unreferenced declarations, %d orphaned pages", len(symbols), len(pages))
+	}
+	log.Println("dappspec: ", "no orphans")
+	return nil
+}
+
+// declarations that can be used without mentioning their name
+var implicitKinds = map[string]bool{"constructor": true, "fallback": true, "receive": true}
+
+// the documented declarations whose name appears in no other section
+func unreferencedSymbols() []string {
+	type declaration struct {
+		source string
+		sec    SectionView
+	}
+	var declared []declaration
+	// how many sections mention each identifier
+	mentions := map[string]int{}
+	for _, source := range sources {
+		s, ok := scanned[source]
+		if !ok {
+			continue
+		}
+		for _, sec := range sectionViews(s.doc) {
+			words := map[string]bool{}
+			for _, text := range [][]byte{sec.codeText, sec.docsText} {
+				for _, w := range identifierWords.FindAll(text, -1) {
+					words[string(w)] = true
+				}
+			}
+			for w := range words {
+				mentions[w]++
+			}
+			sym := sec.symbol
+			if sym == nil || sec.collapsed || len(strings.TrimSpace(string(sec.docsText))) == 0 ||
+				sym.IsUnit() || implicitKinds[sym.Kind] || sym.Visibility == "external" || sym.Visibility == "public" {
+				continue
+			}
+			declared = append(declared, declaration{source, sec})
+		}
+	}
+	var unused []string
+	for _, d := range declared {
+		// the declaring section mentions the name itself
+		if mentions[d.sec.symbol.Name] <= 1 {
+			unused = append(unused, fmt.Sprintf("%s: %s (%s)", d.source, sectionLabel(d.source, d.sec), d.sec.symbol.Kind))
+		}
+	}
+	return unused
+}
+
+// the pages in `docs/` that no source documented in this run leads to
+func orphanedPages() ([]string, error) {
+	reachable := map[string]bool{importsLink(""): true, overviewLink(""): true}
+	for _, source := range sources {
+		reachable[pageOf(source)] = true
+		reachable[outputName(referenceDestination(source))] = true
+		reachable[outputName(examplesDestination(source))] = true
+	}
+	var orphans []string
+	err := filepath.WalkDir(outputDir(), func(page string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || filepath.Ext(page) != ".html" {
+			return err
+		}
+		if !reachable[outputName(page)] {
+			orphans = append(orphans, filepath.ToSlash(page))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(orphans)
+	return orphans, nil
+}
@@ -0,0 +1,227 @@
+package dappspec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// ## Output
+// Every generated file goes through `writeOutput`, which remembers what
+// was written so that manifests can be produced at the end of the run.
+// Files are written to a temporary name and renamed into place, so an
+// interrupted run never leaves half a page behind, and files dappspec did
+// not generate itself are left alone unless `--force` is given. The output
+// directory is `docs/` unless `--out` (or `out`) names another.
+
+// an `Output` is one file written into the output directory
+type Output struct {
+	// path relative to the output directory
+	Path string `json:"path"`
+	// subresource-integrity style hashes of the content
+	SHA256 string `json:"sha256"`
+	SHA384 string `json:"sha384"`
+}
+
+var (
+	outputsMu sync.Mutex
+	outputs   = map[string]*Output{}
+	// what previous runs generated, read from the manifest
+	previous = map[string]*Output{}
+	// what this run wrote with new content
+	changed = map[string]bool{}
+)
+
+// where the docs go
+func outputDir() string {
+	if config.Out != "" {
+		return config.Out
+	}
+	return "docs"
+}
+
+// where the list of generated files is kept between runs
+func manifestFile() string {
+	return filepath.Join(outputDir(), ".dappspec-manifest.json")
+}
+
+type manifest struct {
+	Version string    `json:"version"`
+	Files   []*Output `json:"files"`
+	// Fingerprints of the sections of each page, for `--annotate-diff`
+	Sections map[string]map[string]string `json:"sections,omitempty"`
+}
+
+func sriHash(prefix string, sum []byte) string {
+	return prefix + "-" + base64.StdEncoding.EncodeToString(sum)
+}
+
+// the hashes `content` would be recorded with
+func hashOutput(path string, content []byte) *Output {
+	s256 := sha256.Sum256(content)
+	s384 := sha512.Sum384(content)
+	return &Output{path, sriHash("sha256", s256[:]), sriHash("sha384", s384[:])}
+}
+
+// the manifest-relative name of an output path
+func outputName(path string) string {
+	rel, err := filepath.Rel(outputDir(), path)
+	if err != nil {
+		rel = path
+	}
+	return filepath.ToSlash(rel)
+}
+
+// write a generated file and record it
+func writeOutput(path string, content []byte, perm os.FileMode) error {
+	name := outputName(path)
+	outputsMu.Lock()
+	_, known := previous[name]
+	outputsMu.Unlock()
+	if !known && !config.Force {
+		if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("refusing to overwrite %s, which dappspec did not generate (use --force)", path)
+		}
+	}
+	// leave files that are already up to date untouched, so their
+	// modification times only change when their content does
+	existing, err := os.ReadFile(path)
+	isChanged := err != nil || !bytes.Equal(existing, content)
+	if isChanged {
+		if err := writeAtomic(path, content, perm); err != nil {
+			return err
+		}
+	} else if err := os.Chmod(path, perm); err != nil {
+		return err
+	}
+	outputsMu.Lock()
+	outputs[name] = hashOutput(name, content)
+	if isChanged {
+		changed[name] = true
+	}
+	outputsMu.Unlock()
+	return nil
+}
+
+// write to a temporary file next to `path` and rename it into place
+func writeAtomic(path string, content []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// read the files a previous run generated
+func loadManifest() error {
+	b, err := os.ReadFile(manifestFile())
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var m manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return fmt.Errorf("%s: %v", manifestFile(), err)
+	}
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	for _, o := range m.Files {
+		previous[o.Path] = o
+	}
+	sectionsMu.Lock()
+	defer sectionsMu.Unlock()
+	for page, prints := range m.Sections {
+		if _, ok := sectionPrints[page]; !ok {
+			sectionPrints[page] = prints
+		}
+	}
+	return nil
+}
+
+// names of the files this run changed, sorted
+func changedOutputs() []string {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	names := make([]string, 0, len(changed))
+	for name := range changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// names of the files previous runs generated, sorted
+func sortedPrevious() []string {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	names := make([]string, 0, len(previous))
+	for name := range previous {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// record everything generated so far, keeping files from earlier runs
+// that are still on disk
+func writeManifest() error {
+	files := writtenOutputs()
+	outputsMu.Lock()
+	for name, o := range previous {
+		if _, ok := outputs[name]; ok {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(outputDir(), filepath.FromSlash(name))); err == nil {
+			files = append(files, o)
+		}
+	}
+	outputsMu.Unlock()
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	sectionsMu.Lock()
+	sections := map[string]map[string]string{}
+	for page, prints := range sectionPrints {
+		if _, err := os.Stat(filepath.Join(outputDir(), page)); err == nil {
+			sections[page] = prints
+		}
+	}
+	sectionsMu.Unlock()
+	b, err := json.MarshalIndent(manifest{versionString(), files, sections}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(manifestFile(), append(b, '\n'), 0644)
+}
+
+// everything written so far, sorted by path
+func writtenOutputs() []*Output {
+	outputsMu.Lock()
+	defer outputsMu.Unlock()
+	list := make([]*Output, 0, len(outputs))
+	for _, o := range outputs {
+		list = append(list, o)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Path < list[j].Path })
+	return list
+}
@@ -0,0 +1,108 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ## Language overrides
+// The language of a file normally follows its extension. Files whose
+// extension says otherwise (generated files, `.txt` exports) can say what
+// they are on their first line, `/// dappspec:language=solidity`, or be
+// mapped with `--language 'exports/*.txt=solidity'` (or the `languageFor`
+// setting). A language is named by its extension or by name (`yul`).
+
+// a first-line `// dappspec:language=name` comment, `#` for languages
+// commented that way
+var languageDirective = regexp.MustCompile(`^\s*(?://+|#)\s*dappspec:language=(\S+)\s*$`)
+
+var (
+	languageOverridesMu sync.Mutex
+	// the language forced on each source, by a directive or a mapping
+	languageOverrides = map[string]*Language{}
+)
+
+var languageValues = pairsFlag{}
+
+// the extensions of the languages that go by a name
+var languageNames = map[string]string{
+	"solidity": ".sol", "yul": ".yul", "huff": ".huff", "fe": ".fe", "sway": ".sw", "rust": ".rs",
+}
+
+// the language called `name`: an extension, with or without its dot, or
+// one of `languageNames`
+func languageNamed(name string) *Language {
+	if ext, ok := languageNames[strings.ToLower(name)]; ok {
+		name = ext
+	}
+	if !strings.HasPrefix(name, ".") {
+		name = "." + name
+	}
+	return languages[name]
+}
+
+func checkLanguageMappings() error {
+	for pattern, name := range config.LanguageFor {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("language: bad pattern %q: %v", pattern, err)
+		}
+		if languageNamed(name) == nil {
+			return fmt.Errorf("language: unknown language %q for %s", name, pattern)
+		}
+	}
+	return nil
+}
+
+// the language the `language` setting maps `source` to, if any
+func mappedLanguage(source string) *Language {
+	for pattern, name := range config.LanguageFor {
+		for _, candidate := range []string{filepath.ToSlash(source), filepath.Base(source)} {
+			if ok, _ := filepath.Match(pattern, candidate); ok {
+				return languageNamed(name)
+			}
+		}
+	}
+	return nil
+}
+
+// look for a language directive on the first line of `code`, recording it
+// for `source` and returning the code without it
+func languageFromDirective(source string, code []byte) []byte {
+	// a directive removed since the last run (in `serve`) no longer counts
+	languageOverridesMu.Lock()
+	delete(languageOverrides, source)
+	languageOverridesMu.Unlock()
+	line, rest, _ := bytes.Cut(code, []byte("\n"))
+	m := languageDirective.FindSubmatch(line)
+	if m == nil {
+		return code
+	}
+	lang := languageNamed(strings.TrimSpace(string(m[1])))
+	if lang == nil {
+		log.Println("dappspec: ", source, ": unknown language ", string(m[1]))
+		return rest
+	}
+	languageOverridesMu.Lock()
+	languageOverrides[source] = lang
+	languageOverridesMu.Unlock()
+	return rest
+}
+
+// files in languages dappspec does not know are shown as plain text
+var plainLanguage = &Language{name: "text", plain: true}
+
+// record the plain language for `source` if nothing else claims it
+func fallBackToPlain(source string) {
+	if getLanguage(source) != nil {
+		return
+	}
+	log.Println("dappspec: ", source, ": unknown language, rendering it as plain text")
+	languageOverridesMu.Lock()
+	languageOverrides[source] = plainLanguage
+	languageOverridesMu.Unlock()
+}
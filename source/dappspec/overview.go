@@ -0,0 +1,156 @@
+package dappspec
+
+import (
+	"bytes"
+	"log"
+	"path/filepath"
+	"sort"
+)
+
+// ## Overview
+// `--overview` writes `docs/overview.html`, a dashboard of where the
+// documentation debt is: a row for every contract, interface and library
+// (and the file-level declarations of each file) with its documentation
+// coverage, undocumented declarations, sections, functions and their
+// complexity, each cell colored from good to poor.
+
+const overviewPage = "overview.html"
+
+// an `OverviewRow` is one contract
+type OverviewRow struct {
+	Name   string
+	Kind   string
+	Source string
+	Href   string
+	// Declarations expected to carry docs, and how many do
+	Coverage     Coverage
+	Undocumented int
+	Sections     int
+	Functions    int
+	// The most complex function, and the mean over all of them
+	MaxComplexity  int
+	MeanComplexity int
+}
+
+// the colors of a row's cells: `good`, `fair` or `poor`
+func (r OverviewRow) CoverageHeat() string {
+	return heat(r.Coverage.Percent() >= 90, r.Coverage.Percent() >= 60)
+}
+
+func (r OverviewRow) UndocumentedHeat() string {
+	return heat(r.Undocumented == 0, r.Undocumented <= 3)
+}
+
+func (r OverviewRow) ComplexityHeat() string {
+	return heat(r.MaxComplexity <= 5, r.MaxComplexity < complexityLimit)
+}
+
+func heat(good, fair bool) string {
+	switch {
+	case good:
+		return "good"
+	case fair:
+		return "fair"
+	}
+	return "poor"
+}
+
+type OverviewData struct {
+	Title string
+	Rows  []OverviewRow
+	PageChrome
+}
+
+// the link to the overview from `page`, if there is one
+func overviewLink(page string) string {
+	if !config.Overview {
+		return ""
+	}
+	return pageLink(page, overviewPage)
+}
+
+// the rows of the file `doc`, in the order its units are declared
+func overviewRows(doc *Document) []OverviewRow {
+	var rows []*OverviewRow
+	byUnit := map[string]*OverviewRow{}
+	complexity := map[*OverviewRow]int{}
+	for _, sec := range sectionViews(doc) {
+		if sec.collapsed {
+			continue
+		}
+		row, ok := byUnit[sec.unit]
+		if !ok {
+			row = &OverviewRow{Name: sec.unit, Source: doc.Source, Href: pageOf(doc.Source)}
+			if sec.unit == "" {
+				row.Name, row.Kind = "file-level", "declarations"
+			}
+			byUnit[sec.unit] = row
+			rows = append(rows, row)
+		}
+		row.Sections++
+		sym := sec.symbol
+		if sym.IsUnit() {
+			row.Kind = sym.Kind
+			row.Href += "#section-" + sec.Tag
+			continue
+		}
+		if expectsDocs(sym) {
+			row.Coverage.Total++
+			if len(bytes.TrimSpace(sec.docsText)) > 0 {
+				row.Coverage.Documented++
+			} else {
+				row.Undocumented++
+			}
+		}
+		if m := metricsOf(sec.Section); m != nil {
+			row.Functions++
+			complexity[row] += m.Complexity
+			if m.Complexity > row.MaxComplexity {
+				row.MaxComplexity = m.Complexity
+			}
+		}
+	}
+	var out []OverviewRow
+	for _, row := range rows {
+		// what precedes the first contract, like pragmas, is not a row
+		if row.Name == "file-level" && row.Coverage.Total == 0 {
+			continue
+		}
+		if row.Functions > 0 {
+			row.MeanComplexity = complexity[row] / row.Functions
+		}
+		out = append(out, *row)
+	}
+	return out
+}
+
+// write `docs/overview.html` for every source of the build
+func writeOverview() error {
+	if !config.Overview {
+		return nil
+	}
+	var rows []OverviewRow
+	for _, source := range sources {
+		doc, err := sourceDocument(source)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, overviewRows(doc)...)
+	}
+	// the most debt first
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Coverage.Percent() < rows[j].Coverage.Percent()
+	})
+	page, err := executeTemplate("overview", mustAsset("assets/overview.html"), OverviewData{
+		Title:      "Overview",
+		Rows:       rows,
+		PageChrome: pageChrome(),
+	})
+	if err != nil {
+		return err
+	}
+	page = finishPage(page)
+	dest := filepath.Join(outputDir(), overviewPage)
+	log.Println("dappspec: ", "overview", " -> ", dest)
+	return writeOutput(dest, page, 0644)
+}
@@ -0,0 +1,164 @@
+package dappspec
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ## Page metadata
+// What a page is called, where it goes and where it stands in the table
+// of contents can be set for each file, in the `pages` setting by source
+// path or in front matter at the top of the file's first comment:
+//
+//	// ---
+//	// title: The token
+//	// slug: token
+//	// order: 1
+//	// ---
+//
+// `title` is the title of the page, `slug` its path under the output directory
+// without `.html` (winning over `outputName`), and files with an `order`
+// come first in the table of contents, lowest first. The `pages` setting
+// wins over the front matter, which is left out of the docs.
+// `stripDocsPrefix` (`--strip-docs-prefix`) brings back what older
+// versions did instead: a file named `docs_Token.sol` was titled and
+// written as `Token`.
+
+// a `PageMeta` is what is set for the page of a file
+type PageMeta struct {
+	Title string `json:"title,omitempty"`
+	Slug  string `json:"slug,omitempty"`
+	Order *int   `json:"order,omitempty"`
+}
+
+var (
+	// a line of a comment, without its markers
+	frontMatterLine = regexp.MustCompile(`^\s*(?:/\*+|\*|//+)\s?(.*?)\s*(?:\*/)?\s*$`)
+	frontMatterDocs = regexp.MustCompile(`(?m)^---[ \t]*\n(?:.*\n)*?---[ \t]*(?:\n|$)`)
+)
+
+// Filled before any page is rendered and only read after.
+var pageMetas = map[string]PageMeta{}
+
+// read the metadata of the pages of `files`
+func scanPages(files []string) error {
+	pageMetas = map[string]PageMeta{}
+	for _, source := range files {
+		var meta PageMeta
+		if code, err := provider.Read(source); err == nil {
+			if meta, err = frontMatter(code); err != nil {
+				return fmt.Errorf("%s: front matter: %v", source, err)
+			}
+		}
+		if set, ok := config.Pages[filepath.ToSlash(source)]; ok && set != nil {
+			if set.Title != "" {
+				meta.Title = set.Title
+			}
+			if set.Slug != "" {
+				meta.Slug = set.Slug
+			}
+			if set.Order != nil {
+				meta.Order = set.Order
+			}
+		}
+		if meta != (PageMeta{}) {
+			pageMetas[source] = meta
+		}
+	}
+	return nil
+}
+
+// the front matter of `code`: a `---` block among the comment lines
+// it starts with
+func frontMatter(code []byte) (PageMeta, error) {
+	var meta PageMeta
+	in := false
+	for _, line := range strings.Split(strings.ReplaceAll(string(code), "\r\n", "\n"), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		m := frontMatterLine.FindStringSubmatch(line)
+		if m == nil {
+			break
+		}
+		text := m[1]
+		if text == "---" {
+			if in {
+				return meta, nil
+			}
+			in = true
+			continue
+		}
+		if !in {
+			continue
+		}
+		key, value, ok := strings.Cut(text, ":")
+		if !ok {
+			return PageMeta{}, fmt.Errorf("%q is not key: value", text)
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "title":
+			meta.Title = value
+		case "slug":
+			meta.Slug = value
+		case "order":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return PageMeta{}, fmt.Errorf("order %q is not a number", value)
+			}
+			meta.Order = &n
+		default:
+			return PageMeta{}, fmt.Errorf("unknown key %q", key)
+		}
+	}
+	return PageMeta{}, nil
+}
+
+// take the front matter out of the folded license or the docs of the
+// first section, whichever has it
+func stripFrontMatter(doc *Document) {
+	if doc.License != nil && frontMatterDocs.MatchString(doc.License.Text+"\n") {
+		doc.License.Text = strings.TrimSpace(string(withoutFrontMatter([]byte(doc.License.Text))))
+		return
+	}
+	if e := doc.Sections.Front(); e != nil {
+		sec := e.Value.(*Section)
+		sec.docsText = withoutFrontMatter(sec.docsText)
+	}
+}
+
+// `docs` without the first `---` block
+func withoutFrontMatter(docs []byte) []byte {
+	loc := frontMatterDocs.FindIndex(docs)
+	if loc == nil {
+		return docs
+	}
+	return append(append([]byte{}, docs[:loc[0]]...), docs[loc[1]:]...)
+}
+
+// the page under the output directory a `slug` names
+func slugPage(slug string) string {
+	p := path.Clean("/" + slug)[1:]
+	if path.Ext(p) != ".html" {
+		p += ".html"
+	}
+	return p
+}
+
+// `sources` in the order of the table of contents: the files with an
+// `order` first, then the others as they were
+func sortPages(sources []string) {
+	sort.SliceStable(sources, func(i, j int) bool {
+		a, b := pageMetas[sources[i]].Order, pageMetas[sources[j]].Order
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+}
@@ -0,0 +1,78 @@
+package dappspec
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// ## Pipeline
+// The pages of a run are rendered concurrently, `--jobs` at a time. The
+// first page that fails stops the run: the pages not started yet are
+// skipped, and the error is what the run returns. With `--keep-going`
+// every page is tried instead, each failure reported as it happens, and
+// the run fails at the end if any did. It works like `errgroup` with a
+// limit.
+
+// a `pageGroup` runs the pages of a run
+type pageGroup struct {
+	jobs      chan struct{}
+	wg        sync.WaitGroup
+	keepGoing bool
+
+	mu     sync.Mutex
+	first  error
+	failed int
+}
+
+func newPageGroup(limit int, keepGoing bool) *pageGroup {
+	return &pageGroup{jobs: make(chan struct{}, limit), keepGoing: keepGoing}
+}
+
+// whether a page failed and the rest should be skipped
+func (g *pageGroup) stopped() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.first != nil && !g.keepGoing
+}
+
+// run `f` once a job is free, unless the run has stopped
+func (g *pageGroup) Go(f func() error) {
+	g.jobs <- struct{}{}
+	if g.stopped() {
+		<-g.jobs
+		return
+	}
+	g.wg.Add(1)
+	go func() {
+		defer func() {
+			<-g.jobs
+			g.wg.Done()
+		}()
+		if err := f(); err != nil {
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if g.keepGoing {
+				log.Println("dappspec: ", err)
+			}
+			if g.first == nil {
+				g.first = err
+			}
+			g.failed++
+		}
+	}()
+}
+
+// wait for the pages started, then report how the run went
+func (g *pageGroup) Wait() error {
+	g.wg.Wait()
+	switch {
+	case g.failed == 0:
+		return nil
+	case !g.keepGoing:
+		return g.first
+	case g.failed == 1:
+		return fmt.Errorf("1 file failed")
+	}
+	return fmt.Errorf("%d files failed", g.failed)
+}
@@ -0,0 +1,90 @@
+package dappspec
+
+import (
+	"bytes"
+	"html"
+	"regexp"
+)
+
+// ## Plain highlighting
+// `--highlighter none` needs nothing but dappspec itself, for audit
+// machines where installing Pygments is not an option. Code is escaped
+// and shown as it is. With `--plain-keywords`, the keywords and
+// elementary types of Solidity are marked the way Pygments marks them,
+// `k` and `kt`, so the stylesheet sets them apart; comments and strings
+// are left alone.
+
+var (
+	solidityKeywords = regexp.MustCompile(`\b(?:abstract|anonymous|as|assembly|break|calldata|catch|constant|constructor|continue|contract|delete|do|else|emit|enum|error|event|external|fallback|for|function|if|immutable|import|indexed|interface|internal|is|let|library|mapping|memory|modifier|new|override|payable|pragma|private|public|pure|receive|return|returns|revert|storage|struct|try|type|unchecked|using|view|virtual|while)\b`)
+	solidityTypes    = regexp.MustCompile(`\b(?:address|bool|string|bytes(?:[1-9]|[12][0-9]|3[0-2])?|u?int(?:8|16|24|32|40|48|56|64|72|80|88|96|104|112|120|128|136|144|152|160|168|176|184|192|200|208|216|224|232|240|248|256)?)\b`)
+)
+
+// `plainHighlighter` only escapes code, marking Solidity keywords if asked
+type plainHighlighter struct {
+	keywords bool
+}
+
+func (h plainHighlighter) Highlight(lang *Language, code [][]byte) ([][]byte, error) {
+	out := make([][]byte, len(code))
+	for i, c := range code {
+		if h.keywords && followsBraces(lang) {
+			out[i] = markKeywords(c)
+		} else {
+			out[i] = []byte(html.EscapeString(string(c)))
+		}
+	}
+	return out, nil
+}
+
+// `code` escaped, with the keywords and types outside of comments and
+// strings in spans
+func markKeywords(code []byte) []byte {
+	var out bytes.Buffer
+	// where the code not yet written starts, and whether it is inside a
+	// comment or string
+	start, quote, inBlock := 0, byte(0), false
+	flush := func(end int, words bool) {
+		text := []byte(html.EscapeString(string(code[start:end])))
+		if words {
+			text = solidityKeywords.ReplaceAll(text, []byte(`<span class="k">$0</span>`))
+			text = solidityTypes.ReplaceAll(text, []byte(`<span class="kt">$0</span>`))
+		}
+		out.Write(text)
+		start = end
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case inBlock:
+			if c == '*' && i+1 < len(code) && code[i+1] == '/' {
+				i++
+				flush(i+1, false)
+				inBlock = false
+			}
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote || c == '\n' {
+				flush(i+1, false)
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			flush(i, true)
+			quote = c
+		case c == '/' && i+1 < len(code) && code[i+1] == '/':
+			flush(i, true)
+			end := bytes.IndexByte(code[i:], '\n')
+			if end < 0 {
+				end = len(code) - i
+			}
+			i += end - 1
+			flush(i+1, false)
+		case c == '/' && i+1 < len(code) && code[i+1] == '*':
+			flush(i, true)
+			inBlock = true
+			i++
+		}
+	}
+	flush(len(code), quote == 0 && !inBlock)
+	return out.Bytes()
+}
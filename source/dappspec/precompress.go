@@ -0,0 +1,81 @@
+package dappspec
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ## Precompression
+// Static hosts such as nginx (`gzip_static`, `brotli_static`) and
+// S3/CloudFront can serve `.gz` and `.br` siblings directly.
+// `--precompress` writes both for every text asset generated in the run.
+
+// the kinds of file worth compressing
+var compressible = map[string]bool{
+	".html": true, ".css": true, ".js": true, ".json": true,
+	".svg": true, ".xml": true, ".txt": true, ".md": true,
+}
+
+func gzipBytes(content []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w, err := gzip.NewWriterLevel(buf, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func brotliBytes(content []byte) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	w := brotli.NewWriterLevel(buf, brotli.BestCompression)
+	if _, err := w.Write(content); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// write the compressed siblings of everything generated so far
+func precompressOutputs() error {
+	if !config.Precompress {
+		return nil
+	}
+	for _, out := range writtenOutputs() {
+		if !compressible[path.Ext(out.Path)] {
+			continue
+		}
+		name := filepath.Join(outputDir(), filepath.FromSlash(out.Path))
+		content, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		gz, err := gzipBytes(content)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(name+".gz", gz, 0644); err != nil {
+			return err
+		}
+		br, err := brotliBytes(content)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(name+".br", br, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,253 @@
+package dappspec
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ## Source providers
+// Sources are usually files in the working tree, but they can also come
+// from a git ref (`--ref v2.0.0`), a tarball or zip archive, local or
+// remote (`--archive`), or standard input (a `-` argument, named with
+// `--stdin-name`). A `Provider` hides where they come from, so versioned
+// builds need no checkout.
+
+// a `Provider` lists and reads source files
+type Provider interface {
+	// List turns the command-line arguments into the sources to document
+	List(args []string) ([]string, error)
+	// Read returns the content of one of those sources
+	Read(source string) ([]byte, error)
+}
+
+// where the sources of this run come from
+var provider Provider = fsProvider{}
+
+// choose the provider the flags ask for
+func sourceProvider(args []string) (Provider, error) {
+	switch {
+	case config.Ref != "" && config.Archive != "":
+		return nil, fmt.Errorf("--ref and --archive cannot be combined")
+	case config.Ref != "":
+		return gitProvider{config.Ref}, nil
+	case config.Archive != "":
+		return openArchive(config.Archive)
+	case len(args) == 1 && args[0] == "-":
+		b, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, err
+		}
+		return stdinProvider{*stdinName, b}, nil
+	}
+	return fsProvider{}, nil
+}
+
+// `fsProvider` reads the working tree
+type fsProvider struct{}
+
+func (fsProvider) List(args []string) ([]string, error) { return expandInputs(args) }
+
+func (fsProvider) Read(source string) ([]byte, error) { return os.ReadFile(source) }
+
+// `gitProvider` reads files as they are at a git ref. Without arguments,
+// every source in the tree at that ref is documented.
+type gitProvider struct {
+	ref string
+}
+
+func (g gitProvider) List(args []string) ([]string, error) {
+	if len(args) > 0 {
+		return args, nil
+	}
+	files, err := sourcesAt(g.ref)
+	if err != nil {
+		return nil, err
+	}
+	wd, _ := os.Getwd()
+	for i, f := range files {
+		if rel, err := filepath.Rel(wd, f); err == nil {
+			files[i] = rel
+		}
+	}
+	return files, nil
+}
+
+func (g gitProvider) Read(source string) ([]byte, error) {
+	return git("show", g.ref+":"+repoPath(source))
+}
+
+// `stdinProvider` is a single file read from standard input
+type stdinProvider struct {
+	name string
+	code []byte
+}
+
+func (s stdinProvider) List(args []string) ([]string, error) { return []string{s.name}, nil }
+
+func (s stdinProvider) Read(source string) ([]byte, error) { return s.code, nil }
+
+// `archiveProvider` holds the sources of a tarball or zip file in memory
+type archiveProvider struct {
+	files map[string][]byte
+}
+
+// read an archive from a path or an http(s) URL
+func openArchive(location string) (*archiveProvider, error) {
+	var b []byte
+	var err error
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		b, err = download(location)
+	} else {
+		b, err = os.ReadFile(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+	a := &archiveProvider{files: map[string][]byte{}}
+	if bytes.HasPrefix(b, []byte("PK\x03\x04")) {
+		err = a.readZip(b)
+	} else {
+		err = a.readTar(b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", location, err)
+	}
+	a.stripTopDir()
+	return a, nil
+}
+
+// archives of a project usually put everything in one directory named
+// after it (and for GitHub, the commit), which is left out of the names
+func (a *archiveProvider) stripTopDir() {
+	top := ""
+	for name := range a.files {
+		dir, _, ok := strings.Cut(name, "/")
+		if !ok || (top != "" && dir != top) {
+			return
+		}
+		top = dir
+	}
+	stripped := make(map[string][]byte, len(a.files))
+	for name, b := range a.files {
+		stripped[strings.TrimPrefix(name, top+"/")] = b
+	}
+	a.files = stripped
+}
+
+func download(url string) ([]byte, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && strings.HasPrefix(url, "https://api.github.com/") {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func (a *archiveProvider) add(name string, r io.Reader) error {
+	if getLanguage(name) == nil {
+		return nil
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	a.files[path.Clean(name)] = b
+	return nil
+}
+
+func (a *archiveProvider) readZip(b []byte) error {
+	zr, err := zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		return err
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return err
+		}
+		err = a.add(f.Name, rc)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *archiveProvider) readTar(b []byte) error {
+	var r io.Reader = bytes.NewReader(b)
+	if bytes.HasPrefix(b, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		r = gz
+	}
+	tr := tar.NewReader(r)
+	for {
+		h, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if h.Typeflag == tar.TypeReg {
+			if err := a.add(h.Name, tr); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// every source in the archive, or those named by (or under a directory
+// named by) the arguments
+func (a *archiveProvider) List(args []string) ([]string, error) {
+	var files []string
+	for name := range a.files {
+		if len(args) == 0 {
+			files = append(files, name)
+			continue
+		}
+		for _, arg := range args {
+			arg = path.Clean(filepath.ToSlash(arg))
+			if name == arg || strings.HasPrefix(name, arg+"/") {
+				files = append(files, name)
+				break
+			}
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func (a *archiveProvider) Read(source string) ([]byte, error) {
+	b, ok := a.files[source]
+	if !ok {
+		return nil, fmt.Errorf("%s is not in the archive", source)
+	}
+	return b, nil
+}
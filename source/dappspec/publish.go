@@ -0,0 +1,220 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"mime"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ## Publishing
+// `dappspec publish --s3 s3://bucket/prefix` (or `--gcs gs://bucket/prefix`)
+// uploads the output directory (`docs/`, or what `--out` or the `out`
+// setting say) with the right content types and cache headers. Only files
+// listed in the build manifest are published, and only those that changed
+// since the last publish to the same target; files published before that
+// the manifest no longer lists, like the page of a deleted contract, are
+// removed from the target. What is published where is recorded in
+// `.dappspec-published.json` next to the config file, so cleaning the
+// output directory does not forget it. The uploads themselves are done by
+// the `aws` and `gsutil` command-line tools, which handle credentials.
+
+// where what is published is recorded: next to `--config`, or in the
+// working directory like `dappspec.json`
+func publishedFile() string {
+	return filepath.Join(filepath.Dir(*configFile), ".dappspec-published.json")
+}
+
+// where earlier versions recorded it
+func oldPublishedFile() string {
+	return filepath.Join(outputDir(), ".dappspec-published.json")
+}
+
+// target URL -> path -> sha256 at the time it was uploaded
+type publishedState map[string]map[string]string
+
+func runPublish(args []string) error {
+	flags := flag.NewFlagSet("publish", flag.ContinueOnError)
+	s3 := flags.String("s3", "", "publish to this s3://bucket/prefix")
+	gcs := flags.String("gcs", "", "publish to this gs://bucket/prefix")
+	all := flags.Bool("all", false, "upload every file, not just the changed ones")
+	dryRun := flags.Bool("dry-run", false, "print what would be uploaded")
+	htmlCache := flags.String("cache-control-html", "public, max-age=300", "Cache-Control for pages")
+	assetCache := flags.String("cache-control-assets", "public, max-age=86400", "Cache-Control for everything else")
+	out := flags.String("out", "", "publish this directory (default docs)")
+	settings := flags.String("config", "", "read settings from this file (default \""+defaultConfigFile+"\" if present)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	// what is published is where the build wrote it: `--out`, the `out`
+	// setting or `DAPPSPEC_OUT`
+	for name, value := range map[string]string{"out": *out, "config": *settings} {
+		if value != "" {
+			commandLine.Set(name, value)
+		}
+	}
+	if err := loadSettings(); err != nil {
+		return err
+	}
+	target := *s3
+	if (*s3 == "") == (*gcs == "") {
+		return fmt.Errorf("publish needs exactly one of --s3 or --gcs")
+	}
+	if *gcs != "" {
+		target = *gcs
+	}
+	target = strings.TrimSuffix(target, "/")
+
+	if err := loadManifest(); err != nil {
+		return err
+	}
+	if len(previous) == 0 {
+		return fmt.Errorf("no files to publish, %s is missing or empty", manifestFile())
+	}
+	state, err := loadPublished()
+	if err != nil {
+		return err
+	}
+	done := state[target]
+	if done == nil {
+		done = map[string]string{}
+	}
+
+	var uploaded int
+	for _, name := range sortedPrevious() {
+		out := previous[name]
+		if !*all && done[name] == out.SHA256 {
+			continue
+		}
+		cacheControl := *assetCache
+		if strings.HasSuffix(strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ".br"), ".html") {
+			cacheControl = *htmlCache
+		}
+		cmd := uploadCommand(target, name, cacheControl)
+		if *dryRun {
+			fmt.Println(shellJoin(cmd.Args))
+			continue
+		}
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("uploading %s: %v", name, err)
+		}
+		done[name] = out.SHA256
+		uploaded++
+	}
+	// what was published before but is no longer built
+	var gone []string
+	for name := range done {
+		if _, ok := previous[name]; !ok {
+			gone = append(gone, name)
+		}
+	}
+	sort.Strings(gone)
+	var removed int
+	for _, name := range gone {
+		cmd := removeCommand(target, name)
+		if *dryRun {
+			fmt.Println(shellJoin(cmd.Args))
+			continue
+		}
+		cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("removing %s: %v", name, err)
+		}
+		delete(done, name)
+		removed++
+	}
+	if *dryRun {
+		return nil
+	}
+	state[target] = done
+	log.Printf("dappspec: published %d changed file(s) to %s, removed %d", uploaded, target, removed)
+	return savePublished(state)
+}
+
+// the headers a file is served with. Precompressed siblings keep the
+// type of the file they compress.
+func contentHeaders(name string) (contentType, contentEncoding string) {
+	switch path.Ext(name) {
+	case ".gz":
+		contentEncoding, name = "gzip", strings.TrimSuffix(name, ".gz")
+	case ".br":
+		contentEncoding, name = "br", strings.TrimSuffix(name, ".br")
+	}
+	contentType = mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return contentType, contentEncoding
+}
+
+func uploadCommand(target, name, cacheControl string) *exec.Cmd {
+	local := filepath.Join(outputDir(), filepath.FromSlash(name))
+	remote := target + "/" + name
+	contentType, contentEncoding := contentHeaders(name)
+	if strings.HasPrefix(target, "gs://") {
+		args := []string{"-q", "-h", "Content-Type:" + contentType, "-h", "Cache-Control:" + cacheControl}
+		if contentEncoding != "" {
+			args = append(args, "-h", "Content-Encoding:"+contentEncoding)
+		}
+		return exec.Command("gsutil", append(args, "cp", local, remote)...)
+	}
+	args := []string{"s3", "cp", local, remote, "--only-show-errors",
+		"--content-type", contentType, "--cache-control", cacheControl}
+	if contentEncoding != "" {
+		args = append(args, "--content-encoding", contentEncoding)
+	}
+	return exec.Command("aws", args...)
+}
+
+func removeCommand(target, name string) *exec.Cmd {
+	remote := target + "/" + name
+	if strings.HasPrefix(target, "gs://") {
+		return exec.Command("gsutil", "-q", "rm", remote)
+	}
+	return exec.Command("aws", "s3", "rm", remote, "--only-show-errors")
+}
+
+// the command line as it could be pasted into a shell
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if strings.ContainsAny(arg, " ;'\"") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = arg
+	}
+	return strings.Join(quoted, " ")
+}
+
+func loadPublished() (publishedState, error) {
+	state := publishedState{}
+	b, err := os.ReadFile(publishedFile())
+	if errors.Is(err, fs.ErrNotExist) {
+		b, err = os.ReadFile(oldPublishedFile())
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return state, json.Unmarshal(b, &state)
+}
+
+func savePublished(state publishedState) error {
+	b, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeAtomic(publishedFile(), append(b, '\n'), 0644)
+}
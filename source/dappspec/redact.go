@@ -0,0 +1,63 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"strings"
+
+	"github.com/sambacha/go-natspec/v2/natspec"
+)
+
+// ## Redaction
+// Some tags are for the team only, like `@custom:internal` notes or
+// `@custom:todo`. With `redactTags` (`--redact-tags
+// custom:internal,custom:todo`) those tags, with all the lines they run
+// on for, are left out of every output, and the run's summary and
+// `--report` list what was left out where. A build for the team simply
+// leaves the setting off.
+
+// a `Redaction` is a tag left out of the docs
+type Redaction struct {
+	Source string `json:"source"`
+	// The declaration it documented, if any
+	Declaration string `json:"declaration,omitempty"`
+	Tag         string `json:"tag"`
+}
+
+// leave the redacted tags out of the docs of every section of `source`
+func redactSections(source string, sections *list.List) []Redaction {
+	if len(config.RedactTags) == 0 {
+		return nil
+	}
+	redacted := map[string]bool{}
+	for _, tag := range config.RedactTags {
+		redacted[strings.TrimPrefix(strings.TrimSpace(tag), "@")] = true
+	}
+	var found []Redaction
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if !bytes.Contains(sec.docsText, []byte("@")) {
+			continue
+		}
+		var out bytes.Buffer
+		// inside a redacted tag
+		skipping := false
+		for _, line := range bytes.SplitAfter(sec.docsText, []byte("\n")) {
+			if name, _, ok := natspec.TagLine(strings.TrimSuffix(string(line), "\n")); ok {
+				skipping = redacted[name]
+				if skipping {
+					r := Redaction{Source: source, Tag: name}
+					if sec.symbol != nil {
+						r.Declaration = sec.symbol.Signature
+					}
+					found = append(found, r)
+				}
+			}
+			if !skipping {
+				out.Write(line)
+			}
+		}
+		sec.docsText = out.Bytes()
+	}
+	return found
+}
@@ -0,0 +1,72 @@
+package dappspec
+
+import (
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/russross/blackfriday"
+)
+
+// ## Reference view
+// Next to the literate page, `--reference` writes a condensed page per
+// file with only the signatures and their `@notice`s, for integrators who
+// want the API rather than the implementation. The two link to each other.
+
+// a `ReferenceEntry` is one documented declaration
+type ReferenceEntry struct {
+	// the section tag of the declaration on the literate page
+	Anchor     string
+	Signature  string
+	NoticeHTML string
+}
+
+type ReferenceData struct {
+	Title    string
+	Literate string
+	Entries  []*ReferenceEntry
+	Examples string
+	PageChrome
+}
+
+// compute the output location of the reference page
+func referenceDestination(source string) string {
+	return strings.TrimSuffix(destination(source), ".html") + ".ref.html"
+}
+
+// the file name of the reference page, relative to the literate one
+func referenceLink(source string) string {
+	return filepath.Base(referenceDestination(source))
+}
+
+// the reference entry for a section, or nil if it declares nothing
+func referenceEntry(sec *Section, anchor string) *ReferenceEntry {
+	if sec.symbol == nil {
+		return nil
+	}
+	var notices []string
+	for _, tag := range parseTags(sec.docsText) {
+		if tag.Name == "notice" {
+			notices = append(notices, tag.Text)
+		}
+	}
+	html := blackfriday.MarkdownCommon([]byte(strings.Join(notices, "\n\n")))
+	return &ReferenceEntry{anchor, sec.symbol.Signature, string(html)}
+}
+
+func generateReference(source, title string, entries []*ReferenceEntry, examples string) error {
+	dest := referenceDestination(source)
+	html, err := executeTemplate("reference", mustAsset("assets/reference.html"), ReferenceData{
+		Title:      title,
+		Literate:   filepath.Base(destination(source)),
+		Entries:    entries,
+		Examples:   examples,
+		PageChrome: pageChromeAt(pageOf(source)),
+	})
+	if err != nil {
+		return err
+	}
+	html = finishPage(html)
+	log.Println("dappspec: ", source, " -> ", dest)
+	return writeOutput(dest, html, 0644)
+}
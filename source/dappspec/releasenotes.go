@@ -0,0 +1,150 @@
+package dappspec
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ## Release notes
+// `dappspec release-notes <from> <to> [files...]` prints Markdown release
+// notes for the public API between two git refs: the external and public
+// functions, events and errors that were added, removed or changed, with
+// their NatSpec. Without files, every source dappspec knows how to read
+// at `<to>` is compared. Breaking changes are marked as such, and
+// `--check-compat` fails if any of them is not acknowledged.
+
+func runReleaseNotes(args []string) error {
+	flags := flag.NewFlagSet("release-notes", flag.ContinueOnError)
+	compat := flags.Bool("check-compat", false, "fail on breaking changes without a @custom:breaking tag")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	args = flags.Args()
+	if len(args) < 2 {
+		return fmt.Errorf("usage: dappspec release-notes [--check-compat] <from> <to> [files...]")
+	}
+	from, to := args[0], args[1]
+	files := args[2:]
+	if len(files) == 0 {
+		var err error
+		if files, err = sourcesAt(to); err != nil {
+			return err
+		}
+	}
+	changes := changesBetween(from, to, files)
+	if err := writeReleaseNotes(os.Stdout, from, to, changes); err != nil {
+		return err
+	}
+	if *compat {
+		return checkCompat(to, changes)
+	}
+	return nil
+}
+
+// every file at `ref` with an extension we have a language for, as
+// absolute paths
+func sourcesAt(ref string) ([]string, error) {
+	out, err := git("ls-tree", "-r", "--name-only", "--full-tree", ref)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, name := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		if _, ok := languages[filepath.Ext(name)]; ok {
+			files = append(files, filepath.Join(workTree(), filepath.FromSlash(name)))
+		}
+	}
+	return files, nil
+}
+
+// whether a declaration is part of the public API
+func isPublicAPI(sym *DocSymbol) bool {
+	switch sym.Kind {
+	case "event", "error":
+		return true
+	case "function", "fallback", "receive":
+		return sym.Visibility == "external" || sym.Visibility == "public" || sym.Visibility == ""
+	}
+	return false
+}
+
+var releaseSections = []struct{ kind, title string }{
+	{"added", "Added"},
+	{"removed", "Removed"},
+	{"changed", "Changed"},
+	{"docs", "Documentation"},
+}
+
+func writeReleaseNotes(w io.Writer, from, to string, changes map[string][]*Change) error {
+	fmt.Fprintf(w, "# Release notes: %s...%s\n", from, to)
+	breaking := 0
+	for _, list := range changes {
+		for _, c := range list {
+			if c.Breaking() {
+				breaking++
+			}
+		}
+	}
+	if breaking > 0 {
+		fmt.Fprintf(w, "\n**%d breaking change(s)**\n", breaking)
+	}
+	var files []string
+	for source := range changes {
+		files = append(files, source)
+	}
+	sort.Strings(files)
+	empty := true
+	for _, source := range files {
+		byKind := map[string][]*Change{}
+		for _, c := range changes[source] {
+			if isPublicAPI(c.Symbol()) {
+				byKind[c.Kind] = append(byKind[c.Kind], c)
+			}
+		}
+		if len(byKind) == 0 {
+			continue
+		}
+		empty = false
+		fmt.Fprintf(w, "\n## %s\n", repoPath(source))
+		for _, section := range releaseSections {
+			list := byKind[section.kind]
+			if len(list) == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "\n### %s\n\n", section.title)
+			for _, c := range list {
+				writeReleaseNote(w, c)
+			}
+		}
+	}
+	if empty {
+		fmt.Fprintln(w, "\nNo changes to the public API.")
+	}
+	return nil
+}
+
+func writeReleaseNote(w io.Writer, c *Change) {
+	sym := c.Symbol()
+	fmt.Fprint(w, "- ")
+	if c.Breaking() {
+		fmt.Fprint(w, "**BREAKING** ")
+	}
+	switch c.Kind {
+	case "changed":
+		fmt.Fprintf(w, "`%s` → `%s`", c.Old.Signature, c.New.Signature)
+	default:
+		fmt.Fprintf(w, "`%s`", sym.Signature)
+	}
+	if sym.Notice != "" {
+		fmt.Fprintf(w, ": %s", strings.ReplaceAll(sym.Notice, "\n", " "))
+	}
+	fmt.Fprintln(w)
+	if c.Kind == "docs" && c.Old.Notice != c.New.Notice && c.Old.Notice != "" {
+		fmt.Fprintf(w, "  (was: %s)\n", strings.ReplaceAll(c.Old.Notice, "\n", " "))
+	}
+}
@@ -0,0 +1,44 @@
+package dappspec
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ## Remote repositories
+// `dappspec remote [flags] owner/repo[@ref] [paths...]` documents a GitHub
+// repository without a checkout: the tarball of the ref (the default
+// branch if none is given) is downloaded and read in memory, and `paths`
+// narrow it down to some files or directories. Set `GITHUB_TOKEN` for
+// private repositories or to get around rate limits.
+
+// the tarball URL of a GitHub `owner/repo[@ref]`
+func tarballURL(spec string) (string, error) {
+	repo, ref, _ := strings.Cut(spec, "@")
+	parts := strings.Split(repo, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf("expected owner/repo[@ref], got %q", spec)
+	}
+	url := "https://api.github.com/repos/" + repo + "/tarball"
+	if ref != "" {
+		url += "/" + ref
+	}
+	return url, nil
+}
+
+func runRemote(args []string) error {
+	if err := commandLine.Parse(args); err != nil {
+		return err
+	}
+	if commandLine.NArg() == 0 {
+		return fmt.Errorf("remote needs a repository, as owner/repo[@ref]")
+	}
+	url, err := tarballURL(commandLine.Arg(0))
+	if err != nil {
+		return err
+	}
+	configure()
+	config.Archive = url
+	generate(commandLine.Args()[1:])
+	return nil
+}
@@ -0,0 +1,97 @@
+package dappspec
+
+// ## Renderers
+// Parsing a file yields a `Document`; what is made of it is up to the
+// `Renderer`s of the formats asked for. They all walk the same
+// `SectionView`s, so every format numbers and groups sections alike.
+
+// a `Renderer` turns documents into one output format
+type Renderer interface {
+	// The name of the format, as given to `--format`
+	Name() string
+	// Render writes the output for one document, returning the paths
+	// written. Documents are rendered concurrently.
+	Render(doc *Document) ([]string, error)
+	// Finish runs once all documents are rendered, for what depends on
+	// all of them (indexes, stylesheets)
+	Finish() error
+}
+
+// every format there is, in the order they are rendered
+var allRenderers = []Renderer{markdownRenderer{}, jsonRenderer{}, natspecRenderer{}, mdbookRenderer{}, htmlRenderer{}}
+
+// the renderers of the configured formats
+func activeRenderers() []Renderer {
+	var list []Renderer
+	for _, r := range allRenderers {
+		if wantsFormat(r.Name()) {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// a `SectionView` is a section along with where it stands in its page
+type SectionView struct {
+	*Section
+	// 1-based position on the page
+	Index int
+	// The id of the section, without the `section-` prefix
+	Tag string
+	// Set on the first section of each group
+	GroupTitle string
+}
+
+// the sections of a document in page order
+func sectionViews(doc *Document) []SectionView {
+	views := make([]SectionView, 0, doc.Sections.Len())
+	group := ""
+	i := 0
+	for e := doc.Sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		i++
+		view := SectionView{Section: sec, Index: i, Tag: getSectionTag(i, sec.firstCodeLine)}
+		if sec.group != group {
+			group = sec.group
+			view.GroupTitle = groupTitle(group)
+		}
+		views = append(views, view)
+	}
+	return views
+}
+
+// render a document in every configured format
+func renderDocument(doc *Document) ([]string, error) {
+	var outputs []string
+	for _, r := range activeRenderers() {
+		written, err := r.Render(doc)
+		if err != nil {
+			return nil, err
+		}
+		outputs = append(outputs, written...)
+	}
+	return outputs, nil
+}
+
+func finishRenderers() error {
+	for _, r := range activeRenderers() {
+		if err := r.Finish(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// `htmlRenderer` writes the literate pages
+type htmlRenderer struct{}
+
+func (htmlRenderer) Name() string { return "html" }
+
+func (htmlRenderer) Render(doc *Document) ([]string, error) {
+	if err := highlight(doc.Source, doc.Sections); err != nil {
+		return nil, err
+	}
+	return generateHTML(doc)
+}
+
+func (htmlRenderer) Finish() error { return writeAssets(config.Theme) }
@@ -0,0 +1,69 @@
+package dappspec
+
+import (
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ## Repository links
+// Given the repository URL (and branch), every page links to the GitHub
+// editor for its source file, so readers who spot a mistake in the docs
+// are one click away from a pull request fixing it.
+
+// `Repo` describes where the sources are hosted
+type Repo struct {
+	// e.g. https://github.com/sambacha/go-natspec
+	URL string `json:"url,omitempty"`
+	// The branch edits should be made against
+	Branch string `json:"branch,omitempty"`
+}
+
+const defaultBranch = "main"
+
+var (
+	gitRootOnce sync.Once
+	gitRoot     string
+)
+
+// the top of the git work tree we are running in, or empty
+func workTree() string {
+	gitRootOnce.Do(func() {
+		out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+		if err == nil {
+			gitRoot = strings.TrimSpace(string(out))
+		}
+	})
+	return gitRoot
+}
+
+// the path of `source` relative to the root of the repository, with
+// forward slashes
+func repoPath(source string) string {
+	abs, err := filepath.Abs(source)
+	if err != nil {
+		return filepath.ToSlash(source)
+	}
+	if root := workTree(); root != "" {
+		if rel, err := filepath.Rel(root, abs); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.ToSlash(rel)
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(source))
+}
+
+func repoBranch() string {
+	if config.Repo.Branch != "" {
+		return config.Repo.Branch
+	}
+	return defaultBranch
+}
+
+// the GitHub editor URL of `source`, empty when no repository is configured
+func editURL(source string) string {
+	if config.Repo.URL == "" {
+		return ""
+	}
+	return strings.TrimSuffix(config.Repo.URL, "/") + "/edit/" + repoBranch() + "/" + repoPath(source)
+}
@@ -0,0 +1,260 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ## Run report
+// Every run ends with a line of what it did: how many files and
+// sections, how many warnings and how long it took. With `--report
+// report.json` the same goes to a file, with the warnings by category
+// and the slowest files, so pipelines can follow the health of the docs
+// from run to run. `--metrics-append metrics.ndjson` keeps them all: it
+// adds a line to the file on every run, with the commit documented, for
+// charting the coverage over the history of the project.
+
+// how many of the slowest files a report lists
+const slowestFiles = 5
+
+// a `Report` sums up a run
+type Report struct {
+	Files      int            `json:"files"`
+	Cached     int            `json:"cached"`
+	Failed     int            `json:"failed"`
+	Sections   int            `json:"sections"`
+	Coverage   Coverage       `json:"coverage"`
+	Warnings   map[string]int `json:"warnings"`
+	DurationMs int64          `json:"durationMs"`
+	Slowest    []FileReport   `json:"slowest"`
+	// The tags left out with `redactTags`
+	Redacted []Redaction `json:"redacted,omitempty"`
+}
+
+// a `FileReport` is what a run did with one file
+type FileReport struct {
+	Source     string `json:"source"`
+	Sections   int    `json:"sections"`
+	Cached     bool   `json:"cached,omitempty"`
+	Failed     bool   `json:"failed,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+
+	redactions []Redaction
+}
+
+// what the run has done so far
+var (
+	reportMu    sync.Mutex
+	reportStart time.Time
+	fileReports map[string]*FileReport
+	warnings    map[string]int
+)
+
+func startReport() {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	reportStart = time.Now()
+	fileReports = map[string]*FileReport{}
+	warnings = map[string]int{}
+}
+
+// the report of `source`, made on first use. reportMu must be held.
+func fileReport(source string) *FileReport {
+	r, ok := fileReports[source]
+	if !ok {
+		r = &FileReport{Source: source}
+		fileReports[source] = r
+	}
+	return r
+}
+
+// note the sections of a page, and whether it came from the cache
+func reportPage(source string, stats FileStats, cached bool) {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	r := fileReport(source)
+	r.Sections, r.Cached, r.redactions = stats.Sections, cached, stats.Redactions
+}
+
+// note how long a page took, and whether it failed
+func reportTime(source string, d time.Duration, err error) {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	r := fileReport(source)
+	r.DurationMs, r.Failed = d.Milliseconds(), err != nil
+}
+
+// count a warning of the kind `category`
+func reportWarning(category string) {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	warnings[category]++
+}
+
+// the report of the run so far
+func runReport() Report {
+	reportMu.Lock()
+	defer reportMu.Unlock()
+	report := Report{Warnings: map[string]int{}, DurationMs: time.Since(reportStart).Milliseconds()}
+	for category, n := range warnings {
+		report.Warnings[category] = n
+	}
+	files := make([]FileReport, 0, len(fileReports))
+	for _, r := range fileReports {
+		files = append(files, *r)
+		report.Files++
+		report.Sections += r.Sections
+		if r.Cached {
+			report.Cached++
+		}
+		if r.Failed {
+			report.Failed++
+		}
+		report.Redacted = append(report.Redacted, r.redactions...)
+	}
+	sort.SliceStable(report.Redacted, func(i, j int) bool {
+		return report.Redacted[i].Source < report.Redacted[j].Source
+	})
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].DurationMs != files[j].DurationMs {
+			return files[i].DurationMs > files[j].DurationMs
+		}
+		return files[i].Source < files[j].Source
+	})
+	if len(files) > slowestFiles {
+		files = files[:slowestFiles]
+	}
+	report.Slowest = files
+	runStatsMu.Lock()
+	report.Coverage = runStats.Coverage
+	runStatsMu.Unlock()
+	return report
+}
+
+// print the summary of the run, and write the report if asked to
+func writeReport() error {
+	report := runReport()
+	log.Println("dappspec: ", summary(report))
+	if config.Report == "" {
+		return nil
+	}
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeAtomic(config.Report, append(b, '\n'), 0644); err != nil {
+		return err
+	}
+	log.Println("dappspec: ", "report -> ", config.Report)
+	return nil
+}
+
+// a `MetricsLine` is what `--metrics-append` adds for a run
+type MetricsLine struct {
+	Time     time.Time      `json:"time"`
+	Commit   string         `json:"commit,omitempty"`
+	Files    int            `json:"files"`
+	Sections int            `json:"sections"`
+	Coverage Coverage       `json:"coverage"`
+	Percent  int            `json:"percent"`
+	Warnings map[string]int `json:"warnings"`
+}
+
+// add the numbers of a run to the `metricsAppend` file. Runs with failed
+// pages are left out, their numbers being incomplete.
+func appendMetrics() error {
+	if config.MetricsAppend == "" {
+		return nil
+	}
+	report := runReport()
+	if report.Failed > 0 {
+		return nil
+	}
+	line := MetricsLine{
+		Time:     reportStart.UTC().Truncate(time.Second),
+		Commit:   documentedCommit(),
+		Files:    report.Files,
+		Sections: report.Sections,
+		Coverage: report.Coverage,
+		Percent:  report.Coverage.Percent(),
+		Warnings: report.Warnings,
+	}
+	b, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(config.MetricsAppend, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// the commit the sources are from, `--ref` or `HEAD`, or empty outside of
+// git
+func documentedCommit() string {
+	ref := "HEAD"
+	if config.Ref != "" {
+		ref = config.Ref
+	}
+	out, err := git("rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(out))
+}
+
+// the report in a line, like
+// "3 files (1 cached), 42 sections, 2 warnings (complexity 2) in 1.2s"
+func summary(r Report) string {
+	var b strings.Builder
+	b.WriteString(plural(r.Files, "file"))
+	var notes []string
+	if r.Cached > 0 {
+		notes = append(notes, fmt.Sprintf("%d cached", r.Cached))
+	}
+	if r.Failed > 0 {
+		notes = append(notes, fmt.Sprintf("%d failed", r.Failed))
+	}
+	if len(notes) > 0 {
+		b.WriteString(" (" + strings.Join(notes, ", ") + ")")
+	}
+	b.WriteString(", " + plural(r.Sections, "section"))
+	total := 0
+	categories := make([]string, 0, len(r.Warnings))
+	for category, n := range r.Warnings {
+		total += n
+		categories = append(categories, category)
+	}
+	b.WriteString(", " + plural(total, "warning"))
+	if total > 0 {
+		sort.Strings(categories)
+		for i, category := range categories {
+			categories[i] = fmt.Sprintf("%s %d", category, r.Warnings[category])
+		}
+		b.WriteString(" (" + strings.Join(categories, ", ") + ")")
+	}
+	if len(r.Redacted) > 0 {
+		b.WriteString(", " + plural(len(r.Redacted), "tag") + " redacted")
+	}
+	fmt.Fprintf(&b, " in %.1fs", float64(r.DurationMs)/1000)
+	return b.String()
+}
+
+// `n` and `noun`, in the plural unless `n` is 1
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -0,0 +1,155 @@
+package dappspec
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ## Resources
+// The stylesheet and page template are compiled into the binary, so
+// dappspec works the same wherever `go install` put it. Both can be
+// swapped out with `--css` and `--template`, and `--print-assets` writes
+// the defaults to disk as a starting point for customization.
+//
+// A `--theme` directory bundles all of that: its `dappspec.html` and
+// `dappspec.css` replace the defaults, and every other file in it (images,
+// fonts, scripts) is copied into `docs/` as is.
+//
+// Smaller changes can override just one of the template's blocks (`head`,
+// `header`, `sidebar`, `section` and `footer`): a `partials/` directory in
+// the theme, or one given with `--partials`, holds a `<block>.html` per
+// block to replace, and the rest of the page keeps following the default.
+
+//go:embed assets
+var assets embed.FS
+
+// the stylesheet copied next to the generated pages
+var Css = mustAsset("assets/dappspec.css")
+
+// the page template
+var HTML = mustAsset("assets/dappspec.html")
+
+func mustAsset(name string) string {
+	b, err := assets.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(b)
+}
+
+// the theme files that replace the embedded ones instead of being copied
+var themeOverrides = map[string]bool{"dappspec.css": true, "dappspec.html": true}
+
+// the blocks of the page template that can be overridden one by one
+var partialNames = []string{"head", "header", "sidebar", "section", "footer"}
+
+// the overridden blocks, by name
+var Partials = map[string]string{}
+
+// read the `<block>.html` files in `dir`, replacing those blocks
+func loadPartials(dir string) error {
+	for _, name := range partialNames {
+		b, err := os.ReadFile(filepath.Join(dir, name+".html"))
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		Partials[name] = string(b)
+	}
+	return nil
+}
+
+// replace the embedded defaults with the theme, then with any files
+// given on the command line
+func loadAssets(theme, cssPath, templatePath, partialsPath string) error {
+	if theme != "" {
+		if err := loadPartials(filepath.Join(theme, "partials")); err != nil {
+			return err
+		}
+		for name := range themeOverrides {
+			b, err := os.ReadFile(filepath.Join(theme, name))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if name == "dappspec.css" {
+				Css = string(b)
+			} else {
+				HTML = string(b)
+			}
+		}
+	}
+	if cssPath != "" {
+		b, err := os.ReadFile(cssPath)
+		if err != nil {
+			return err
+		}
+		Css = string(b)
+	}
+	if templatePath != "" {
+		b, err := os.ReadFile(templatePath)
+		if err != nil {
+			return err
+		}
+		HTML = string(b)
+	}
+	if partialsPath != "" {
+		return loadPartials(partialsPath)
+	}
+	return nil
+}
+
+// write the stylesheet and the theme's own files into `docs/`
+func writeAssets(theme string) error {
+	if err := writeOutput(filepath.Join(outputDir(), "dappspec.css"), []byte(finalStylesheet()), 0644); err != nil {
+		return err
+	}
+	if theme == "" {
+		return nil
+	}
+	return filepath.WalkDir(theme, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(theme, path)
+		if err != nil || themeOverrides[rel] || filepath.Dir(rel) == "partials" {
+			return err
+		}
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		dest := filepath.Join(outputDir(), rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		return writeOutput(dest, b, 0644)
+	})
+}
+
+// write every embedded asset into `dir`, keeping the names they are
+// embedded under (minus the `assets/` prefix)
+func printAssets(dir string) error {
+	return fs.WalkDir(assets, "assets", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel("assets", path)
+		dest := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return err
+		}
+		b, err := assets.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(dest, b, 0644)
+	})
+}
@@ -0,0 +1,106 @@
+package dappspec
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// ## Rust
+// ink! and CosmWasm contracts are Rust crates, and Fe and Sway borrowed
+// Rust's syntax, so they share a reading of declarations: items with an
+// optional `pub`, `name: Type` parameters, and attributes such as
+// `#[ink(message)]` or `#[entry_point]` in front. Attributes are kept on
+// the symbol, and ones written above a doc comment are moved below it so
+// they stay with the item they belong to.
+
+var (
+	attributeLine = regexp.MustCompile(`^\s*#!?\[.*\]\s*$`)
+	rustItem      = regexp.MustCompile(`^(pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|extern\s+"[^"]*")\s+)*(fn|struct|enum|trait|impl|mod|const|static|type|contract|abi|storage|library)\b\s*(?:<[^>]*>\s*)?(\w+)?`)
+)
+
+// Rust's item kinds, as the kinds symbols have elsewhere
+var rustKinds = map[string]string{
+	"fn": "function", "struct": "struct", "enum": "enum", "type": "type",
+	"const": "variable", "static": "variable", "storage": "variable",
+	"trait": "interface", "abi": "interface", "mod": "module",
+	"contract": "contract", "library": "library", "impl": "impl",
+}
+
+// split the attribute lines off the end of some code, for `parse` to
+// carry over to the next section
+func trailingAttributes(code []byte) ([]byte, []byte) {
+	lines := bytes.SplitAfter(code, []byte("\n"))
+	end := len(lines)
+	for end > 0 && len(bytes.TrimSpace(lines[end-1])) == 0 {
+		end--
+	}
+	start := end
+	for start > 0 && attributeLine.Match(lines[start-1]) {
+		start--
+	}
+	if start == end {
+		return code, nil
+	}
+	return bytes.Join(lines[:start], nil), bytes.Join(lines[start:end], nil)
+}
+
+// `parseRustSymbol` is `parseSymbol` for the Rust family
+func parseRustSymbol(code []byte) *Symbol {
+	var attrs []string
+	lines := bytes.Split(bytes.TrimSpace(code), []byte("\n"))
+	for len(lines) > 0 && (attributeLine.Match(lines[0]) || len(bytes.TrimSpace(lines[0])) == 0) {
+		if attr := bytes.TrimSpace(lines[0]); len(attr) > 0 {
+			attrs = append(attrs, string(attr))
+		}
+		lines = lines[1:]
+	}
+	sig := signature(bytes.TrimSpace(bytes.Join(lines, []byte("\n"))))
+	m := rustItem.FindStringSubmatch(sig)
+	if m == nil {
+		return nil
+	}
+	sym := &Symbol{Kind: rustKinds[m[2]], Name: m[3], Signature: sig, Attributes: attrs}
+	switch {
+	case m[2] == "impl":
+		// `impl Trait for Type` is about the type
+		if i := strings.Index(sig, " for "); i >= 0 {
+			sym.Name = strings.Fields(sig[i+5:])[0]
+		}
+	case m[1] == "":
+	case strings.HasPrefix(m[1], "pub("):
+		sym.Visibility = "internal"
+	default:
+		sym.Visibility = "public"
+	}
+	sym.Name = strings.TrimRight(sym.Name, "{<")
+	if sym.Kind != "function" {
+		return sym
+	}
+	params, end := parenthesized(sig, 0)
+	for _, part := range splitTopLevel(params, ',') {
+		name, typ, ok := strings.Cut(part, ":")
+		name = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(name), "mut "))
+		switch {
+		case strings.HasSuffix(strings.TrimSpace(part), "self"):
+			if !strings.Contains(part, "mut") && strings.Contains(part, "&") {
+				sym.Mutability = "view"
+			}
+		case ok:
+			sym.Params = append(sym.Params, Param{Type: strings.TrimSpace(typ), Name: name})
+		}
+	}
+	if end > 0 {
+		if i := strings.Index(sig[end:], "->"); i >= 0 {
+			ret := strings.TrimSpace(sig[end+i+2:])
+			ret = strings.TrimSpace(strings.SplitN(ret, " where ", 2)[0])
+			sym.Returns = []Param{{Type: strings.TrimSuffix(ret, ";")}}
+		}
+	}
+	for _, attr := range attrs {
+		if strings.Contains(attr, "payable") {
+			sym.Mutability = "payable"
+		}
+	}
+	return sym
+}
@@ -0,0 +1,239 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ## Serve
+// `dappspec serve [--addr localhost:3000] [flags] files...` documents the
+// files, serves the output directory and keeps watching the sources and
+// the template, theme, partials and stylesheet in use. A change re-renders the pages (or just
+// the stylesheet, if that is all that changed) and reloads the browser,
+// which makes working on a theme a matter of saving the file. Only this
+// machine is served unless `--addr` says otherwise, `--addr :3000` for
+// every interface.
+
+// how often the watched files are looked at
+const pollInterval = 500 * time.Millisecond
+
+// the browser side of the reload, appended to pages as they are served
+const reloadScript = `<script>new EventSource("/_dappspec/reload").onmessage = function () { location.reload() }</script>`
+
+func runServe(args []string) error {
+	addr, rest := takeFlag(args, "addr")
+	if addr == "" {
+		addr = "localhost:3000"
+	}
+	if err := generateFromArgs(rest); err != nil {
+		return err
+	}
+	files, err := provider.List(commandLine.Args())
+	if err != nil {
+		return err
+	}
+
+	reload := newReloader()
+	go watch(files, reload)
+
+	mux := http.NewServeMux()
+	mux.Handle("/_dappspec/reload", reload)
+	mux.Handle("/", servePages(http.Dir(outputDir())))
+	log.Println("dappspec: ", "serving", outputDir(), "on", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+// serve the output directory, with the reload script added to each page. Like most
+// static hosts, `/name` serves `name.html`.
+func servePages(root http.FileSystem) http.Handler {
+	files := http.FileServer(root)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Path
+		if filepath.Ext(name) == "" && name != "/" {
+			name += ".html"
+		}
+		if !strings.HasSuffix(name, ".html") {
+			files.ServeHTTP(w, r)
+			return
+		}
+		page, err := os.ReadFile(filepath.Join(outputDir(), filepath.FromSlash(name)))
+		if err != nil {
+			files.ServeHTTP(w, r)
+			return
+		}
+		if i := bytes.LastIndex(page, []byte("</body>")); i >= 0 {
+			page = append(page[:i:i], append([]byte(reloadScript), page[i:]...)...)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.Write(page)
+	})
+}
+
+// a `reloader` tells every open page to reload, over server-sent events
+type reloader struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newReloader() *reloader {
+	return &reloader{clients: map[chan struct{}]bool{}}
+}
+
+func (rl *reloader) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan struct{}, 1)
+	rl.mu.Lock()
+	rl.clients[ch] = true
+	rl.mu.Unlock()
+	defer func() {
+		rl.mu.Lock()
+		delete(rl.clients, ch)
+		rl.mu.Unlock()
+	}()
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	flusher.Flush()
+	for {
+		select {
+		case <-ch:
+			fmt.Fprint(w, "data: reload\n\n")
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (rl *reloader) reload() {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	for ch := range rl.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// the template files in use, besides the sources
+func templateFiles() []string {
+	var list []string
+	for _, path := range []string{*cssFile, *templateFile} {
+		if path != "" {
+			list = append(list, path)
+		}
+	}
+	for _, dir := range []string{config.Theme, config.Partials} {
+		if dir == "" {
+			continue
+		}
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				list = append(list, path)
+			}
+			return nil
+		})
+	}
+	return list
+}
+
+func modTimes(paths []string) map[string]time.Time {
+	times := make(map[string]time.Time, len(paths))
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			times[path] = info.ModTime()
+		}
+	}
+	return times
+}
+
+// what differs between two sets of modification times
+func changedPaths(before, after map[string]time.Time) []string {
+	var list []string
+	for path, t := range after {
+		if !before[path].Equal(t) {
+			list = append(list, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			list = append(list, path)
+		}
+	}
+	return list
+}
+
+// poll `files` and the template files, rebuilding on every change
+func watch(files []string, reload *reloader) {
+	seen := modTimes(append(templateFiles(), files...))
+	for range time.Tick(pollInterval) {
+		now := modTimes(append(templateFiles(), files...))
+		paths := changedPaths(seen, now)
+		seen = now
+		if len(paths) == 0 {
+			continue
+		}
+		log.Println("dappspec: ", "changed:", strings.Join(paths, ", "))
+		if rebuild(files, paths) {
+			reload.reload()
+		}
+	}
+}
+
+// the changed paths were all stylesheets
+func onlyStyles(paths []string) bool {
+	for _, path := range paths {
+		if filepath.Ext(path) != ".css" {
+			return false
+		}
+	}
+	return true
+}
+
+// regenerate after `paths` changed, reporting whether it worked. A broken
+// template or source is reported and otherwise ignored, so the server
+// keeps running until it is fixed.
+func rebuild(files, paths []string) bool {
+	Css, HTML, Partials = mustAsset("assets/dappspec.css"), mustAsset("assets/dappspec.html"), map[string]string{}
+	if err := loadAssets(config.Theme, *cssFile, *templateFile, config.Partials); err != nil {
+		log.Println("dappspec: ", err)
+		return false
+	}
+	if onlyStyles(paths) && !config.Minify {
+		// the stylesheet is ours to overwrite if the manifest says so
+		resetRun()
+		if err := loadManifest(); err != nil {
+			log.Println("dappspec: ", err)
+			return false
+		}
+		if err := writeAssets(config.Theme); err != nil {
+			log.Println("dappspec: ", err)
+			return false
+		}
+		return true
+	}
+	p, err := sourceProvider(files)
+	if err == nil {
+		generating.Lock()
+		err = documentFiles(p, files)
+		generating.Unlock()
+	}
+	if err != nil {
+		log.Println("dappspec: ", err)
+		return false
+	}
+	return true
+}
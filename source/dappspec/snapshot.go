@@ -0,0 +1,103 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+)
+
+// ## Snapshots
+// `dappspec snapshot [flags] files...` generates the docs as usual and
+// records the hash of every generated file in `dappspec.snapshot.json`,
+// which is small enough to commit. `dappspec verify [flags] files...`
+// regenerates and fails if anything differs from the snapshot, so CI can
+// enforce that committed docs are up to date without diffing the HTML.
+
+const snapshotFile = "dappspec.snapshot.json"
+
+type snapshot struct {
+	Version string            `json:"version"`
+	Files   map[string]string `json:"files"`
+}
+
+func currentSnapshot() snapshot {
+	s := snapshot{versionString(), map[string]string{}}
+	for _, out := range writtenOutputs() {
+		s.Files[out.Path] = out.SHA256
+	}
+	return s
+}
+
+// parse the regular flags after the command name and generate
+func generateFromArgs(args []string) error {
+	if err := commandLine.Parse(args); err != nil {
+		return err
+	}
+	configure()
+	if commandLine.NArg() == 0 && len(config.Include) == 0 && config.Ref == "" && config.Archive == "" {
+		return fmt.Errorf("no source files given")
+	}
+	generate(commandLine.Args())
+	return nil
+}
+
+func runSnapshot(args []string) error {
+	if err := generateFromArgs(args); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(currentSnapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Println("dappspec: ", "snapshot", " -> ", snapshotFile)
+	return writeAtomic(snapshotFile, append(b, '\n'), 0644)
+}
+
+func runVerify(args []string) error {
+	b, err := os.ReadFile(snapshotFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s not found, run `dappspec snapshot` first", snapshotFile)
+	}
+	if err != nil {
+		return err
+	}
+	var want snapshot
+	if err := json.Unmarshal(b, &want); err != nil {
+		return fmt.Errorf("%s: %v", snapshotFile, err)
+	}
+	if err := generateFromArgs(args); err != nil {
+		return err
+	}
+	got := currentSnapshot()
+
+	var problems []string
+	for path, hash := range got.Files {
+		switch old, ok := want.Files[path]; {
+		case !ok:
+			problems = append(problems, "new file "+path)
+		case old != hash:
+			problems = append(problems, "changed "+path)
+		}
+	}
+	for path := range want.Files {
+		if _, ok := got.Files[path]; !ok {
+			problems = append(problems, "missing "+path)
+		}
+	}
+	if len(problems) == 0 {
+		log.Println("dappspec: docs match", snapshotFile)
+		return nil
+	}
+	sort.Strings(problems)
+	for _, p := range problems {
+		log.Println("dappspec: ", p)
+	}
+	if want.Version != got.Version {
+		log.Printf("dappspec: the snapshot was taken with dappspec %s, this is %s", want.Version, got.Version)
+	}
+	return fmt.Errorf("docs differ from %s in %d file(s)", snapshotFile, len(problems))
+}
@@ -0,0 +1,104 @@
+package dappspec
+
+import (
+	"bytes"
+	"container/list"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// ## Canonical URLs and social cards
+// With a `--base-url`, every page names its canonical URL and carries Open
+// Graph tags, so links shared in chats and timelines get a proper preview.
+// `--social-cards` also draws a 1200×630 PNG per page (the page title and
+// the contract it documents) into `docs/cards/` to go with them.
+
+const (
+	cardWidth  = 1200
+	cardHeight = 630
+	cardMargin = 80
+)
+
+var (
+	cardBackground = color.RGBA{0xf5, 0xf5, 0xff, 0xff}
+	cardAccent     = color.RGBA{0x37, 0x42, 0xfa, 0xff}
+	cardText       = color.RGBA{0x26, 0x1a, 0x3b, 0xff}
+)
+
+// the absolute URL of a page, if a base URL is configured
+func canonicalURL(dest string) string {
+	base := baseURL()
+	if base == "" {
+		return ""
+	}
+	return base + "/" + outputName(dest)
+}
+
+// where the card for a page goes
+func cardDestination(source string) string {
+	return filepath.Join(outputDir(), "cards", filepath.FromSlash(strings.TrimSuffix(outputPath(source), ".html"))+".png")
+}
+
+// the first contract, interface or library a file declares, as the card
+// subtitle
+func mainContract(sections *list.List) string {
+	for e := sections.Front(); e != nil; e = e.Next() {
+		if sym := e.Value.(*Section).symbol; sym.IsUnit() && sym.Contract == "" {
+			return sym.Kind + " " + sym.Name
+		}
+	}
+	return ""
+}
+
+// draw `text` with the bitmap font scaled by `scale`, its baseline at `y`
+func drawText(dst draw.Image, text string, y, scale int, c color.Color) {
+	face := basicfont.Face7x13
+	width := font.MeasureString(face, text).Ceil()
+	small := image.NewRGBA(image.Rect(0, 0, width, face.Height))
+	d := &font.Drawer{Dst: small, Src: image.NewUniform(c), Face: face, Dot: fixed.P(0, face.Ascent)}
+	d.DrawString(text)
+	// nearest neighbour, which keeps the pixel font crisp
+	top := y - face.Ascent*scale
+	for sy := 0; sy < face.Height; sy++ {
+		for sx := 0; sx < width; sx++ {
+			if _, _, _, a := small.At(sx, sy).RGBA(); a == 0 {
+				continue
+			}
+			draw.Draw(dst, image.Rect(cardMargin+sx*scale, top+sy*scale, cardMargin+(sx+1)*scale, top+(sy+1)*scale), image.NewUniform(c), image.Point{}, draw.Src)
+		}
+	}
+}
+
+// the largest scale up to `max` at which `text` fits on the card
+func fitScale(text string, max int) int {
+	width := font.MeasureString(basicfont.Face7x13, text).Ceil()
+	for scale := max; scale > 1; scale-- {
+		if width*scale <= cardWidth-2*cardMargin {
+			return scale
+		}
+	}
+	return 1
+}
+
+// the PNG card for a page
+func socialCard(title, subtitle string) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(img, img.Bounds(), image.NewUniform(cardBackground), image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(0, 0, cardWidth, 16), image.NewUniform(cardAccent), image.Point{}, draw.Src)
+	drawText(img, title, 300, fitScale(title, 10), cardAccent)
+	if subtitle != "" {
+		drawText(img, subtitle, 420, fitScale(subtitle, 5), cardText)
+	}
+	drawText(img, "dappspec", cardHeight-cardMargin, 3, cardText)
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	return buf.Bytes()
+}
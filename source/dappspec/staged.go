@@ -0,0 +1,77 @@
+package dappspec
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ## Pre-commit hook
+// `--staged` narrows a run down to the files staged in git (intersected
+// with the files given, if any), and `dappspec install-hook [flags...]`
+// writes a pre-commit hook running `dappspec --staged` with those flags.
+
+// the staged files dappspec can document, relative to the working
+// directory. If `only` is not empty, only those files are considered.
+func stagedSources(only []string) ([]string, error) {
+	out, err := git("diff", "--cached", "--name-only", "--diff-filter=ACMR", "--relative")
+	if err != nil {
+		return nil, err
+	}
+	wanted := map[string]bool{}
+	for _, f := range only {
+		wanted[filepath.Clean(f)] = true
+	}
+	var files []string
+	for _, name := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		name = filepath.FromSlash(name)
+		if name == "" || getLanguage(name) == nil {
+			continue
+		}
+		if len(only) > 0 && !wanted[name] {
+			continue
+		}
+		files = append(files, name)
+	}
+	return files, nil
+}
+
+const hookMarker = "# installed by dappspec install-hook"
+
+func runInstallHook(args []string) error {
+	force := false
+	var flags []string
+	for _, arg := range args {
+		if arg == "--force-hook" {
+			force = true
+			continue
+		}
+		flags = append(flags, arg)
+	}
+	out, err := git("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	dir := strings.TrimSpace(string(out))
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(workTree(), dir)
+	}
+	path := filepath.Join(dir, "pre-commit")
+	if existing, err := os.ReadFile(path); err == nil && !strings.Contains(string(existing), hookMarker) && !force {
+		return fmt.Errorf("%s exists and was not written by dappspec (use --force-hook to replace it)", path)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	quoted := make([]string, len(flags))
+	for i, f := range flags {
+		quoted[i] = "'" + strings.ReplaceAll(f, "'", `'\''`) + "'"
+	}
+	script := fmt.Sprintf("#!/bin/sh\n%s\nset -e\ndappspec --staged %s\n", hookMarker, strings.Join(quoted, " "))
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		return err
+	}
+	fmt.Println("dappspec: installed", path)
+	return nil
+}
@@ -0,0 +1,108 @@
+package dappspec
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// ## Highlight styles
+// The built-in stylesheet colors tokens the way Docco does. A
+// `highlightStyle` (`--highlight-style`) names a style to color them with
+// instead, and a `highlightDarkStyle` (`--highlight-dark-style`) one for
+// readers whose system is set to a dark appearance. With Chroma
+// highlighting, the styles are Chroma's and their rules are written from
+// it; with Pygments or a command, they are Pygments' and come from
+// `pygmentize -S`. Either way the rules are scoped to the code blocks and
+// appended to the stylesheet, and with `--minify` those of unused classes
+// are dropped like the built-in ones.
+
+// the rules of the configured styles, filled before any page is rendered
+var styleCSS string
+
+func loadHighlightStyles() error {
+	styleCSS = ""
+	rules := pygmentsStyle
+	if _, ok := activeHighlighter().(chromaHighlighter); ok {
+		rules = chromaStyle
+	}
+	if config.HighlightStyle != "" {
+		css, err := rules(config.HighlightStyle)
+		if err != nil {
+			return err
+		}
+		styleCSS += "\n" + css
+	}
+	if config.HighlightDarkStyle != "" {
+		css, err := rules(config.HighlightDarkStyle)
+		if err != nil {
+			return err
+		}
+		styleCSS += "\n@media (prefers-color-scheme: dark) {\n" + css + "}\n"
+	}
+	return nil
+}
+
+// the rules of the Pygments style `name` for `.highlight` code blocks
+func pygmentsStyle(name string) (string, error) {
+	out, err := exec.Command(pygmentize(), "-S", name, "-f", "html", "-a", ".highlight").Output()
+	if err != nil {
+		return "", fmt.Errorf("highlight style %s: %v", name, err)
+	}
+	// leave out the rules for line numbers and `pre`, which the layout
+	// sets itself
+	var b strings.Builder
+	lines := bufio.NewScanner(bytes.NewReader(out))
+	for lines.Scan() {
+		if strings.HasPrefix(lines.Text(), ".highlight") {
+			b.WriteString(lines.Text() + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// the rules of the Chroma style `name` for `.highlight` code blocks, one
+// per line and in the order of their classes, as `pygmentize -S` writes
+// them
+func chromaStyle(name string) (string, error) {
+	style, ok := styles.Registry[strings.ToLower(name)]
+	if !ok {
+		return "", fmt.Errorf("highlight style %s: chroma has no such style (want one of %s)", name, strings.Join(styles.Names(), ", "))
+	}
+	background := style.Get(chroma.Background)
+	rules := map[string]string{}
+	for t, class := range chroma.StandardTypes {
+		if class == "" || (t < 0 && t != chroma.Error) {
+			continue
+		}
+		if !style.Has(t) && !style.Has(t.SubCategory()) && !style.Has(t.Category()) {
+			continue
+		}
+		// the background is the block's, set once below
+		entry := style.Get(t)
+		if entry.Background == background.Background {
+			entry.Background = 0
+		}
+		if css := html.StyleEntryToCSS(entry); css != "" {
+			rules[class] = fmt.Sprintf(".highlight .%s { %s } /* %s */\n", class, css, t)
+		}
+	}
+	classes := make([]string, 0, len(rules))
+	for class := range rules {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	var b strings.Builder
+	fmt.Fprintf(&b, ".highlight { %s }\n", html.StyleEntryToCSS(background))
+	for _, class := range classes {
+		b.WriteString(rules[class])
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,273 @@
+package dappspec
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+)
+
+// ## Symbols
+// A light-weight look at the code of each section: just enough to know
+// what it declares, without a real Solidity parser.
+
+// a `Symbol` is the declaration a section's code starts with
+type Symbol struct {
+	// `function`, `constructor`, `fallback`, `receive`, `modifier`,
+	// `event`, `error`, `struct`, `enum`, `type`, `variable`, `contract`,
+	// `abstract contract`, `interface` or `library`, and for Rust also
+	// `module` and `impl`
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+	// The declaration up to its body, on a single line
+	Signature string `json:"signature"`
+	// `external`, `public`, `internal`, `private` or empty
+	Visibility string `json:"visibility,omitempty"`
+	// `view`, `pure`, `payable` or empty
+	Mutability string `json:"mutability,omitempty"`
+	// The parameter and return lists, for the kinds that have them
+	Params  []Param `json:"params,omitempty"`
+	Returns []Param `json:"returns,omitempty"`
+	// The contract, interface or library the declaration is in, if any
+	Contract string `json:"contract,omitempty"`
+	// Attributes in front of the declaration, like `#[ink(message)]`
+	Attributes []string `json:"attributes,omitempty"`
+	// The documented fields of a struct or values of an enum
+	Members []Member `json:"members,omitempty"`
+}
+
+// the kinds that contain other declarations
+var unitKinds = map[string]bool{
+	"contract": true, "abstract contract": true, "interface": true, "library": true,
+	"module": true, "impl": true,
+}
+
+// whether the symbol is a contract, interface, library or the like
+func (sym *Symbol) IsUnit() bool {
+	return sym != nil && unitKinds[sym.Kind]
+}
+
+// a `Param` is one entry of a parameter or return list
+type Param struct {
+	Type    string `json:"type"`
+	Name    string `json:"name,omitempty"`
+	Indexed bool   `json:"indexed,omitempty"`
+}
+
+// data locations and other words that are part of neither type nor name
+var paramQualifiers = map[string]bool{"memory": true, "calldata": true, "storage": true, "indexed": true}
+
+// split a parameter list (without its parentheses) into `Param`s
+func parseParams(list string) []Param {
+	var params []Param
+	for _, part := range splitTopLevel(list, ',') {
+		words := strings.Fields(part)
+		if len(words) == 0 {
+			continue
+		}
+		var p Param
+		var typeWords []string
+		for i, w := range words {
+			switch {
+			case w == "indexed":
+				p.Indexed = true
+			case paramQualifiers[w]:
+			case i == len(words)-1 && i > 0 && isIdentifier(w) && w != "payable":
+				p.Name = w
+			default:
+				typeWords = append(typeWords, w)
+			}
+		}
+		p.Type = strings.Join(typeWords, " ")
+		params = append(params, p)
+	}
+	return params
+}
+
+// split on `sep`, ignoring separators nested in brackets
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		case sep:
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+var identifierMatcher = regexp.MustCompile(`^[A-Za-z_$][\w$]*$`)
+
+func isIdentifier(s string) bool {
+	return identifierMatcher.MatchString(s)
+}
+
+// the contents of the parenthesized list starting at or after `from`,
+// and the index just past its closing parenthesis
+func parenthesized(s string, from int) (string, int) {
+	open := strings.IndexByte(s[from:], '(')
+	if open < 0 {
+		return "", -1
+	}
+	open += from
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s[open+1 : i], i + 1
+			}
+		}
+	}
+	return "", -1
+}
+
+// the canonical ABI type of a parameter, as far as it can be known
+// without resolving user-defined types
+func canonicalType(t string) string {
+	t = strings.TrimSuffix(t, " payable")
+	switch {
+	case t == "uint" || strings.HasPrefix(t, "uint["):
+		return "uint256" + strings.TrimPrefix(t, "uint")
+	case t == "int" || strings.HasPrefix(t, "int["):
+		return "int256" + strings.TrimPrefix(t, "int")
+	}
+	return t
+}
+
+// `Canonical` is the declaration as solc keys it, e.g.
+// `transfer(address,uint256)`. Declarations without parameter lists are
+// just their name.
+func (sym *Symbol) Canonical() string {
+	switch sym.Kind {
+	case "function", "constructor", "fallback", "receive", "modifier", "event", "error":
+	default:
+		return sym.Name
+	}
+	types := make([]string, len(sym.Params))
+	for i, p := range sym.Params {
+		types[i] = canonicalType(p.Type)
+	}
+	return sym.Name + "(" + strings.Join(types, ",") + ")"
+}
+
+var (
+	declarationMatcher = regexp.MustCompile(`^(function|modifier|event|error|struct|enum)\s+(\w+)`)
+	specialMatcher     = regexp.MustCompile(`^(constructor|fallback|receive)\s*\(`)
+	typeMatcher        = regexp.MustCompile(`^type\s+(\w+)\s+is\b`)
+	unitMatcher        = regexp.MustCompile(`^(abstract\s+contract|contract|interface|library)\s+(\w+)`)
+	variableMatcher    = regexp.MustCompile(`(\w+)\s*(?:=[^;]*)?;$`)
+	variableName       = regexp.MustCompile(`(\w+)\s*;$`)
+	visibilityMatcher  = regexp.MustCompile(`\b(external|public|internal|private)\b`)
+	mutabilityMatcher  = regexp.MustCompile(`\b(view|pure|payable)\b`)
+	spaceMatcher       = regexp.MustCompile(`\s+`)
+)
+
+// statements that end in `;` but declare nothing worth documenting
+var notVariables = []string{"pragma", "import", "using", "return", "emit", "require", "revert", "}"}
+
+// the index of the `=` of an initializer, skipping the ones in `=>`,
+// `==`, `<=`, `>=` and `!=`
+func assignment(s string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '=' {
+			continue
+		}
+		if i+1 < len(s) && (s[i+1] == '>' || s[i+1] == '=') {
+			i++
+			continue
+		}
+		if i > 0 && strings.IndexByte("<>!=", s[i-1]) >= 0 {
+			continue
+		}
+		return i
+	}
+	return -1
+}
+
+// the declaration at the start of `code`, joined onto one line and cut
+// off where its body starts
+func signature(code []byte) string {
+	end := bytes.IndexAny(code, "{;")
+	if end < 0 {
+		end = len(code)
+	} else if code[end] == ';' {
+		end++
+	}
+	return strings.TrimSpace(spaceMatcher.ReplaceAllString(string(code[:end]), " "))
+}
+
+// `parseSymbol` classifies the code of a section, returning nil when it
+// does not start with a declaration
+func parseSymbol(code []byte) *Symbol {
+	sig := signature(bytes.TrimSpace(code))
+	if sig == "" {
+		return nil
+	}
+	sym := &Symbol{Signature: sig}
+	switch {
+	case unitMatcher.MatchString(sig):
+		m := unitMatcher.FindStringSubmatch(sig)
+		sym.Kind, sym.Name = spaceMatcher.ReplaceAllString(m[1], " "), m[2]
+		return sym
+	case specialMatcher.MatchString(sig):
+		sym.Kind = specialMatcher.FindStringSubmatch(sig)[1]
+		sym.Name = sym.Kind
+	case declarationMatcher.MatchString(sig):
+		m := declarationMatcher.FindStringSubmatch(sig)
+		sym.Kind, sym.Name = m[1], m[2]
+	case typeMatcher.MatchString(sig):
+		sym.Kind, sym.Name = "type", typeMatcher.FindStringSubmatch(sig)[1]
+	case strings.HasSuffix(sig, ";") && variableMatcher.MatchString(sig):
+		for _, prefix := range notVariables {
+			if strings.HasPrefix(sig, prefix) {
+				return nil
+			}
+		}
+		sym.Kind = "variable"
+		decl := sig
+		if i := assignment(sig); i >= 0 {
+			decl = sig[:i] + ";"
+		}
+		m := variableName.FindStringSubmatch(decl)
+		if m == nil {
+			return nil
+		}
+		sym.Name = m[1]
+	default:
+		return nil
+	}
+	// only the part before the parameter list can hold a name, the part
+	// after it holds the attributes
+	attrs := sig
+	if i := strings.Index(sig, ")"); i >= 0 && sym.Kind != "variable" {
+		attrs = sig[i:]
+	}
+	if m := visibilityMatcher.FindStringSubmatch(attrs); m != nil {
+		sym.Visibility = m[1]
+	}
+	if m := mutabilityMatcher.FindStringSubmatch(attrs); m != nil {
+		sym.Mutability = m[1]
+	}
+	if sym.Kind != "variable" && sym.Kind != "type" && sym.Kind != "struct" && sym.Kind != "enum" {
+		params, end := parenthesized(sig, 0)
+		sym.Params = parseParams(params)
+		if end > 0 {
+			if i := strings.Index(sig[end:], "returns"); i >= 0 {
+				returns, _ := parenthesized(sig, end+i)
+				sym.Returns = parseParams(returns)
+			}
+		}
+	}
+	return sym
+}
@@ -0,0 +1,53 @@
+package dappspec
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// ## Tabs
+// Contracts mixing tabs and spaces line up differently in every browser.
+// `--tab-width` fixes how wide a tab is drawn in the code column, and
+// `--expand-tabs` replaces tabs with spaces before highlighting, so the
+// layout (and text copied out of the page) no longer depends on it.
+
+const defaultTabWidth = 4
+
+func tabWidth() int {
+	if config.TabWidth > 0 {
+		return config.TabWidth
+	}
+	return defaultTabWidth
+}
+
+// replace each tab with spaces up to the next tab stop
+func expandTabs(code []byte, width int) []byte {
+	if bytes.IndexByte(code, '\t') < 0 {
+		return code
+	}
+	buf := make([]byte, 0, len(code))
+	col := 0
+	for _, r := range string(code) {
+		switch r {
+		case '\t':
+			n := width - col%width
+			buf = append(buf, bytes.Repeat([]byte(" "), n)...)
+			col += n
+			continue
+		case '\n':
+			col = 0
+		default:
+			col++
+		}
+		buf = append(buf, string(r)...)
+	}
+	return buf
+}
+
+// the rule appended to the stylesheet when a tab width is configured
+func tabStyle() string {
+	if config.TabWidth <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("\npre { -moz-tab-size: %d; tab-size: %d; }\n", config.TabWidth, config.TabWidth)
+}
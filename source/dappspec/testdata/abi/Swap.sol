@@ -0,0 +1,65 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.20;
+
+/// @notice A token
+interface IERC20 {
+    /// @notice Moves tokens, like any ERC-20
+    event Transfer(address indexed from, address indexed to, uint value);
+
+    /// @notice Sends `amount` tokens to `to`
+    function transfer(address to, uint amount) external returns (bool);
+}
+
+/// A price, in wei per token
+type Price is uint128;
+
+/// @title A swap
+contract Swap {
+    /// which way an order goes
+    enum Side {
+        Buy,
+        Sell
+    }
+
+    struct Order {
+        address maker;
+        Side side;
+        Price price;
+    }
+
+    /// @dev Set by the last swap
+    /// @return the price last paid
+    Price public last;
+
+    /// @notice Swapped `amount` of `token`
+    /// @param token the token sold
+    event Swapped(IERC20 indexed token, Side side, uint256 amount);
+
+    /// @notice An order was placed
+    event Placed(Order order);
+
+    /// @notice A payout to a vault, which is declared elsewhere
+    event Paid(Vault vault);
+
+    /// @notice The order is past `limit`
+    error Slipped(Order order, Price limit);
+
+    /// @notice Swaps `amount` of `token`
+    /// @param token the token sold
+    /// @param side which way
+    /// @param amount how much
+    function swap(IERC20 token, Side side, uint amount) external {}
+
+    /// @notice The price of `token`
+    /// @return the price
+    function peek(IERC20 token) external view returns (Price) {}
+
+    /// @notice Places `order`
+    function place(Order calldata order) external {}
+
+    /// @notice Places many orders
+    function placeAll(Order[] calldata orders) external {}
+
+    /// @notice Pays out to `vault`
+    function pay(Vault vault) external {}
+}
@@ -0,0 +1,16 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/// @title Low-level helpers
+library Bytes {
+    /// @notice Reads a word at `offset`
+    /// @param data The bytes to read from
+    /// @param offset Where the word starts
+    /// @return word The 32 bytes at `offset`
+    function readWord(bytes memory data, uint256 offset) internal pure returns (bytes32 word) {
+        // the length word comes first
+        assembly {
+            word := mload(add(add(data, 0x20), offset))
+        }
+    }
+}
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/**
+ * @title Documented with block comments
+ * @notice NatSpec may also be written as block comments
+ */
+contract Counter {
+    /** @notice The current count */
+    uint256 public count;
+
+    /**
+     * @notice Adds one
+     * @dev Emits nothing
+     */
+    function increment() external {
+        count += 1;
+    }
+
+    /// @notice Starts over
+    function reset() external {
+        count = 0;
+    }
+}
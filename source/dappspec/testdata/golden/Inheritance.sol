@@ -0,0 +1,38 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/// @title Something with an owner
+abstract contract Ownable {
+    /// @notice The current owner
+    address public owner;
+
+    /// @notice Only the owner may call
+    modifier onlyOwner() {
+        require(msg.sender == owner, "not owner");
+        _;
+    }
+
+    constructor() {
+        owner = msg.sender;
+    }
+}
+
+/// @title A vault only its owner can drain
+/// @custom:security-contact security@example.com
+contract Vault is Ownable {
+    /// @notice Balances by depositor
+    mapping(address => uint256) public balances;
+
+    /// @notice Accepts a deposit
+    function deposit() external payable {
+        balances[msg.sender] += msg.value;
+    }
+
+    /// @notice Sends everything to the owner
+    /// @inheritdoc Ownable
+    function drain() external onlyOwner {
+        payable(owner).transfer(address(this).balance);
+    }
+
+    receive() external payable {}
+}
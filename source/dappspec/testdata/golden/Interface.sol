@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/// @title ERC-20 token standard
+/// @notice The interface every fungible token implements
+interface IERC20 {
+    /// @notice Emitted when `value` tokens move from `from` to `to`
+    /// @param from The sender
+    /// @param to The recipient
+    /// @param value The amount
+    event Transfer(address indexed from, address indexed to, uint256 value);
+
+    /// @notice The balance of `account`
+    /// @param account The holder
+    /// @return The number of tokens held
+    function balanceOf(address account) external view returns (uint256);
+
+    /// @notice Moves `amount` tokens to `to`
+    /// @param to The recipient
+    /// @param amount The amount
+    /// @return Whether the transfer succeeded
+    function transfer(address to, uint256 amount) external returns (bool);
+}
@@ -0,0 +1,24 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/// @title Checked arithmetic helpers
+library SafeMath {
+    /// @notice Thrown when a result does not fit
+    error Overflow();
+
+    /// @notice Adds two numbers, reverting on overflow
+    /// @param a The first operand
+    /// @param b The second operand
+    /// @return c The sum
+    function add(uint256 a, uint256 b) internal pure returns (uint256 c) {
+        unchecked {
+            c = a + b;
+        }
+        if (c < a) revert Overflow();
+    }
+
+    /// @dev Not documented for users
+    function sub(uint256 a, uint256 b) internal pure returns (uint256) {
+        return a - b;
+    }
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: MIT
+pragma solidity ^0.8.0;
+
+/// @notice A price in wei per token
+type Price is uint256;
+
+/// @title Orders and their sides
+contract Orders {
+    /// @notice An order resting on the book
+    /// @param expiry when it lapses
+    struct Order {
+        /// who placed the order
+        address maker;
+        uint256 amount; /// how much, in wei
+        mapping(address => uint256) fills;
+        Price limit; /// the worst price accepted
+        uint64 expiry;
+    }
+
+    /// @notice Which side an order is on
+    enum Side {
+        Buy, /// bids
+        /// asks
+        Sell
+    }
+
+    /// @notice Places an order
+    function place(Order calldata order) external {}
+}
+
+function midpoint(Price a, Price b) pure returns (Price) {
+    return Price.wrap((Price.unwrap(a) + Price.unwrap(b)) / 2);
+}
@@ -0,0 +1,266 @@
+package dappspec
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ## Tests
+// With `tests` (`--tests test`), the test files under the given
+// directories are read for the tests of each function, listed under the
+// function as "Tested by" with links to their source. Tests are found by
+// their names:
+//
+//   - in Foundry tests (`*.t.sol`), `testTransfer`, `test_transfer_...`,
+//     `testFuzz_Transfer` and `invariant...` exercise `transfer`; when the
+//     test contract is named after a contract, as `TokenTest` is, only that
+//     contract's functions are matched
+//   - in Hardhat or other JavaScript tests, `describe` and `it` blocks whose
+//     title starts with the function, as in `it("transfer moves tokens")`
+//     or `describe("#transfer")`, exercise it
+//
+// Where a name fits several functions, the longest wins, so
+// `testTransferFrom` is for `transferFrom` and not `transfer`. What the
+// names cannot tell can be spelled out in a `testMap` file (`--test-map`),
+// from `Contract.function` or `function` to tests as `file:name`:
+//
+//	{"Token.transfer": ["test/Token.t.sol:testMovesBalance"]}
+//
+// Test files are read from the working tree. Links go to the file on the
+// repository when `repo` is set, and to the file on disk otherwise.
+
+// a `TestCase` is a test of a function
+type TestCase struct {
+	Name string `json:"name"`
+	File string `json:"file"`
+	Line int    `json:"line"`
+	// The contract it tests, lowercased, or empty for any
+	target string
+	// The function it is mapped to, or empty if it is matched by name
+	function string
+	// What of its name tells which function it tests
+	words string
+}
+
+var (
+	foundryTest   = regexp.MustCompile(`^\s*function\s+((?:test|invariant)\w*)\s*\(`)
+	foundryPrefix = regexp.MustCompile(`^(?:test|invariant)(?:Fuzz|Fail|Fork)?_*`)
+	testContract  = regexp.MustCompile(`^\s*contract\s+(\w+)`)
+	jsTest        = regexp.MustCompile("\\b(?:describe|context|it)\\s*\\(\\s*[\"'`]([^\"'`]+)")
+	testFiles     = regexp.MustCompile(`\.t\.sol$|\.(?:test|spec)\.[cm]?[jt]s$|^test/.*\.[cm]?[jt]s$`)
+)
+
+// Filled before any page is rendered and only read after.
+var (
+	testCases []TestCase
+	// a digest of the above, for the cache
+	testsKey string
+)
+
+// read the tests of the `tests` directories and the `testMap`
+func scanTests() error {
+	testCases, testsKey = nil, ""
+	for _, root := range config.Tests {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() || !testFiles.MatchString(filepath.ToSlash(path)) {
+				return nil
+			}
+			code, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			testCases = append(testCases, findTests(path, code)...)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("tests: %v", err)
+		}
+	}
+	if config.TestMap != "" {
+		mapped, err := loadTestMap(config.TestMap)
+		if err != nil {
+			return fmt.Errorf("test map: %v", err)
+		}
+		testCases = append(testCases, mapped...)
+	}
+	if len(testCases) == 0 {
+		return nil
+	}
+	h := sha256.New()
+	for _, t := range testCases {
+		fmt.Fprintf(h, "%s\x00%s\x00%d\x00%s\x00%s\x00", t.Name, t.File, t.Line, t.target, t.function)
+	}
+	testsKey = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// the tests declared in the test file `path`
+func findTests(path string, code []byte) []TestCase {
+	var found []TestCase
+	solidity := strings.HasSuffix(path, ".sol")
+	target := ""
+	for i, line := range bytes.Split(code, []byte("\n")) {
+		if !solidity {
+			for _, m := range jsTest.FindAllSubmatch(line, -1) {
+				title := string(m[1])
+				found = append(found, TestCase{Name: title, File: path, Line: i + 1,
+					words: strings.TrimLeft(title, "#.")})
+			}
+			continue
+		}
+		if m := testContract.FindSubmatch(line); m != nil {
+			target = testedContract(string(m[1]))
+			continue
+		}
+		if m := foundryTest.FindSubmatch(line); m != nil {
+			name := string(m[1])
+			found = append(found, TestCase{Name: name, File: path, Line: i + 1, target: target,
+				words: foundryPrefix.ReplaceAllString(name, "")})
+		}
+	}
+	return found
+}
+
+// the contract a test contract is named after, lowercased, as `token` for
+// `TokenTest` or `TestToken`, or empty
+func testedContract(name string) string {
+	for _, affix := range []string{"Tests", "Test"} {
+		if strings.HasSuffix(name, affix) && len(name) > len(affix) {
+			return strings.ToLower(strings.TrimSuffix(name, affix))
+		}
+		if strings.HasPrefix(name, affix) && len(name) > len(affix) {
+			return strings.ToLower(strings.TrimPrefix(name, affix))
+		}
+	}
+	return ""
+}
+
+// the tests a `testMap` file maps to functions
+func loadTestMap(path string) ([]TestCase, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entries map[string][]string
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return nil, err
+	}
+	var mapped []TestCase
+	for key, tests := range entries {
+		target, function := "", key
+		if i := strings.LastIndex(key, "."); i >= 0 {
+			target, function = strings.ToLower(key[:i]), key[i+1:]
+		}
+		for _, test := range tests {
+			i := strings.LastIndex(test, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("%s: %q is not file:name", key, test)
+			}
+			file, name := test[:i], test[i+1:]
+			mapped = append(mapped, TestCase{Name: name, File: file, Line: lineOf(file, name),
+				target: target, function: function})
+		}
+	}
+	sort.Slice(mapped, func(i, j int) bool {
+		if mapped[i].File != mapped[j].File {
+			return mapped[i].File < mapped[j].File
+		}
+		return mapped[i].Line < mapped[j].Line
+	})
+	return mapped, nil
+}
+
+// the line of `file` first mentioning `name`, or 0
+func lineOf(file, name string) int {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return 0
+	}
+	for i, line := range bytes.Split(b, []byte("\n")) {
+		if bytes.Contains(line, []byte(name)) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// whether the name `words` of a test starts with the function `name`,
+// followed by the end or the start of a new word
+func testsFunction(words, name string) bool {
+	if len(words) < len(name) || !strings.EqualFold(words[:len(name)], name) {
+		return false
+	}
+	if len(words) == len(name) {
+		return true
+	}
+	next := rune(words[len(name)])
+	return next == '_' || unicode.IsUpper(next) || !unicode.IsLetter(next) && !unicode.IsDigit(next)
+}
+
+// the links to the tests of each function of a page, by section
+func testsOf(page string, views []SectionView) map[*Section][]Backlink {
+	if len(testCases) == 0 {
+		return nil
+	}
+	functions := map[string][]*Section{}
+	for _, sec := range views {
+		if sym := sec.symbol; sym != nil && sym.Kind == "function" && !sec.collapsed {
+			functions[sym.Name] = append(functions[sym.Name], sec.Section)
+		}
+	}
+	links := map[*Section][]Backlink{}
+	for _, t := range testCases {
+		name := t.function
+		if name == "" {
+			// the longest function the name of the test starts with
+			for fn := range functions {
+				if len(fn) > len(name) && testsFunction(t.words, fn) {
+					name = fn
+				}
+			}
+		}
+		for _, sec := range functions[name] {
+			if t.target == "" || t.target == strings.ToLower(sec.unit) {
+				links[sec] = append(links[sec], Backlink{testHref(page, t), t.Name})
+			}
+		}
+	}
+	return links
+}
+
+// the link to a test from the page `page`
+func testHref(page string, t TestCase) string {
+	if config.Repo.URL != "" {
+		href := strings.TrimSuffix(config.Repo.URL, "/") + "/blob/" + repoBranch() + "/" + repoPath(t.File)
+		if t.Line > 0 {
+			href += fmt.Sprintf("#L%d", t.Line)
+		}
+		return href
+	}
+	abs, err := filepath.Abs(t.File)
+	if err != nil {
+		return filepath.ToSlash(t.File)
+	}
+	root, err := filepath.Abs(outputDir())
+	if err != nil {
+		return filepath.ToSlash(t.File)
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return filepath.ToSlash(t.File)
+	}
+	return pageLink(page, filepath.ToSlash(rel))
+}
@@ -0,0 +1,229 @@
+package dappspec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ## Titles
+// A page is called what its contract calls itself: the `@title` of the
+// contract, interface or library the file declares, or else its name, and
+// only failing both the file name. A file declaring several is called by
+// its name, and the table of contents lists each of them under it. Titles are read
+// straight from the sources before any page is rendered, since every page
+// lists every other in its table of contents; only files declaring several
+// units are parsed, to link to the section of each.
+//
+// With `tocSummary` every file is parsed, and the table of contents doubles
+// as an overview of the project: each entry gets a badge for the kind of
+// unit, the first line of its `@notice`, and how much of the file is
+// documented.
+
+// a `FileTitle` is what the table of contents shows for a file
+type FileTitle struct {
+	Title string      `json:"title"`
+	Units []UnitTitle `json:"units,omitempty"`
+	// Set with `tocSummary`
+	Coverage *Coverage `json:"coverage,omitempty"`
+}
+
+// a `UnitTitle` is a contract, interface or library of a file
+type UnitTitle struct {
+	Name  string `json:"name"`
+	Title string `json:"title"`
+	// The id of the unit's section, if it has one of its own
+	ID string `json:"id,omitempty"`
+	// Set with `tocSummary`: `interface`, `library`, `abstract` or
+	// `contract`, and the first line of the notice
+	Kind   string `json:"kind,omitempty"`
+	Notice string `json:"notice,omitempty"`
+}
+
+// a `TOCUnit` is a link to a unit in the table of contents, relative to
+// `docs/`
+type TOCUnit struct {
+	// With `numberSections`
+	Number string
+	Title  string
+	Href   string
+	Kind   string
+	Notice string
+}
+
+// a `TOCSummary` is what the table of contents shows next to a file
+type TOCSummary struct {
+	Kind     string
+	Notice   string
+	Coverage *Coverage
+}
+
+var (
+	unitLine  = regexp.MustCompile(`^(abstract\s+)?(contract|interface|library)\s+(\w+)`)
+	titleLine = regexp.MustCompile(`^\s*(?:///|/\*\*|\*)\s*@title\s+(.*?)\s*(?:\*/)?$`)
+	// lines of a doc comment, or attributes between it and the declaration
+	commentLine = regexp.MustCompile(`^\s*(?://|/\*|\*|@)`)
+)
+
+// Filled before any page is rendered and only read after.
+var (
+	titles = map[string]*FileTitle{}
+	// a digest of the titles, for the cache
+	titlesKey string
+)
+
+// the title of `source` when its contracts do not give one
+func fileTitle(source string) string {
+	title := filepath.Base(source)
+	title = strings.TrimSuffix(title, filepath.Ext(source))
+	if config.StripDocsPrefix {
+		title = strings.TrimPrefix(title, "docs_")
+	}
+	return title
+}
+
+// the top-level units `code` declares, with the `@title` of the doc
+// comment right above each
+func unitTitles(code []byte) []UnitTitle {
+	var units []UnitTitle
+	lines := strings.Split(strings.ReplaceAll(string(code), "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		m := unitLine.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		u := UnitTitle{Name: m[3], Title: m[3], Kind: unitBadge(m[2])}
+		if m[1] != "" {
+			u.Kind = unitBadge("abstract contract")
+		}
+		for j := i - 1; j >= 0 && commentLine.MatchString(lines[j]); j-- {
+			if t := titleLine.FindStringSubmatch(lines[j]); t != nil && t[1] != "" {
+				u.Title = t[1]
+			}
+		}
+		units = append(units, u)
+	}
+	return units
+}
+
+// read the titles of every source
+func scanTitles(files []string) {
+	titles = map[string]*FileTitle{}
+	for _, source := range files {
+		t := &FileTitle{Title: fileTitle(source)}
+		if code, err := provider.Read(source); err == nil {
+			if code, err = decodeSource(source, code); err == nil {
+				t.Units = unitTitles(code)
+			}
+		}
+		switch {
+		case pageMetas[source].Title != "":
+			t.Title = pageMetas[source].Title
+		case len(t.Units) == 1:
+			t.Title = t.Units[0].Title
+		}
+		if len(t.Units) > 1 || config.TOCSummary {
+			t.Coverage = unitSections(source, t.Units)
+		}
+		if !config.TOCSummary {
+			t.Coverage = nil
+			for i := range t.Units {
+				t.Units[i].Kind, t.Units[i].Notice = "", ""
+			}
+		}
+		titles[source] = t
+	}
+	b, _ := json.Marshal([]interface{}{titles, pageMetas})
+	sum := sha256.Sum256(b)
+	titlesKey = hex.EncodeToString(sum[:])
+}
+
+// the title of the page of `source`
+func pageTitle(source string) string {
+	if t, ok := titles[source]; ok {
+		return t.Title
+	}
+	return fileTitle(source)
+}
+
+// find the sections declaring `units` in the page of `source` and their
+// notices, returning the coverage of the file
+func unitSections(source string, units []UnitTitle) *Coverage {
+	doc, err := scanDocument(source)
+	if err != nil {
+		return nil
+	}
+	found := map[string]UnitTitle{}
+	for _, sec := range sectionViews(doc) {
+		if !sec.symbol.IsUnit() || sec.collapsed {
+			continue
+		}
+		if _, ok := found[sec.symbol.Name]; !ok {
+			found[sec.symbol.Name] = UnitTitle{ID: "section-" + sec.Tag, Notice: firstNotice(parseTags(sec.docsText))}
+		}
+	}
+	for i := range units {
+		f := found[units[i].Name]
+		units[i].ID, units[i].Notice = f.ID, f.Notice
+	}
+	// the docs of the first contract are the page's metadata
+	if len(units) > 0 && units[0].Notice == "" {
+		var tags []Tag
+		for _, m := range doc.Metadata {
+			tags = append(tags, Tag{Name: m.Name, Text: m.Text})
+		}
+		units[0].Notice = firstNotice(tags)
+	}
+	c := doc.Coverage
+	return &c
+}
+
+// the first line of the notice among `tags`
+func firstNotice(tags []Tag) string {
+	for _, tag := range tags {
+		if tag.Name == "notice" {
+			return strings.SplitN(tag.Text, "\n", 2)[0]
+		}
+	}
+	return ""
+}
+
+// what the table of contents shows next to `source`: the kind and notice
+// of its unit when it has just one, and its coverage
+func summaryTOC(source string) *TOCSummary {
+	t, ok := titles[source]
+	if !ok || !config.TOCSummary {
+		return nil
+	}
+	s := &TOCSummary{Coverage: t.Coverage}
+	if len(t.Units) == 1 {
+		s.Kind, s.Notice = t.Units[0].Kind, t.Units[0].Notice
+	}
+	return s
+}
+
+// the units of `source` for its table of contents entry, when it declares
+// more than one
+func unitsTOC(source string) []TOCUnit {
+	t, ok := titles[source]
+	if !ok || len(t.Units) < 2 {
+		return nil
+	}
+	var units []TOCUnit
+	for _, u := range t.Units {
+		href := destinationTOC(source)
+		if u.ID != "" {
+			href += "#" + u.ID
+		}
+		number := ""
+		if n := unitNumber(source, u.Name); n > 0 {
+			number = strconv.Itoa(n)
+		}
+		units = append(units, TOCUnit{number, u.Title, href, u.Kind, u.Notice})
+	}
+	return units
+}
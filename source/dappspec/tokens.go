@@ -0,0 +1,56 @@
+package dappspec
+
+import "regexp"
+
+// ## Token classes
+// Pygments marks tokens with short classes (`k`, `nf`, `s2`...) that
+// other highlighters may not share. With `--token-classes` every token
+// span also gets one of a few stable classes, so a theme can color code
+// the same way whatever highlighted it:
+//
+//	tok-comment  tok-keyword  tok-type      tok-name     tok-function
+//	tok-class    tok-builtin  tok-variable  tok-constant tok-string
+//	tok-number   tok-operator tok-punctuation           tok-error
+//
+// Spans of tokens none of them fits, like whitespace, are left alone.
+
+var tokenSpan = regexp.MustCompile(`<span class="(\w+)">`)
+
+// the Pygments classes that are not told apart by their first letter
+var tokenClasses = map[string]string{
+	"kt": "tok-type", "nf": "tok-function", "fm": "tok-function",
+	"nc": "tok-class", "nb": "tok-builtin", "bp": "tok-builtin",
+	"nv": "tok-variable", "vc": "tok-variable", "vg": "tok-variable",
+	"vi": "tok-variable", "vm": "tok-variable", "no": "tok-constant",
+	"kc": "tok-constant", "ow": "tok-operator", "dl": "tok-string",
+	"il": "tok-number", "p": "tok-punctuation", "err": "tok-error",
+}
+
+// the Pygments classes by their first letter: Comment, Keyword, Name,
+// String, Number (`m`) and Operator
+var tokenFamilies = map[byte]string{
+	'c': "tok-comment", 'k': "tok-keyword", 'n': "tok-name",
+	's': "tok-string", 'm': "tok-number", 'o': "tok-operator",
+}
+
+// the stable class of the Pygments class `class`, if any
+func tokenClass(class string) string {
+	if c, ok := tokenClasses[class]; ok {
+		return c
+	}
+	return tokenFamilies[class[0]]
+}
+
+// add the stable classes to the token spans of `html`
+func addTokenClasses(html []byte) []byte {
+	if !config.TokenClasses {
+		return html
+	}
+	return tokenSpan.ReplaceAllFunc(html, func(span []byte) []byte {
+		class := string(tokenSpan.FindSubmatch(span)[1])
+		if tok := tokenClass(class); tok != "" {
+			return []byte(`<span class="` + class + " " + tok + `">`)
+		}
+		return span
+	})
+}
@@ -0,0 +1,158 @@
+package dappspec
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// ## Try it
+// With an RPC URL and the address a contract is deployed at, every view
+// or pure function of the contract, and every public variable, gets a
+// small form calling it with `eth_call`, so readers can look up current
+// values without leaving the docs. The calls are made by `try-it.js`,
+// which encodes the arguments and decodes the results for the elementary
+// ABI types; declarations taking anything else get no form. Nothing is
+// rendered in `--no-js` mode.
+
+// `TryIt` configures the call forms
+type TryIt struct {
+	// The JSON-RPC endpoint the calls go to
+	RPC string `json:"rpc,omitempty"`
+	// The address of each deployed contract, by name
+	Addresses map[string]string `json:"addresses,omitempty"`
+}
+
+// a `CallForm` is the form of one declaration
+type CallForm struct {
+	RPC      string
+	Address  string
+	Selector string
+	Params   []Param
+	// The canonical return types, comma separated
+	Returns string
+}
+
+const tryItScript = "try-it.js"
+
+var (
+	addressValues  = pairsFlag{}
+	addressPattern = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+	elementaryType = regexp.MustCompile(`^(?:address|bool|string|bytes|bytes([1-9]|[12][0-9]|3[0-2])|u?int(8|16|24|32|40|48|56|64|72|80|88|96|104|112|120|128|136|144|152|160|168|176|184|192|200|208|216|224|232|240|248|256)?)$`)
+	mappingType    = regexp.MustCompile(`^mapping\s*\(\s*(\w+)(?:\s+\w+)?\s*=>\s*(.*?)(?:\s+\w+)?\s*\)$`)
+)
+
+func tryItEnabled() bool {
+	return config.TryIt.RPC != "" && len(config.TryIt.Addresses) > 0 && !config.NoJS
+}
+
+func checkTryIt() error {
+	for contract, address := range config.TryIt.Addresses {
+		if !addressPattern.MatchString(address) {
+			return fmt.Errorf("tryIt: %q is not an address, for %s", address, contract)
+		}
+	}
+	if config.TryIt.RPC != "" {
+		if u, err := url.Parse(config.TryIt.RPC); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return fmt.Errorf("tryIt: the RPC URL %q is not an http(s) URL", config.TryIt.RPC)
+		}
+	}
+	return nil
+}
+
+// the origin the forms call, for `--csp`
+func tryItOrigins() []string {
+	if !tryItEnabled() {
+		return nil
+	}
+	u, _ := url.Parse(config.TryIt.RPC)
+	return []string{u.Scheme + "://" + u.Host}
+}
+
+// write the script the forms need
+func writeTryIt() error {
+	if !tryItEnabled() {
+		return nil
+	}
+	return writeOutput(filepath.Join(outputDir(), tryItScript), []byte(mustAsset("assets/"+tryItScript)), 0644)
+}
+
+// the form for a section, or nil
+func callForm(sym *Symbol) *CallForm {
+	if !tryItEnabled() || sym == nil || sym.Visibility != "public" && sym.Visibility != "external" {
+		return nil
+	}
+	address := config.TryIt.Addresses[sym.Contract]
+	if address == "" {
+		return nil
+	}
+	var params, returns []Param
+	switch {
+	case sym.Kind == "function" && (sym.Mutability == "view" || sym.Mutability == "pure"):
+		params, returns = sym.Params, sym.Returns
+	case sym.Kind == "variable":
+		var ok bool
+		if params, returns, ok = getter(sym); !ok {
+			return nil
+		}
+	default:
+		return nil
+	}
+	form := &CallForm{RPC: config.TryIt.RPC, Address: address}
+	types := make([]string, len(params))
+	for i, p := range params {
+		p.Type = canonicalType(p.Type)
+		if !elementaryType.MatchString(p.Type) {
+			return nil
+		}
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("arg%d", i)
+		}
+		types[i] = p.Type
+		form.Params = append(form.Params, p)
+	}
+	var out []string
+	for _, r := range returns {
+		out = append(out, canonicalType(r.Type))
+	}
+	form.Selector = selector(sym.Name + "(" + strings.Join(types, ",") + ")")
+	form.Returns = strings.Join(out, ",")
+	return form
+}
+
+// the parameters and return type of the getter of a public variable:
+// the keys of its mappings and indexes of its arrays, and what they hold
+func getter(sym *Symbol) (params, returns []Param, ok bool) {
+	decl := sym.Signature
+	if i := assignment(decl); i >= 0 {
+		decl = decl[:i]
+	}
+	decl = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(decl), ";"))
+	decl = strings.TrimSpace(strings.TrimSuffix(decl, sym.Name))
+	var words []string
+	for _, w := range strings.Fields(decl) {
+		switch w {
+		case "public", "constant", "immutable", "override":
+		default:
+			words = append(words, w)
+		}
+	}
+	t := strings.Join(words, " ")
+	for {
+		if m := mappingType.FindStringSubmatch(t); m != nil {
+			params = append(params, Param{Type: m[1]})
+			t = strings.TrimSpace(m[2])
+		} else if i := strings.LastIndexByte(t, '['); i > 0 && strings.HasSuffix(t, "]") {
+			params = append(params, Param{Type: "uint256"})
+			t = strings.TrimSpace(t[:i])
+		} else {
+			break
+		}
+	}
+	if !elementaryType.MatchString(canonicalType(t)) {
+		return nil, nil, false
+	}
+	return params, []Param{{Type: t}}, true
+}
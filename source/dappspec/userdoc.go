@@ -0,0 +1,212 @@
+package dappspec
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/sambacha/go-natspec/v2/natspec"
+)
+
+// ## userdoc and devdoc
+// Wallets, verifiers and block explorers already read NatSpec the way
+// solc hands it out: `--format natspec` writes `docs/natspec/<name>.json`
+// with the `userdoc` and `devdoc` of every contract of a Solidity file,
+// in the schema of `solc --userdoc --devdoc`, and `--format json` has the
+// same objects under `contracts`. Functions, events and
+// errors are keyed by their canonical signature (`transfer(address,uint256)`),
+// with contracts, enums, structs and value types written as their ABI
+// types, as solc does (`swap(IERC20)` is `swap(address)`); a declaration
+// with a type not declared in the sources is left out. The constructor
+// is keyed as `constructor`, and public state variables go under
+// `stateVariables` of the devdoc. Returns are keyed by the name of the
+// return variable, or `_0`, `_1`... for unnamed ones. Unlike solc,
+// `@inheritdoc` is not followed, and the notices of public state
+// variables are not listed under their getters.
+
+// the `userdoc` and `devdoc` of a contract
+type ContractDocs struct {
+	Userdoc map[string]interface{} `json:"userdoc"`
+	Devdoc  map[string]interface{} `json:"devdoc"`
+}
+
+// `natspecRenderer` writes `docs/natspec/<name>.json`
+type natspecRenderer struct{}
+
+func (natspecRenderer) Name() string { return "natspec" }
+
+func (natspecRenderer) Render(doc *Document) ([]string, error) {
+	if !strings.HasSuffix(doc.Source, ".sol") {
+		return nil, nil
+	}
+	contracts := contractDocs(doc)
+	if len(contracts) == 0 {
+		return nil, nil
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(contracts); err != nil {
+		return nil, err
+	}
+	dest := formatDestination(doc.Source, "natspec", ".json")
+	return writeFormat(doc.Source, dest, b.Bytes())
+}
+
+func (natspecRenderer) Finish() error { return nil }
+
+// the docs of every contract, interface and library of `doc`, by name,
+// worked out once for all the formats that have them
+func contractDocs(doc *Document) map[string]*ContractDocs {
+	if doc.natspec != nil {
+		return doc.natspec
+	}
+	contracts := map[string]*ContractDocs{}
+	doc.natspec = contracts
+	contract := func(name string) *ContractDocs {
+		c, ok := contracts[name]
+		if !ok {
+			c = &ContractDocs{
+				Userdoc: map[string]interface{}{"kind": "user", "version": 1, "methods": map[string]interface{}{}},
+				Devdoc:  map[string]interface{}{"kind": "dev", "version": 1, "methods": map[string]interface{}{}},
+			}
+			contracts[name] = c
+		}
+		return c
+	}
+	// the metadata were taken off the first contract
+	metadata := doc.Metadata
+	for _, sec := range sectionViews(doc) {
+		sym := sec.symbol
+		if sym == nil || sec.collapsed {
+			continue
+		}
+		tags := parseTags(sec.docsText)
+		if sym.IsUnit() && len(tags) == 0 && metadata != nil {
+			for _, entry := range metadata {
+				tags = append(tags, Tag{Name: entry.Name, Text: entry.Text})
+			}
+			metadata = nil
+		}
+		if sym.IsUnit() {
+			c := contract(sym.Name)
+			if len(tags) == 0 {
+				continue
+			}
+			d, err := natspec.FromTags(tags)
+			if err != nil {
+				lintWarn("natspec", "%s: %s: %v", doc.Source, sym.Name, err)
+				continue
+			}
+			setText(c.Userdoc, "notice", d.Notice)
+			setText(c.Devdoc, "title", d.Title)
+			setText(c.Devdoc, "author", d.Author)
+			setText(c.Devdoc, "details", d.Dev)
+			setCustom(c.Devdoc, d)
+			continue
+		}
+		if sym.Contract == "" || len(tags) == 0 {
+			continue
+		}
+		var key, kind string
+		switch {
+		case sym.Kind == "constructor":
+			key, kind = "constructor", "methods"
+		case sym.Kind == "function" && (sym.Visibility == "external" || sym.Visibility == "public"):
+			kind = "methods"
+		case sym.Kind == "event":
+			kind = "events"
+		case sym.Kind == "error":
+			kind = "errors"
+		case sym.Kind == "variable" && sym.Visibility == "public":
+			key, kind = sym.Name, "stateVariables"
+		default:
+			continue
+		}
+		if key == "" {
+			var ok bool
+			if key, ok = abiSignature(sym); !ok {
+				lintWarn("natspec", "%s: %s has a parameter of a type not declared in the sources, so it is left out", doc.Source, sym.Canonical())
+				continue
+			}
+		}
+		d, err := natspec.FromTags(tags)
+		if err != nil {
+			lintWarn("natspec", "%s: %s: %v", doc.Source, key, err)
+			continue
+		}
+		c := contract(sym.Contract)
+		if d.Notice != "" && kind != "stateVariables" {
+			addEntry(c.Userdoc, kind, key, map[string]interface{}{"notice": d.Notice})
+		}
+		if entry := devEntry(d, sym); len(entry) > 0 {
+			addEntry(c.Devdoc, kind, key, entry)
+		}
+	}
+	return contracts
+}
+
+// the devdoc of a declaration
+func devEntry(d *natspec.DocComment, sym *Symbol) map[string]interface{} {
+	entry := map[string]interface{}{}
+	setText(entry, "details", d.Dev)
+	if len(d.Params) > 0 {
+		params := map[string]string{}
+		for _, p := range d.Params {
+			params[p.Name] = p.Text
+		}
+		entry["params"] = params
+	}
+	if len(d.Returns) > 0 {
+		returns := map[string]string{}
+		for i, text := range d.Returns {
+			name := fmt.Sprintf("_%d", i)
+			if i < len(sym.Returns) && sym.Returns[i].Name != "" {
+				name = sym.Returns[i].Name
+				if first, rest, _ := strings.Cut(text, " "); first == name {
+					text = strings.TrimSpace(rest)
+				}
+			}
+			returns[name] = text
+		}
+		entry["returns"] = returns
+		// a state variable has one return, which solc also spells out
+		// by itself
+		if sym.Kind == "variable" && len(returns) == 1 {
+			entry["return"] = d.Returns[0]
+		}
+	}
+	setCustom(entry, d)
+	return entry
+}
+
+// add `entry` as `key` of the `kind` of a userdoc or devdoc. Errors can
+// be declared more than once, so solc lists theirs.
+func addEntry(docs map[string]interface{}, kind, key string, entry map[string]interface{}) {
+	entries, ok := docs[kind].(map[string]interface{})
+	if !ok {
+		entries = map[string]interface{}{}
+		docs[kind] = entries
+	}
+	if kind == "errors" {
+		list, _ := entries[key].([]map[string]interface{})
+		entries[key] = append(list, entry)
+		return
+	}
+	entries[key] = entry
+}
+
+func setText(entry map[string]interface{}, name, text string) {
+	if text != "" {
+		entry[name] = text
+	}
+}
+
+// the `@custom:` tags, as `custom:name`
+func setCustom(entry map[string]interface{}, d *natspec.DocComment) {
+	for name, text := range d.Custom {
+		entry["custom:"+name] = text
+	}
+}
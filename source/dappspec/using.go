@@ -0,0 +1,84 @@
+package dappspec
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ## Libraries attached with `using`
+// `using SafeERC20 for IERC20;` is how a contract takes on a library, and
+// neither side says so in its docs. The section with the directive links
+// to the library ("Uses SafeERC20 for IERC20"), and the library's section
+// lists every contract attaching it and to what type. Like backlinks, this
+// needs every page, so sources with `using` directives are parsed up front.
+
+var usingMatcher = regexp.MustCompile(`\busing\s+([A-Za-z_$][\w$.]*)\s+for\s+([^;]+);`)
+
+// a `using` directive found on a page
+type usingSite struct {
+	at       Anchor
+	source   string
+	contract string
+	library  string
+	// The type the library is attached to, `*` for every type
+	target string
+}
+
+// every `using` directive in the build, in source order
+var usingSites []usingSite
+
+// record the `using` directives in the sections of `source`
+func scanUsing(source string, views []SectionView) {
+	for _, sec := range views {
+		if sec.collapsed {
+			continue
+		}
+		for _, m := range usingMatcher.FindAllSubmatch(sec.codeText, -1) {
+			target := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(string(m[2])), "global"))
+			usingSites = append(usingSites, usingSite{
+				at:       Anchor{pageOf(source), "section-" + sec.Tag},
+				source:   source,
+				contract: sec.unit,
+				library:  string(m[1]),
+				target:   spaceMatcher.ReplaceAllString(target, " "),
+			})
+		}
+	}
+}
+
+// the libraries the section `id` of `page` attaches, linked when they are
+// documented
+func usesOf(page, id string) []Backlink {
+	var links []Backlink
+	for _, u := range usingSites {
+		if u.at != (Anchor{page, id}) {
+			continue
+		}
+		link := Backlink{Label: u.library + " for " + u.target}
+		if lib, ok := resolveReference(page, u.library); ok {
+			link.Href = lib.href(page)
+		}
+		links = append(links, link)
+	}
+	return links
+}
+
+// the contracts attaching the library declared by the section `id` of
+// `page`
+func usedByOf(page, id string, sym *Symbol) []Backlink {
+	if sym == nil || sym.Kind != "library" {
+		return nil
+	}
+	var links []Backlink
+	for _, u := range usingSites {
+		if lib, ok := resolveReference(u.at.Page, u.library); !ok || lib != (Anchor{page, id}) {
+			continue
+		}
+		label := u.contract
+		if label == "" {
+			label = pageTitle(u.source)
+		}
+		links = append(links, Backlink{u.at.href(page), label + " for " + u.target})
+	}
+	return links
+}
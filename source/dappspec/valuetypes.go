@@ -0,0 +1,105 @@
+package dappspec
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ## User-defined value types
+// `type Price is uint256;` declares a type of its own, documented like any
+// other declaration. Its section says what it wraps, and wherever the type
+// appears in the code of the docs, in a signature or a mapping, the name
+// links back to the declaration: on the same page if it declares the type,
+// or else the first page that does.
+
+var (
+	valueTypeDeclaration = regexp.MustCompile(`(?m)^\s*type\s+\w+\s+is\b`)
+	valueTypeUnderlying  = regexp.MustCompile(`^type\s+\w+\s+is\s+([\w.]+)`)
+	// the text between the tags of highlighted code
+	codeText = regexp.MustCompile(`>[^<]+<`)
+)
+
+// Filled before any page is rendered and only read after.
+var (
+	// the sections declaring each value type, in source order
+	valueTypes = map[string][]Anchor{}
+	// matches the names of all of them
+	valueTypeNames *regexp.Regexp
+	// a digest of the above, for the cache
+	valueTypesKey string
+)
+
+// the type a value type wraps, from its signature
+func underlyingType(sym *Symbol) string {
+	if m := valueTypeUnderlying.FindStringSubmatch(sym.Signature); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// find the value types every source declares. Only the files declaring one
+// are parsed.
+func scanValueTypes(files []string) {
+	valueTypes, valueTypeNames, valueTypesKey = map[string][]Anchor{}, nil, ""
+	for _, source := range files {
+		code, err := provider.Read(source)
+		if err != nil || !valueTypeDeclaration.Match(code) {
+			continue
+		}
+		doc, err := scanDocument(source)
+		if err != nil {
+			continue
+		}
+		for _, sec := range sectionViews(doc) {
+			if sym := sec.symbol; sym != nil && sym.Kind == "type" && !sec.collapsed {
+				valueTypes[sym.Name] = append(valueTypes[sym.Name], Anchor{pageOf(source), "section-" + sec.Tag})
+			}
+		}
+	}
+	if len(valueTypes) == 0 {
+		return
+	}
+	names := make([]string, 0, len(valueTypes))
+	for name := range valueTypes {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Strings(names)
+	valueTypeNames = regexp.MustCompile(`\b(?:` + strings.Join(names, "|") + `)\b`)
+	b, _ := json.Marshal(valueTypes)
+	sum := sha256.Sum256(b)
+	valueTypesKey = hex.EncodeToString(sum[:])
+}
+
+// the declaration of the value type `name` seen from `page`
+func valueTypeAnchor(page, name string) Anchor {
+	anchors := valueTypes[name]
+	for _, at := range anchors {
+		if at.Page == page {
+			return at
+		}
+	}
+	return anchors[0]
+}
+
+// link the value types in the highlighted code of the section `id` of
+// `page` to their declarations
+func linkValueTypes(page, id string, html []byte) []byte {
+	if valueTypeNames == nil {
+		return html
+	}
+	return codeText.ReplaceAllFunc(html, func(text []byte) []byte {
+		return valueTypeNames.ReplaceAllFunc(text, func(name []byte) []byte {
+			at := valueTypeAnchor(page, string(name))
+			if at == (Anchor{page, id}) {
+				return name
+			}
+			return []byte(fmt.Sprintf(`<a class="type-link" href="%s" title="%s is a user-defined value type">%s</a>`,
+				at.href(page), name, name))
+		})
+	})
+}
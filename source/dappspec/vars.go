@@ -0,0 +1,49 @@
+package dappspec
+
+import (
+	"container/list"
+	"fmt"
+	"regexp"
+)
+
+// ## Variables
+// Values that change from one deployment to the next, like addresses,
+// versions or chain names, are better kept out of the comments. A
+// `{{var "protocolName"}}` in docs (or in a guide) is replaced by the
+// value of `protocolName` under `vars` in the config file, or given with
+// `--var protocolName=Acme`. A variable without a value fails the file.
+
+var varPlaceholder = regexp.MustCompile(`\{\{\s*var\s+"([^"]+)"\s*\}\}`)
+
+// `text` with its variables replaced
+func substituteVars(text []byte) ([]byte, error) {
+	var failed error
+	out := varPlaceholder.ReplaceAllFunc(text, func(m []byte) []byte {
+		name := string(varPlaceholder.FindSubmatch(m)[1])
+		value, ok := config.Vars[name]
+		if !ok {
+			if failed == nil {
+				failed = fmt.Errorf("no value for {{var %q}} (set it under \"vars\" or with --var)", name)
+			}
+			return m
+		}
+		return []byte(value)
+	})
+	return out, failed
+}
+
+// replace the variables in the docs of every section
+func substituteSections(sections *list.List) error {
+	for e := sections.Front(); e != nil; e = e.Next() {
+		sec := e.Value.(*Section)
+		if !varPlaceholder.Match(sec.docsText) {
+			continue
+		}
+		docs, err := substituteVars(sec.docsText)
+		if err != nil {
+			return err
+		}
+		sec.docsText = docs
+	}
+	return nil
+}
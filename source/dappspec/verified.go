@@ -0,0 +1,166 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+)
+
+// ## Verified sources
+// `dappspec fetch-verified --address 0x... [--chain 1] [flags]` documents
+// a deployed contract from its verified source: Sourcify first, then
+// Etherscan (with `ETHERSCAN_API_KEY`, or `--api-key`) if Sourcify does
+// not have it. `--from sourcify` or `--from etherscan` asks just one.
+// Multi-file verifications keep their paths.
+
+const (
+	sourcifyURL  = "https://sourcify.dev/server/files/any/%s/%s"
+	etherscanURL = "https://api.etherscan.io/v2/api?chainid=%s&module=contract&action=getsourcecode&address=%s&apikey=%s"
+)
+
+// take `--name value` or `--name=value` out of `args`
+func takeFlag(args []string, name string) (string, []string) {
+	value := ""
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := strings.TrimPrefix(strings.TrimPrefix(args[i], "-"), "-")
+		switch {
+		case arg == name && i+1 < len(args):
+			value = args[i+1]
+			i++
+		case strings.HasPrefix(arg, name+"="):
+			value = arg[len(name)+1:]
+		default:
+			rest = append(rest, args[i])
+		}
+	}
+	return value, rest
+}
+
+func runFetchVerified(args []string) error {
+	address, args := takeFlag(args, "address")
+	chain, args := takeFlag(args, "chain")
+	from, args := takeFlag(args, "from")
+	apiKey, args := takeFlag(args, "api-key")
+	if address == "" {
+		return fmt.Errorf("fetch-verified needs --address")
+	}
+	if chain == "" {
+		chain = "1"
+	}
+	if apiKey == "" {
+		apiKey = os.Getenv("ETHERSCAN_API_KEY")
+	}
+	if err := commandLine.Parse(args); err != nil {
+		return err
+	}
+
+	var files map[string][]byte
+	var err error
+	switch from {
+	case "sourcify":
+		files, err = fromSourcify(chain, address)
+	case "etherscan":
+		files, err = fromEtherscan(chain, address, apiKey)
+	case "":
+		if files, err = fromSourcify(chain, address); err != nil && apiKey != "" {
+			files, err = fromEtherscan(chain, address, apiKey)
+		}
+	default:
+		return fmt.Errorf("unknown --from %q, want sourcify or etherscan", from)
+	}
+	if err != nil {
+		return err
+	}
+	src := &archiveProvider{files: map[string][]byte{}}
+	for name, code := range files {
+		if getLanguage(name) != nil {
+			src.files[path.Clean(strings.TrimPrefix(name, "/"))] = code
+		}
+	}
+	if len(src.files) == 0 {
+		return fmt.Errorf("no sources found for %s on chain %s", address, chain)
+	}
+	configure()
+	generateFrom(src, commandLine.Args())
+	return nil
+}
+
+// the files of a Sourcify verification, full or partial match
+func fromSourcify(chain, address string) (map[string][]byte, error) {
+	b, err := download(fmt.Sprintf(sourcifyURL, chain, address))
+	if err != nil {
+		return nil, fmt.Errorf("sourcify: %v", err)
+	}
+	var resp struct {
+		Files []struct {
+			Path    string `json:"path"`
+			Content string `json:"content"`
+		} `json:"files"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return nil, fmt.Errorf("sourcify: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, f := range resp.Files {
+		// repository paths end in `.../<address>/sources/<source path>`
+		name := f.Path
+		if i := strings.Index(name, "/sources/"); i >= 0 {
+			name = name[i+len("/sources/"):]
+		}
+		files[name] = []byte(f.Content)
+	}
+	return files, nil
+}
+
+// the files of an Etherscan verification. Its `SourceCode` is either the
+// single file itself, a JSON map of files, or standard JSON input wrapped
+// in an extra pair of braces.
+func fromEtherscan(chain, address, apiKey string) (map[string][]byte, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("etherscan needs an API key (ETHERSCAN_API_KEY or --api-key)")
+	}
+	b, err := download(fmt.Sprintf(etherscanURL, chain, address, apiKey))
+	if err != nil {
+		return nil, fmt.Errorf("etherscan: %v", err)
+	}
+	var resp struct {
+		Status string `json:"status"`
+		Result []struct {
+			SourceCode   string `json:"SourceCode"`
+			ContractName string `json:"ContractName"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(b, &resp); err != nil || len(resp.Result) == 0 {
+		return nil, fmt.Errorf("etherscan: unexpected response for %s", address)
+	}
+	result := resp.Result[0]
+	if result.SourceCode == "" {
+		return nil, fmt.Errorf("etherscan: %s is not verified", address)
+	}
+	code := strings.TrimSpace(result.SourceCode)
+	if !strings.HasPrefix(code, "{") {
+		return map[string][]byte{result.ContractName + ".sol": []byte(code)}, nil
+	}
+	if strings.HasPrefix(code, "{{") {
+		code = code[1 : len(code)-1]
+	}
+	var input struct {
+		Sources map[string]struct {
+			Content string `json:"content"`
+		} `json:"sources"`
+	}
+	if err := json.Unmarshal([]byte(code), &input); err != nil || input.Sources == nil {
+		// not standard JSON input, but the bare map of sources
+		if err := json.Unmarshal([]byte(code), &input.Sources); err != nil {
+			return nil, fmt.Errorf("etherscan: %v", err)
+		}
+	}
+	files := map[string][]byte{}
+	for name, src := range input.Sources {
+		files[name] = []byte(src.Content)
+	}
+	return files, nil
+}
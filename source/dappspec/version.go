@@ -0,0 +1,104 @@
+package dappspec
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+)
+
+// ## Version
+// Release builds get these injected through
+// `-ldflags "-X github.com/sambacha/go-natspec/v2/dappspec.version=..."`,
+// everything else falls back to whatever `runtime/debug` knows about the
+// module that produced the binary.
+
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// where releases are published, used by the optional update check
+const releasesURL = "https://api.github.com/repos/sambacha/go-natspec/releases/latest"
+
+// fill in anything the linker did not set from the embedded build info
+func resolveVersion() {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	if version == "" && info.Main.Version != "" {
+		version = info.Main.Version
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			if commit == "" {
+				commit = setting.Value
+			}
+		case "vcs.time":
+			if date == "" {
+				date = setting.Value
+			}
+		}
+	}
+}
+
+// the short form used in the generated pages
+func versionString() string {
+	if version == "" {
+		return "(devel)"
+	}
+	return version
+}
+
+// the long form printed by `--version`
+func versionInfo() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "dappspec %s", versionString())
+	if commit != "" {
+		rev := commit
+		if len(rev) > 12 {
+			rev = rev[:12]
+		}
+		fmt.Fprintf(&b, " (%s", rev)
+		if date != "" {
+			fmt.Fprintf(&b, ", %s", date)
+		}
+		b.WriteString(")")
+	}
+	fmt.Fprintf(&b, " %s %s/%s", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	return b.String()
+}
+
+// `checkUpdate` asks GitHub for the latest release and reports whether
+// it differs from the running build. It is only ever called when
+// `--check-update` is given, so offline builds never touch the network.
+func checkUpdate() (string, error) {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(releasesURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("checking for updates: %s", resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	current := strings.TrimPrefix(versionString(), "v")
+	latest := strings.TrimPrefix(release.TagName, "v")
+	if latest == "" || latest == current {
+		return fmt.Sprintf("dappspec %s is up to date", versionString()), nil
+	}
+	return fmt.Sprintf("dappspec %s is available (running %s): %s", release.TagName, versionString(), release.HTMLURL), nil
+}
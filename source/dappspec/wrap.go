@@ -0,0 +1,28 @@
+package dappspec
+
+import (
+	"bytes"
+)
+
+// ## Long lines
+// Long lines (`abi.encode` calls, long signatures) scroll horizontally
+// inside their own code block by default, instead of stretching the whole
+// table. With `--code-wrap wrap` they soft-wrap instead: every line is
+// put in its own block with a hanging indent, and a bar in the gutter
+// marks the continuation lines.
+
+// `wrapLines` puts each line of highlighted code in a `<span class="line">`
+func wrapLines(html []byte) []byte {
+	lines := bytes.Split(html, []byte("\n"))
+	// keep the trailing newline out of the last line
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	buf := new(bytes.Buffer)
+	for _, line := range lines {
+		buf.WriteString(`<span class="line">`)
+		buf.Write(line)
+		buf.WriteString("\n</span>")
+	}
+	return buf.Bytes()
+}
@@ -2,4 +2,11 @@ module github.com/sambacha/go-natspec/v2
 
 go 1.20
 
-require github.com/russross/blackfriday v1.6.0
+require (
+	github.com/alecthomas/chroma/v2 v2.15.0
+	github.com/andybalholm/brotli v1.0.6
+	github.com/russross/blackfriday v1.6.0
+	golang.org/x/image v0.14.0
+)
+
+require github.com/dlclark/regexp2 v1.11.4 // indirect
@@ -0,0 +1,9 @@
+// The `dappspec` command. Everything it does is in the `dappspec` package,
+// which other programs can import to document projects of their own.
+package main
+
+import "github.com/sambacha/go-natspec/v2/dappspec"
+
+func main() {
+	dappspec.Main()
+}
@@ -0,0 +1,169 @@
+// Package natspec parses NatSpec, the doc comments of Solidity.
+//
+// `Tags` splits the text of a comment into its tags the way dappspec
+// reads them, leniently; `Parse` checks them the way solc does and sorts
+// them into a `DocComment`:
+//
+//	doc, err := natspec.Parse([]byte(`/// @notice Sends tokens
+//	/// @param to the recipient`))
+//	// doc.Notice == "Sends tokens", doc.Params[0].Name == "to"
+package natspec
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// a `Tag` is a single NatSpec tag and its (possibly multi-line) text
+type Tag struct {
+	// `title`, `notice`, `param`, `custom:foo`, ...
+	Name string
+	Text string
+}
+
+// a `Param` is what a `@param` tag says of a parameter
+type Param struct {
+	Name string
+	Text string
+}
+
+// a `DocComment` is a comment's tags by kind. Tags given more than once
+// are joined by new lines, except `@param` and `@return`, which are kept
+// in order.
+type DocComment struct {
+	Title  string
+	Author string
+	Notice string
+	Dev    string
+	Params []Param
+	// The text of each `@return`, which starts with the name of the
+	// returned variable if it has one
+	Returns []string
+	// The contract named by `@inheritdoc`
+	Inheritdoc string
+	// `@custom:name` tags, by name without `custom:`
+	Custom map[string]string
+	// Every tag, in order
+	Tags []Tag
+}
+
+var (
+	tagMatcher = regexp.MustCompile(`^\s*@([\w:-]+)\s?(.*)$`)
+	// the markers starting a line of a comment, and ending a block
+	lineMarker = regexp.MustCompile(`^\s*(?:///|/\*\*|\*/|\*(?:\s|$))`)
+	blockEnd   = regexp.MustCompile(`\s*\*/\s*$`)
+	customName = regexp.MustCompile(`^custom:[a-z][a-z-]*$`)
+)
+
+// Tags splits doc text into tags. Text before the first tag is an
+// implicit `@notice`, as in solc.
+func Tags(docs []byte) []Tag {
+	var tags []Tag
+	for _, line := range strings.Split(string(docs), "\n") {
+		if m := tagMatcher.FindStringSubmatch(line); m != nil {
+			tags = append(tags, Tag{m[1], strings.TrimSpace(m[2])})
+			continue
+		}
+		line = strings.TrimSpace(line)
+		if len(tags) == 0 {
+			if line == "" {
+				continue
+			}
+			tags = append(tags, Tag{Name: "notice"})
+		}
+		last := &tags[len(tags)-1]
+		if last.Text != "" {
+			last.Text += "\n"
+		}
+		last.Text += line
+	}
+	for i := range tags {
+		tags[i].Text = strings.TrimSpace(tags[i].Text)
+	}
+	return tags
+}
+
+// TagLine splits a line starting with a tag into the tag's name and the
+// rest of the line, as it is
+func TagLine(line string) (name, text string, ok bool) {
+	m := tagMatcher.FindStringSubmatch(line)
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
+
+// HasTags reports whether any line of the docs starts with a NatSpec tag
+func HasTags(docs []byte) bool {
+	for _, line := range strings.Split(string(docs), "\n") {
+		if tagMatcher.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// Strip returns the text of a comment without its `///`, `/**`, `*/`
+// and leading `*` markers
+func Strip(comment []byte) []byte {
+	lines := strings.Split(string(comment), "\n")
+	for i, line := range lines {
+		line = blockEnd.ReplaceAllString(line, "")
+		lines[i] = lineMarker.ReplaceAllString(line, "")
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// Parse reads a doc comment, with or without its comment markers, into
+// its tags. Like solc, it fails on tags NatSpec does not have and on
+// `@param` without a name.
+func Parse(comment []byte) (*DocComment, error) {
+	return FromTags(Tags(Strip(comment)))
+}
+
+// FromTags reads tags split by Tags into a DocComment, failing as Parse
+// does
+func FromTags(tags []Tag) (*DocComment, error) {
+	doc := &DocComment{Tags: tags}
+	join := func(to *string, text string) {
+		if *to != "" {
+			*to += "\n"
+		}
+		*to += text
+	}
+	for _, tag := range doc.Tags {
+		switch tag.Name {
+		case "title":
+			join(&doc.Title, tag.Text)
+		case "author":
+			join(&doc.Author, tag.Text)
+		case "notice":
+			join(&doc.Notice, tag.Text)
+		case "dev":
+			join(&doc.Dev, tag.Text)
+		case "param":
+			fields := strings.Fields(tag.Text)
+			if len(fields) == 0 {
+				return nil, fmt.Errorf("natspec: @param without a name")
+			}
+			doc.Params = append(doc.Params, Param{fields[0], strings.TrimSpace(strings.TrimPrefix(tag.Text, fields[0]))})
+		case "return":
+			doc.Returns = append(doc.Returns, tag.Text)
+		case "inheritdoc":
+			doc.Inheritdoc = tag.Text
+		default:
+			if !customName.MatchString(tag.Name) {
+				return nil, fmt.Errorf("natspec: unknown tag @%s", tag.Name)
+			}
+			if doc.Custom == nil {
+				doc.Custom = map[string]string{}
+			}
+			name := strings.TrimPrefix(tag.Name, "custom:")
+			text := doc.Custom[name]
+			join(&text, tag.Text)
+			doc.Custom[name] = text
+		}
+	}
+	return doc, nil
+}
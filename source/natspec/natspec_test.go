@@ -0,0 +1,170 @@
+package natspec
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		comment string
+		want    DocComment
+	}{
+		{
+			name:    "implicit notice",
+			comment: "/// Sends tokens",
+			want:    DocComment{Notice: "Sends tokens"},
+		},
+		{
+			name: "multi-line dev",
+			comment: `/// @notice Sends tokens
+/// @dev Checks the balance first,
+/// then moves it.
+///
+/// Reverts on overflow.`,
+			want: DocComment{Notice: "Sends tokens", Dev: "Checks the balance first,\nthen moves it.\n\nReverts on overflow."},
+		},
+		{
+			name: "block comment",
+			comment: `/**
+ * @title A token
+ * @author Alice
+ * @dev Not audited
+ */`,
+			want: DocComment{Title: "A token", Author: "Alice", Dev: "Not audited"},
+		},
+		{
+			name: "repeated params and returns, in order",
+			comment: `/// @param to the recipient
+/// @param amount how much,
+/// in wei
+/// @return ok whether it worked
+/// @return the new balance`,
+			want: DocComment{
+				Params:  []Param{{"to", "the recipient"}, {"amount", "how much,\nin wei"}},
+				Returns: []string{"ok whether it worked", "the new balance"},
+			},
+		},
+		{
+			name:    "repeated notices are joined",
+			comment: "/// @notice Sends tokens\n/// @notice to anyone",
+			want:    DocComment{Notice: "Sends tokens\nto anyone"},
+		},
+		{
+			name:    "inheritdoc",
+			comment: "/// @inheritdoc IERC20",
+			want:    DocComment{Inheritdoc: "IERC20"},
+		},
+		{
+			name:    "custom tags",
+			comment: "/// @custom:security-contact security@example.com\n/// @custom:example transfer(to, 1)\n/// @custom:example transfer(to, 2)",
+			want: DocComment{Custom: map[string]string{
+				"security-contact": "security@example.com",
+				"example":          "transfer(to, 1)\ntransfer(to, 2)",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.comment))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got.Tags = nil
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("Parse(%q) =\n%+v, want\n%+v", tt.comment, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		comment string
+		err     string
+	}{
+		{"/// @notice Sends\n/// @returns the balance", "unknown tag @returns"},
+		{"/// @since 1.0", "unknown tag @since"},
+		{"/// @custom:Upper case", "unknown tag @custom:Upper"},
+		{"/// @custom: nothing", "unknown tag @custom:"},
+		{"/// @param", "@param without a name"},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.comment))
+		if err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) = %v, want an error with %q", tt.comment, err, tt.err)
+		}
+	}
+}
+
+func TestTags(t *testing.T) {
+	// unknown tags are kept, not checked
+	got := Tags([]byte("Sends tokens\n@since 1.0\n@custom:Odd x\n\n@param to the\n  recipient"))
+	want := []Tag{
+		{"notice", "Sends tokens"},
+		{"since", "1.0"},
+		{"custom:Odd", "x"},
+		{"param", "to the\nrecipient"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Tags = %+v, want %+v", got, want)
+	}
+	if got := Tags([]byte("\n  \n")); got != nil {
+		t.Errorf("Tags of blank docs = %+v", got)
+	}
+}
+
+func TestTagLine(t *testing.T) {
+	tests := []struct {
+		line, name, text string
+		ok               bool
+	}{
+		{" @param to  the recipient ", "param", "to  the recipient ", true},
+		{"@custom:example x", "custom:example", "x", true},
+		{"an @notice in the middle", "", "", false},
+	}
+	for _, tt := range tests {
+		name, text, ok := TagLine(tt.line)
+		if name != tt.name || text != tt.text || ok != tt.ok {
+			t.Errorf("TagLine(%q) = %q, %q, %v", tt.line, name, text, ok)
+		}
+	}
+}
+
+func TestStripAndHasTags(t *testing.T) {
+	tests := []struct {
+		comment  string
+		stripped string
+		tags     bool
+	}{
+		{"/// @notice Sends\n/// tokens", " @notice Sends\n tokens", true},
+		{"/**\n * @dev Checks\n *\n */", "\n@dev Checks\n\n", true},
+		{"/** @notice One line */", " @notice One line", true},
+		{"/// Just prose, mentioning an @notice", " Just prose, mentioning an @notice", false},
+		{"/// a list\n/// * not a marker", " a list\n * not a marker", false},
+		{"///@param to", "@param to", true},
+	}
+	for _, tt := range tests {
+		stripped := Strip([]byte(tt.comment))
+		if string(stripped) != tt.stripped {
+			t.Errorf("Strip(%q) = %q, want %q", tt.comment, stripped, tt.stripped)
+		}
+		if HasTags(stripped) != tt.tags {
+			t.Errorf("HasTags(%q) = %v, want %v", stripped, !tt.tags, tt.tags)
+		}
+		// written back as a `///` comment, the text strips to itself
+		lines := strings.Split(string(stripped), "\n")
+		for i, line := range lines {
+			lines[i] = "///" + line
+		}
+		again := Strip([]byte(strings.Join(lines, "\n")))
+		if string(again) != string(stripped) || HasTags(again) != tt.tags {
+			t.Errorf("Strip of %q commented again = %q", stripped, again)
+		}
+		if !reflect.DeepEqual(Tags(again), Tags(stripped)) {
+			t.Errorf("Tags of %q commented again = %+v", stripped, Tags(again))
+		}
+	}
+}